@@ -2,10 +2,17 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -16,33 +23,117 @@ import (
 
 	_ "my-vault/docs"
 	"my-vault/internal/handlers"
+	"my-vault/internal/models"
 	"my-vault/internal/repository"
 	"my-vault/internal/services"
+	"my-vault/internal/utils"
 )
 
 func main() {
+	devMode := flag.Bool("dev", false, "run a throwaway in-memory vault, unlocked with a printed root password and wiped on exit")
+	flag.Parse()
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using system environment variables")
 	}
 
-	// Initialize database connection
-	db, err := repository.NewPostgresDB()
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	// Schema migrations run as a subcommand instead of the server
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(flag.Args()[1:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
 	}
-	defer db.Close()
 
-	// Initialize repositories
-	secretRepo := repository.NewSecretRepository(db)
+	// Open the configured storage backend, or an empty in-memory vault in dev mode
+	store, err := openStorage(*devMode)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	defer store.close()
 
 	// Initialize services
-	vaultService := services.NewVaultService()
-	secretService := services.NewSecretService(secretRepo, vaultService)
+	vaultService := services.NewVaultService(store.vault)
+	policyService := services.NewPolicyService(store.policies)
+	secretService := services.NewSecretService(store.secrets, vaultService, policyService)
+	history := versionRetention()
+	secretService.SetVersionRetention(history)
+
+	// Record reveals of secret values in the audit log, or the server log
+	if path := os.Getenv("AUDIT_LOG_PATH"); path != "" {
+		auditor, err := services.NewFileAuditor(path)
+		if err != nil {
+			log.Fatalf("Failed to open audit log: %v", err)
+		}
+		defer auditor.Close()
+		secretService.SetAuditor(auditor)
+	}
+
+	// Initialize encrypted attachment storage; dev mode uses a temporary directory
+	attachmentsDir := getEnv("ATTACHMENTS_DIR", "./data/attachments")
+	if *devMode {
+		attachmentsDir, err = os.MkdirTemp("", "vaultbox-dev-")
+		if err != nil {
+			log.Fatalf("Failed to create dev attachment directory: %v", err)
+		}
+		defer os.RemoveAll(attachmentsDir)
+	}
+	// The vault file does not hold attachment contents, so one copied without
+	// its attachments directory would list attachments that cannot be opened
+	if store.requireAttachmentsDir {
+		if info, err := os.Stat(attachmentsDir); err != nil || !info.IsDir() {
+			log.Fatalf("Attachments directory %s not found: copy it along with the vault file, or create it empty if the vault has no attachments", attachmentsDir)
+		}
+	}
+	blobs, err := repository.NewBlobStore(attachmentsDir)
+	if err != nil {
+		log.Fatalf("Failed to initialize attachment storage: %v", err)
+	}
+	secretService.SetAttachments(blobs, attachmentLimits())
+
+	// Open the local breached passwords dataset, if one is configured
+	if path := os.Getenv("HIBP_PATH"); path != "" {
+		breaches, err := repository.OpenPwnedPasswords(path)
+		if err != nil {
+			log.Fatalf("Failed to open breached passwords dataset: %v", err)
+		}
+		defer breaches.Close()
+		secretService.SetBreachIndex(breaches)
+	}
+
+	// Permanently remove secrets that sat in the trash past the retention period
+	if retention := trashRetention(); retention > 0 {
+		stopPurge := secretService.StartTrashPurge(retention)
+		defer stopPurge()
+	}
+
+	// Delete prior versions past the age limit, including those of secrets no longer updated
+	if history.MaxAge > 0 {
+		stopPrune := secretService.StartVersionPrune(history.MaxAge)
+		defer stopPrune()
+	}
+
+	// Remind about secrets nearing expiry or overdue for rotation
+	var notifier services.Notifier = services.NewLogNotifier()
+	if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+		notifier = services.NewWebhookNotifier(url)
+	}
+	stopReminders := secretService.StartReminders(notifier, reminderWindow())
+	defer stopReminders()
+
+	// Dev mode initializes and unlocks the fresh vault with a generated root password
+	if *devMode {
+		if err := initDevVault(vaultService); err != nil {
+			log.Fatalf("Failed to initialize dev vault: %v", err)
+		}
+	}
 
 	// Initialize handlers
 	vaultHandler := handlers.NewVaultHandler(vaultService)
+	policyHandler := handlers.NewPolicyHandler(policyService, ownerToken(*devMode), trustedProxies())
 	secretHandler := handlers.NewSecretHandler(secretService, vaultService)
+	transferDeadline := handlers.TransferDeadline(attachmentTimeout())
 
 	// Setup Gin router
 	gin.SetMode(gin.ReleaseMode)
@@ -51,8 +142,9 @@ func main() {
 	// CORS middleware
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "http://localhost:5173")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-Vault-User, X-Vault-Token-ID, X-Vault-Groups, If-Match")
+		c.Header("Access-Control-Expose-Headers", "ETag")
 		c.Header("Access-Control-Allow-Credentials", "true")
 		c.Header("Access-Control-Max-Age", "300")
 
@@ -74,21 +166,77 @@ func main() {
 
 	// API routes
 	api := r.Group("/api")
+	api.Use(policyHandler.Identify())
 	{
-		// Vault management
-		api.POST("/unlock", vaultHandler.Unlock)
-		api.POST("/lock", vaultHandler.Lock)
+		// Vault management (vault owner only, status is public)
+		api.POST("/init", policyHandler.RequireOwner(), vaultHandler.Initialize)
+		api.POST("/unlock", policyHandler.RequireOwner(), vaultHandler.Unlock)
+		api.POST("/lock", policyHandler.RequireOwner(), vaultHandler.Lock)
 		api.GET("/status", vaultHandler.Status)
 
+		// Built-in secret types
+		api.GET("/secret-types", secretHandler.ListTypes)
+
+		// Password and passphrase generator (identified callers only)
+		api.POST("/generate", policyHandler.RequireIdentified(), secretHandler.Generate)
+
 		// Secret management (protected by vault unlock)
 		secrets := api.Group("/secrets")
 		secrets.Use(vaultHandler.RequireUnlocked())
 		{
-			secrets.GET("/", secretHandler.List)
-			secrets.POST("/", secretHandler.Create)
-			secrets.GET("/:id", secretHandler.Get)
-			secrets.PUT("/:id", secretHandler.Update)
-			secrets.DELETE("/:id", secretHandler.Delete)
+			secrets.GET("/", policyHandler.Authorize(models.ActionRead), secretHandler.List)
+			secrets.POST("/", policyHandler.Authorize(models.ActionCreate), secretHandler.Create)
+			secrets.GET("/:id", policyHandler.Authorize(models.ActionRead), secretHandler.Get)
+			secrets.PUT("/:id", policyHandler.Authorize(models.ActionUpdate), secretHandler.Update)
+			secrets.PATCH("/:id", policyHandler.Authorize(models.ActionUpdate), secretHandler.Patch)
+			secrets.DELETE("/:id", policyHandler.Authorize(models.ActionDelete), secretHandler.Delete)
+			secrets.POST("/:id/move", policyHandler.Authorize(models.ActionUpdate), secretHandler.Move)
+			secrets.GET("/:id/totp", policyHandler.Authorize(models.ActionRead), secretHandler.TOTP)
+			secrets.GET("/:id/render", policyHandler.Authorize(models.ActionRead), secretHandler.Render)
+			secrets.POST("/:id/ssh-key/export", policyHandler.Authorize(models.ActionRead), secretHandler.ExportSSHKey)
+			secrets.GET("/:id/versions", policyHandler.Authorize(models.ActionRead), secretHandler.ListVersions)
+			secrets.GET("/:id/versions/:version", policyHandler.Authorize(models.ActionRead), secretHandler.GetVersion)
+			secrets.POST("/:id/versions/:version/restore", policyHandler.Authorize(models.ActionUpdate), secretHandler.RestoreVersion)
+			secrets.GET("/:id/attachments", policyHandler.Authorize(models.ActionRead), secretHandler.ListAttachments)
+			secrets.POST("/:id/attachments", policyHandler.Authorize(models.ActionUpdate), transferDeadline, secretHandler.UploadAttachment)
+			secrets.GET("/:id/attachments/:attachmentId", policyHandler.Authorize(models.ActionRead), transferDeadline, secretHandler.DownloadAttachment)
+			secrets.DELETE("/:id/attachments/:attachmentId", policyHandler.Authorize(models.ActionUpdate), secretHandler.DeleteAttachment)
+		}
+
+		// Folder management (protected by vault unlock)
+		folders := api.Group("/folders")
+		folders.Use(vaultHandler.RequireUnlocked())
+		{
+			folders.GET("/", policyHandler.Authorize(models.ActionRead), secretHandler.ListFolders)
+			folders.POST("/rename", policyHandler.Authorize(models.ActionUpdate), secretHandler.RenameFolder)
+		}
+
+		// Reports (protected by vault unlock)
+		reports := api.Group("/reports")
+		reports.Use(vaultHandler.RequireUnlocked())
+		{
+			reports.GET("/health", policyHandler.Authorize(models.ActionRead), secretHandler.HealthReport)
+			reports.GET("/breached", policyHandler.Authorize(models.ActionRead), secretHandler.BreachReport)
+		}
+
+		// Trash bin (protected by vault unlock)
+		trash := api.Group("/trash")
+		trash.Use(vaultHandler.RequireUnlocked())
+		{
+			trash.GET("/", policyHandler.Authorize(models.ActionRead), secretHandler.ListTrash)
+			trash.POST("/:id/restore", policyHandler.Authorize(models.ActionDelete), secretHandler.RestoreFromTrash)
+			trash.DELETE("/:id", policyHandler.Authorize(models.ActionDelete), secretHandler.Purge)
+		}
+
+		// Policy management (vault owner only)
+		policies := api.Group("/policies")
+		policies.Use(vaultHandler.RequireUnlocked(), policyHandler.RequireOwner())
+		{
+			policies.GET("/", policyHandler.List)
+			policies.POST("/", policyHandler.Create)
+			policies.GET("/:id", policyHandler.Get)
+			policies.PUT("/:id", policyHandler.Update)
+			policies.DELETE("/:id", policyHandler.Delete)
 		}
 	}
 
@@ -130,4 +278,237 @@ func main() {
 	}
 
 	log.Println("Server exited")
-} 
\ No newline at end of file
+}
+
+// storage holds the stores of the configured backend
+type storage struct {
+	secrets  repository.SecretStore
+	policies repository.PolicyStore
+	vault    repository.VaultStore
+	close    func()
+
+	// requireAttachmentsDir is set for an existing vault file, whose attachment
+	// contents must have been copied along with it
+	requireAttachmentsDir bool
+}
+
+// openStorage opens the storage backend selected with STORAGE_BACKEND,
+// or an in-memory vault in dev mode
+func openStorage(devMode bool) (*storage, error) {
+	if devMode {
+		db := repository.NewMemoryDB()
+		return &storage{
+			secrets:  repository.NewMemorySecretRepository(db),
+			policies: repository.NewMemoryPolicyRepository(db),
+			vault:    repository.NewMemoryVaultRepository(db),
+			close:    db.Close,
+		}, nil
+	}
+
+	switch backend := getEnv("STORAGE_BACKEND", "postgres"); backend {
+	case "postgres":
+		db, err := repository.NewPostgresDB()
+		if err != nil {
+			return nil, err
+		}
+		return &storage{
+			secrets:  repository.NewSecretRepository(db),
+			policies: repository.NewPolicyRepository(db),
+			vault:    repository.NewVaultRepository(db),
+			close:    db.Close,
+		}, nil
+
+	case "sqlite":
+		db, err := repository.NewSQLiteDB(getEnv("SQLITE_PATH", "./data/vaultbox.db"))
+		if err != nil {
+			return nil, err
+		}
+		return &storage{
+			secrets:  repository.NewSQLiteSecretRepository(db),
+			policies: repository.NewSQLitePolicyRepository(db),
+			vault:    repository.NewSQLiteVaultRepository(db),
+			close:    db.Close,
+		}, nil
+
+	case "file":
+		path := getEnv("VAULT_FILE_PATH", "./data/vault.vbx")
+		_, err := os.Stat(path)
+		existing := err == nil
+
+		db, err := repository.OpenVaultFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return &storage{
+			secrets:  repository.NewMemorySecretRepository(db),
+			policies: repository.NewMemoryPolicyRepository(db),
+			vault:    repository.NewMemoryVaultRepository(db),
+			close:    db.Close,
+
+			requireAttachmentsDir: existing,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q, expected postgres, sqlite or file", backend)
+	}
+}
+
+// initDevVault initializes the dev vault with a random root password and
+// prints it, since it is needed again after an auto-lock
+func initDevVault(vaultService *services.VaultService) error {
+	generated, err := services.Generate(&models.GenerateRequest{Mode: models.GenerateModePassphrase})
+	if err != nil {
+		return err
+	}
+
+	if err := vaultService.Initialize(context.Background(), generated.Value); err != nil {
+		return err
+	}
+
+	log.Println("Dev mode: in-memory vault, wiped on exit. Do not store real secrets.")
+	log.Printf("Dev mode: vault unlocked, root password: %s", generated.Value)
+	return nil
+}
+
+// ownerToken reads the bearer token that identifies the vault owner. Dev mode
+// draws and prints one when none is configured.
+func ownerToken(devMode bool) string {
+	token := os.Getenv("OWNER_TOKEN")
+	if token == "" && devMode {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			log.Fatalf("Failed to generate dev owner token: %v", err)
+		}
+		token = base64.RawURLEncoding.EncodeToString(raw)
+		log.Printf("Dev mode: owner token: %s", token)
+	}
+
+	if len(token) < 16 {
+		log.Fatalf("OWNER_TOKEN must be set to a random string of at least 16 characters")
+	}
+
+	return token
+}
+
+// trustedProxies reads the addresses allowed to identify callers with the
+// X-Vault-* headers, as IP addresses or CIDR ranges
+func trustedProxies() []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, value := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(value)
+			if addrErr != nil {
+				log.Fatalf("Invalid TRUSTED_PROXIES entry: %q", value)
+			}
+			addr = addr.Unmap()
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes
+}
+
+// versionRetention reads the secret history limits from the environment
+func versionRetention() services.VersionRetention {
+	retention := services.VersionRetention{MaxVersions: 50}
+
+	if value := os.Getenv("SECRET_VERSIONS_MAX"); value != "" {
+		maxVersions, err := strconv.Atoi(value)
+		if err != nil || maxVersions < 0 {
+			log.Fatalf("Invalid SECRET_VERSIONS_MAX: %q", value)
+		}
+		retention.MaxVersions = maxVersions
+	}
+
+	if value := os.Getenv("SECRET_VERSIONS_MAX_AGE"); value != "" {
+		maxAge, err := utils.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("Invalid SECRET_VERSIONS_MAX_AGE: %v", err)
+		}
+		retention.MaxAge = maxAge
+	}
+
+	return retention
+}
+
+// trashRetention reads how long deleted secrets stay in the trash; zero disables purging
+func trashRetention() time.Duration {
+	value := os.Getenv("TRASH_RETENTION")
+	if value == "" {
+		return 30 * 24 * time.Hour
+	}
+
+	retention, err := utils.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid TRASH_RETENTION: %v", err)
+	}
+
+	return retention
+}
+
+// reminderWindow reads how long before expiry a secret triggers a reminder
+func reminderWindow() time.Duration {
+	value := os.Getenv("REMINDER_WINDOW")
+	if value == "" {
+		return 7 * 24 * time.Hour
+	}
+
+	window, err := utils.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid REMINDER_WINDOW: %v", err)
+	}
+
+	return window
+}
+
+// attachmentLimits reads the attachment size limits from the environment
+func attachmentLimits() services.AttachmentLimits {
+	limits := services.AttachmentLimits{MaxFileSize: 25 << 20, MaxTotalSize: 100 << 20}
+
+	if value := os.Getenv("ATTACHMENT_MAX_SIZE"); value != "" {
+		size, err := utils.ParseSize(value)
+		if err != nil {
+			log.Fatalf("Invalid ATTACHMENT_MAX_SIZE: %v", err)
+		}
+		limits.MaxFileSize = size
+	}
+
+	if value := os.Getenv("ATTACHMENT_MAX_TOTAL_SIZE"); value != "" {
+		size, err := utils.ParseSize(value)
+		if err != nil {
+			log.Fatalf("Invalid ATTACHMENT_MAX_TOTAL_SIZE: %v", err)
+		}
+		limits.MaxTotalSize = size
+	}
+
+	return limits
+}
+
+// attachmentTimeout reads how long an attachment upload or download may take; zero means no limit
+func attachmentTimeout() time.Duration {
+	value := os.Getenv("ATTACHMENT_TIMEOUT")
+	if value == "" {
+		return 10 * time.Minute
+	}
+
+	timeout, err := utils.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid ATTACHMENT_TIMEOUT: %v", err)
+	}
+
+	return timeout
+}
+
+// getEnv gets an environment variable with a fallback default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
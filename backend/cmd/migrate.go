@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"my-vault/internal/repository"
+)
+
+// migrateUsage describes the migrate subcommand
+const migrateUsage = `usage: vaultbox migrate <command>
+
+Commands:
+  status  list migrations and whether they are applied
+  up      apply all pending migrations
+  down    roll back the most recently applied migration
+  redo    roll back the most recently applied migration and apply it again`
+
+// runMigrate runs the migrate subcommand against the PostgreSQL database
+// configured with the DB_* variables
+func runMigrate(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	db, err := repository.ConnectPostgres()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := repository.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(statuses)
+
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+
+	case "down", "redo":
+		run := migrator.Down
+		if args[0] == "redo" {
+			run = migrator.Redo
+		}
+		migration, err := run(ctx)
+		if err != nil {
+			return err
+		}
+		if migration == nil {
+			fmt.Println("No applied migrations")
+		}
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", args[0], migrateUsage)
+	}
+
+	return nil
+}
+
+// printMigrationStatus writes one line per migration to stdout
+func printMigrationStatus(statuses []repository.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		if status.AppliedAt != nil {
+			state, appliedAt = "applied", status.AppliedAt.Local().Format(time.RFC3339)
+		}
+		if status.Unknown {
+			state = "applied, unknown to this build"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", status.Migration, state, appliedAt)
+	}
+	w.Flush()
+}
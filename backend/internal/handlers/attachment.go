@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"my-vault/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAttachments lists the files attached to a secret
+// @Summary List attachments
+// @Description Get the metadata of all files attached to a secret
+// @Tags attachments
+// @Produce json
+// @Param id path string true "Secret ID"
+// @Success 200 {array} models.AttachmentInfo
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/secrets/{id}/attachments [get]
+func (h *SecretHandler) ListAttachments(c *gin.Context) {
+	attachments, err := h.secretService.ListAttachments(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to list attachments",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// UploadAttachment attaches a file to a secret
+// @Summary Upload an attachment
+// @Description Attach a file to a secret. The content is stream-encrypted in chunks and the filename is stored encrypted.
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Secret ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} models.AttachmentInfo
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/secrets/{id}/attachments [post]
+func (h *SecretHandler) UploadAttachment(c *gin.Context) {
+	// Read the multipart body part by part so the file is streamed, not buffered
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: "Expected a multipart/form-data body",
+		})
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid request",
+				Message: err.Error(),
+			})
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.secretService.AddAttachment(c.Request.Context(), c.Param("id"), part.FileName(), part)
+		part.Close()
+		if err != nil {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+				Error:   "Failed to upload attachment",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusCreated, attachment)
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		Error:   "Invalid request",
+		Message: "A file field is required",
+	})
+}
+
+// DownloadAttachment streams the decrypted content of an attachment
+// @Summary Download an attachment
+// @Description Download the decrypted content of a file attached to a secret
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path string true "Secret ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/secrets/{id}/attachments/{attachmentId} [get]
+func (h *SecretHandler) DownloadAttachment(c *gin.Context) {
+	attachment, content, err := h.secretService.OpenAttachment(c.Request.Context(), c.Param("id"), c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to download attachment",
+			Message: err.Error(),
+		})
+		return
+	}
+	defer content.Close()
+
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	c.Status(http.StatusOK)
+
+	// Headers are already sent, so a failure mid-stream can only cut the response short
+	if _, err := io.Copy(c.Writer, content); err != nil {
+		log.Printf("Failed to stream attachment %s: %v", attachment.ID, err)
+	}
+}
+
+// DeleteAttachment removes a file from a secret
+// @Summary Delete an attachment
+// @Description Remove a file attached to a secret
+// @Tags attachments
+// @Param id path string true "Secret ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/secrets/{id}/attachments/{attachmentId} [delete]
+func (h *SecretHandler) DeleteAttachment(c *gin.Context) {
+	if err := h.secretService.DeleteAttachment(c.Request.Context(), c.Param("id"), c.Param("attachmentId")); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to delete attachment",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// TransferDeadline is middleware that replaces the server read and write timeouts
+// for routes streaming attachment contents, which can take far longer than an API call;
+// a zero timeout lifts the deadlines altogether
+func TransferDeadline(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var deadline time.Time
+		if timeout > 0 {
+			deadline = time.Now().Add(timeout)
+		}
+
+		controller := http.NewResponseController(c.Writer)
+		if err := controller.SetReadDeadline(deadline); err != nil {
+			log.Printf("Failed to extend the read deadline: %v", err)
+		}
+		if err := controller.SetWriteDeadline(deadline); err != nil {
+			log.Printf("Failed to extend the write deadline: %v", err)
+		}
+		c.Next()
+	}
+}
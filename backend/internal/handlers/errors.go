@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"my-vault/internal/services"
+)
+
+// errorStatus maps well-known service errors to HTTP status codes,
+// returning fallback for anything else
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, services.ErrLocked):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrAccessDenied):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrConflict), errors.Is(err, services.ErrNotInitialized):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrRevisionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, services.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return fallback
+	}
+}
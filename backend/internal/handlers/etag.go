@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"my-vault/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag sends a secret's revision as its entity tag
+func setETag(c *gin.Context, revision int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(revision)))
+}
+
+// ifMatchRevision returns the secret revision named by the If-Match header.
+// Writes must name the revision they were based on, so a missing header is
+// answered with 428 and one that cannot match any revision with 412.
+func ifMatchRevision(c *gin.Context) (int, bool) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		c.JSON(http.StatusPreconditionRequired, models.ErrorResponse{
+			Error:   "Precondition required",
+			Message: "If-Match must carry the ETag of the secret as last read",
+		})
+		return 0, false
+	}
+
+	// Only a single strong tag is accepted; weak tags never match for writes
+	if tag, ok := strings.CutPrefix(value, `"`); ok {
+		if tag, ok := strings.CutSuffix(tag, `"`); ok {
+			if revision, err := strconv.Atoi(tag); err == nil {
+				return revision, true
+			}
+		}
+	}
+
+	c.JSON(http.StatusPreconditionFailed, models.ErrorResponse{
+		Error:   "Precondition failed",
+		Message: "If-Match does not match the secret's ETag",
+	})
+	return 0, false
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"my-vault/internal/models"
+	"my-vault/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Generate creates a random password or passphrase
+// @Summary Generate a password or passphrase
+// @Description Generate a random password from the chosen character classes, or a diceware passphrase from the bundled EFF wordlist, with an entropy estimate
+// @Tags generator
+// @Accept json
+// @Produce json
+// @Param options body models.GenerateRequest true "Generator options"
+// @Success 200 {object} models.GenerateResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /api/generate [post]
+func (h *SecretHandler) Generate(c *gin.Context) {
+	var req models.GenerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	generated, err := services.Generate(&req)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to generate value",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, generated)
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"my-vault/internal/models"
+	"my-vault/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers identifying the caller, set by a trusted authenticating proxy
+const (
+	HeaderUser    = "X-Vault-User"
+	HeaderTokenID = "X-Vault-Token-ID"
+	HeaderGroups  = "X-Vault-Groups"
+)
+
+// PolicyHandler handles policy-related HTTP requests and authorization middleware
+type PolicyHandler struct {
+	policyService  *services.PolicyService
+	ownerToken     [sha256.Size]byte
+	trustedProxies []netip.Prefix
+}
+
+// NewPolicyHandler creates a new policy handler. Callers presenting ownerToken
+// as a bearer token act as the vault owner; identity headers are only honored
+// on requests coming from one of trustedProxies.
+func NewPolicyHandler(policyService *services.PolicyService, ownerToken string, trustedProxies []netip.Prefix) *PolicyHandler {
+	return &PolicyHandler{
+		policyService:  policyService,
+		ownerToken:     sha256.Sum256([]byte(ownerToken)),
+		trustedProxies: trustedProxies,
+	}
+}
+
+// List retrieves all policies
+// @Summary List all policies
+// @Description Retrieve all authorization policies
+// @Tags policies
+// @Produce json
+// @Success 200 {array} models.Policy
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/policies [get]
+func (h *PolicyHandler) List(c *gin.Context) {
+	policies, err := h.policyService.List(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to list policies",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+// Create creates a new policy
+// @Summary Create a new policy
+// @Description Create a policy document and attach it to users, groups or tokens
+// @Tags policies
+// @Accept json
+// @Produce json
+// @Param request body models.CreatePolicyRequest true "Policy creation request"
+// @Success 201 {object} models.Policy
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/policies [post]
+func (h *PolicyHandler) Create(c *gin.Context) {
+	var req models.CreatePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: "Failed to parse request body",
+		})
+		return
+	}
+
+	policy, err := h.policyService.Create(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to create policy",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+// Get retrieves a policy by ID
+// @Summary Get a policy by ID
+// @Description Retrieve a specific policy by its ID
+// @Tags policies
+// @Produce json
+// @Param id path string true "Policy ID"
+// @Success 200 {object} models.Policy
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/policies/{id} [get]
+func (h *PolicyHandler) Get(c *gin.Context) {
+	policy, err := h.policyService.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), models.ErrorResponse{
+			Error:   "Policy not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// Update updates an existing policy
+// @Summary Update a policy
+// @Description Replace a policy document and its attachments
+// @Tags policies
+// @Accept json
+// @Produce json
+// @Param id path string true "Policy ID"
+// @Param request body models.UpdatePolicyRequest true "Policy update request"
+// @Success 200 {object} models.Policy
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/policies/{id} [put]
+func (h *PolicyHandler) Update(c *gin.Context) {
+	var req models.UpdatePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: "Failed to parse request body",
+		})
+		return
+	}
+
+	policy, err := h.policyService.Update(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to update policy",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// Delete removes a policy
+// @Summary Delete a policy
+// @Description Delete a policy and detach it from all subjects
+// @Tags policies
+// @Param id path string true "Policy ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/policies/{id} [delete]
+func (h *PolicyHandler) Delete(c *gin.Context) {
+	if err := h.policyService.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to delete policy",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Identify is middleware that attaches the calling principal to the request context.
+// A bearer token equal to the owner token identifies the vault owner, and a
+// wrong one is rejected. Identity headers are ignored unless the request comes
+// from a trusted proxy, so anyone else is anonymous and denied by policies.
+func (h *PolicyHandler) Identify() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var principal services.Principal
+
+		if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+			// Comparing digests keeps the check constant time whatever the token length
+			token = strings.TrimSpace(token)
+			digest := sha256.Sum256([]byte(token))
+			if token == "" || subtle.ConstantTimeCompare(digest[:], h.ownerToken[:]) != 1 {
+				c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+					Error:   "Authentication failed",
+					Message: "Invalid owner token",
+				})
+				c.Abort()
+				return
+			}
+			principal.Owner = true
+		} else if h.fromTrustedProxy(c.Request) {
+			principal.User = strings.TrimSpace(c.GetHeader(HeaderUser))
+			principal.Token = strings.TrimSpace(c.GetHeader(HeaderTokenID))
+			for _, group := range strings.Split(c.GetHeader(HeaderGroups), ",") {
+				if group = strings.TrimSpace(group); group != "" {
+					principal.Groups = append(principal.Groups, group)
+				}
+			}
+		}
+
+		c.Request = c.Request.WithContext(services.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// fromTrustedProxy reports whether the request's direct peer is a trusted proxy.
+// The peer address is used as is; forwarding headers are client input.
+func (h *PolicyHandler) fromTrustedProxy(r *http.Request) bool {
+	peer, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+
+	addr := peer.Addr().Unmap()
+	for _, prefix := range h.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requireIdentified rejects anonymous callers with 401
+func requireIdentified(c *gin.Context) bool {
+	if services.PrincipalFromContext(c.Request.Context()).IsAnonymous() {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Message: "Present the owner token or connect through a trusted proxy",
+		})
+		c.Abort()
+		return false
+	}
+	return true
+}
+
+// Authorize is middleware that rejects callers with no policy allowing action on any secret.
+// Per-secret conditions are enforced by the secret service.
+func (h *PolicyHandler) Authorize(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireIdentified(c) {
+			return
+		}
+
+		evaluator, err := h.policyService.Evaluator(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Authorization failed",
+				Message: err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		if !evaluator.MayPerform(action) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Access denied",
+				Message: "No policy allows " + action + " on secrets",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(services.WithEvaluator(c.Request.Context(), evaluator))
+		c.Next()
+	}
+}
+
+// RequireOwner is middleware that restricts a route to the vault owner
+func (h *PolicyHandler) RequireOwner() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireIdentified(c) {
+			return
+		}
+		if !services.PrincipalFromContext(c.Request.Context()).IsOwner() {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Access denied",
+				Message: "Only the vault owner can do this",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireIdentified is middleware that rejects anonymous callers
+func (h *PolicyHandler) RequireIdentified() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireIdentified(c) {
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"my-vault/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testOwnerToken = "owner-token-0123456789"
+
+// newIdentifyRouter serves the principal Identify attaches, trusting proxies in 10.0.0.0/8
+func newIdentifyRouter(middleware ...func(h *PolicyHandler) gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	h := NewPolicyHandler(nil, testOwnerToken, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+	handlers := []gin.HandlerFunc{h.Identify()}
+	for _, m := range middleware {
+		handlers = append(handlers, m(h))
+	}
+	handlers = append(handlers, func(c *gin.Context) {
+		c.JSON(http.StatusOK, services.PrincipalFromContext(c.Request.Context()))
+	})
+
+	r := gin.New()
+	r.GET("/whoami", handlers...)
+	return r
+}
+
+// identify sends a request from remoteAddr with headers and returns the response
+func identify(t *testing.T, r *gin.Engine, remoteAddr string, headers map[string]string) (*httptest.ResponseRecorder, services.Principal) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.RemoteAddr = remoteAddr
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var principal services.Principal
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &principal); err != nil {
+			t.Fatalf("decode principal: %v", err)
+		}
+	}
+	return w, principal
+}
+
+func TestIdentify(t *testing.T) {
+	r := newIdentifyRouter()
+	identity := map[string]string{HeaderUser: "bob", HeaderTokenID: "tok-1", HeaderGroups: " ops, ,dev "}
+
+	t.Run("owner token", func(t *testing.T) {
+		w, principal := identify(t, r, "192.0.2.1:1234", map[string]string{"Authorization": "Bearer " + testOwnerToken})
+		if w.Code != http.StatusOK || !principal.Owner {
+			t.Errorf("got %d %+v, want the owner", w.Code, principal)
+		}
+	})
+
+	t.Run("invalid bearer token", func(t *testing.T) {
+		for _, header := range []string{"Bearer wrong-token", "Bearer ", "Bearer " + testOwnerToken + "x"} {
+			if w, _ := identify(t, r, "192.0.2.1:1234", map[string]string{"Authorization": header}); w.Code != http.StatusUnauthorized {
+				t.Errorf("Authorization %q: got %d, want 401", header, w.Code)
+			}
+		}
+	})
+
+	t.Run("invalid bearer token from a trusted proxy", func(t *testing.T) {
+		headers := map[string]string{"Authorization": "Bearer wrong-token", HeaderUser: "bob"}
+		if w, _ := identify(t, r, "10.1.2.3:1234", headers); w.Code != http.StatusUnauthorized {
+			t.Errorf("got %d, want 401", w.Code)
+		}
+	})
+
+	t.Run("identity headers from an untrusted peer", func(t *testing.T) {
+		// Forwarding headers are client input and do not make a peer trusted
+		headers := map[string]string{HeaderUser: "bob", HeaderGroups: "ops", "X-Forwarded-For": "10.1.2.3"}
+		w, principal := identify(t, r, "192.0.2.1:1234", headers)
+		if w.Code != http.StatusOK || !principal.IsAnonymous() {
+			t.Errorf("got %d %+v, want an anonymous caller", w.Code, principal)
+		}
+	})
+
+	t.Run("identity headers from a trusted proxy", func(t *testing.T) {
+		w, principal := identify(t, r, "10.1.2.3:1234", identity)
+		if w.Code != http.StatusOK || principal.Owner || principal.User != "bob" || principal.Token != "tok-1" {
+			t.Errorf("got %d %+v, want user bob with token tok-1", w.Code, principal)
+		}
+		if !slices.Equal(principal.Groups, []string{"ops", "dev"}) {
+			t.Errorf("groups = %v, want [ops dev]", principal.Groups)
+		}
+	})
+
+	t.Run("identity headers from a trusted IPv4-mapped IPv6 peer", func(t *testing.T) {
+		if _, principal := identify(t, r, "[::ffff:10.1.2.3]:1234", identity); principal.User != "bob" {
+			t.Errorf("got %+v, want user bob", principal)
+		}
+	})
+
+	t.Run("malformed peer address", func(t *testing.T) {
+		if _, principal := identify(t, r, "10.1.2.3", identity); !principal.IsAnonymous() {
+			t.Errorf("got %+v, want an anonymous caller", principal)
+		}
+	})
+}
+
+func TestRequireIdentifiedAndOwner(t *testing.T) {
+	owner := map[string]string{"Authorization": "Bearer " + testOwnerToken}
+	user := map[string]string{HeaderUser: "bob"}
+
+	tests := []struct {
+		name       string
+		middleware func(h *PolicyHandler) gin.HandlerFunc
+		remoteAddr string
+		headers    map[string]string
+		want       int
+	}{
+		{"identified: anonymous", (*PolicyHandler).RequireIdentified, "192.0.2.1:1234", user, http.StatusUnauthorized},
+		{"identified: user", (*PolicyHandler).RequireIdentified, "10.1.2.3:1234", user, http.StatusOK},
+		{"identified: owner", (*PolicyHandler).RequireIdentified, "192.0.2.1:1234", owner, http.StatusOK},
+		{"owner: anonymous", (*PolicyHandler).RequireOwner, "192.0.2.1:1234", nil, http.StatusUnauthorized},
+		{"owner: user", (*PolicyHandler).RequireOwner, "10.1.2.3:1234", user, http.StatusForbidden},
+		{"owner: owner", (*PolicyHandler).RequireOwner, "192.0.2.1:1234", owner, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newIdentifyRouter(tt.middleware)
+			if w, _ := identify(t, r, tt.remoteAddr, tt.headers); w.Code != tt.want {
+				t.Errorf("got %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"my-vault/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Render returns a secret with its references to other secrets resolved
+// @Summary Render a secret's references
+// @Description Resolve {{ref "secret"}} and {{ref "secret" "field"}} references in a secret's value, typed fields and custom fields. Secrets are named by ID, folder/title path or title; the caller must be allowed to read every referenced secret, and secrets it may not read are reported as not existing. Reference cycles are rejected.
+// @Tags secrets
+// @Produce json
+// @Param id path string true "Secret ID"
+// @Success 200 {object} models.RenderedSecret
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/secrets/{id}/render [get]
+func (h *SecretHandler) Render(c *gin.Context) {
+	rendered, err := h.secretService.Render(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to render secret",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, rendered)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"my-vault/internal/models"
+	"my-vault/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthReport reports weak, reused, old and incomplete secrets
+// @Summary Password health report
+// @Description Decrypt every readable secret and report weak credentials (zxcvbn-style score), credentials reused across secrets (compared by keyed hashes), breached credentials when HIBP_PATH is configured, secrets not updated within max_age_days and secrets missing fields their type expects
+// @Tags reports
+// @Produce json
+// @Param min_score query int false "Lowest strength score (0-4) not reported as weak" default(3)
+// @Param max_age_days query int false "Report secrets not updated for more days than this; 0 disables" default(90)
+// @Success 200 {object} models.HealthReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/reports/health [get]
+func (h *SecretHandler) HealthReport(c *gin.Context) {
+	opts := models.HealthReportOptions{
+		MinScore: services.DefaultHealthMinScore,
+		MaxAge:   services.DefaultHealthMaxAge,
+	}
+
+	if value := c.Query("min_score"); value != "" {
+		score, err := strconv.Atoi(value)
+		if err != nil || score < 0 || score > 4 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid request",
+				Message: "min_score must be an integer between 0 and 4",
+			})
+			return
+		}
+		opts.MinScore = score
+	}
+
+	if value := c.Query("max_age_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid request",
+				Message: "max_age_days must be a non-negative integer",
+			})
+			return
+		}
+		opts.MaxAge = time.Duration(days) * 24 * time.Hour
+	}
+
+	report, err := h.secretService.HealthReport(c.Request.Context(), opts)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to build health report",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// BreachReport lists stored credentials found in the breached passwords dataset
+// @Summary Breached password report
+// @Description Check every readable credential against the local Have I Been Pwned dataset configured with HIBP_PATH; no network access is used
+// @Tags reports
+// @Produce json
+// @Success 200 {object} models.BreachReport
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /api/reports/breached [get]
+func (h *SecretHandler) BreachReport(c *gin.Context) {
+	report, err := h.secretService.BreachReport(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to build breach report",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
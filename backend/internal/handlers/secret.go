@@ -1,10 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"my-vault/internal/models"
 	"my-vault/internal/services"
+	"my-vault/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,19 +29,64 @@ func NewSecretHandler(secretService *services.SecretService, vaultService *servi
 	}
 }
 
-// List retrieves all secrets
-// @Summary List all secrets
-// @Description Retrieve all secrets from the vault
+// Listing page sizes
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+// List retrieves secrets page by page
+// @Summary List secrets
+// @Description Retrieve one page of secret metadata, without values. With include=value the secrets are returned decrypted, as models.SecretValueListResponse, and each reveal is audited. Pass next_cursor from the response as cursor to fetch the next page; it is omitted on the last page. Plain custom fields can be matched with field.<name>=<value> query parameters.
 // @Tags secrets
 // @Produce json
-// @Success 200 {array} models.SecretResponse
+// @Param q query string false "Only secrets whose title, custom field names or plain field values contain every word, e.g. stripe"
+// @Param tag query string false "Only secrets carrying this tag"
+// @Param folder query string false "Only secrets in this folder or its subfolders"
+// @Param field.name query string false "Match a plain custom field by name and value, e.g. field.region=eu-west-1"
+// @Param expiring_within query string false "Only secrets expiring within this duration, including expired ones, e.g. 30d"
+// @Param type query string false "Only secrets of this type"
+// @Param title_prefix query string false "Only secrets whose title starts with this prefix, case-sensitively"
+// @Param created_after query string false "Only secrets created after this RFC 3339 time"
+// @Param created_before query string false "Only secrets created before this RFC 3339 time"
+// @Param updated_after query string false "Only secrets last updated after this RFC 3339 time"
+// @Param updated_before query string false "Only secrets last updated before this RFC 3339 time"
+// @Param sort query string false "Sort field" Enums(created_at, updated_at, title) default(created_at)
+// @Param order query string false "Sort order; defaults to desc, or asc when sorting by title" Enums(asc, desc)
+// @Param limit query int false "Maximum number of secrets per page, up to 1000" default(100)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param include query string false "Also return the secret values" Enums(value)
+// @Success 200 {object} models.SecretListResponse
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/secrets [get]
 func (h *SecretHandler) List(c *gin.Context) {
-	secrets, err := h.secretService.List(c.Request.Context())
+	filter, param, err := secretFilter(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid " + param,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	var secrets any
+	switch c.Query("include") {
+	case "":
+		secrets, err = h.secretService.List(c.Request.Context(), filter)
+	case "value":
+		secrets, err = h.secretService.ListWithValues(c.Request.Context(), filter)
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid include",
+			Message: "include must be value",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
 			Error:   "Failed to list secrets",
 			Message: err.Error(),
 		})
@@ -45,9 +96,91 @@ func (h *SecretHandler) List(c *gin.Context) {
 	c.JSON(http.StatusOK, secrets)
 }
 
+// secretFilter reads a secret listing's filter, sort order and page from the
+// query string, returning the offending parameter with any error
+func secretFilter(c *gin.Context) (models.SecretFilter, string, error) {
+	filter := models.SecretFilter{
+		Tag:          strings.TrimSpace(c.Query("tag")),
+		FolderPrefix: strings.Trim(strings.TrimSpace(c.Query("folder")), "/"),
+		Type:         strings.TrimSpace(c.Query("type")),
+		TitlePrefix:  c.Query("title_prefix"),
+		Search:       strings.TrimSpace(c.Query("q")),
+		Limit:        defaultListLimit,
+	}
+	for key, values := range c.Request.URL.Query() {
+		if name, ok := strings.CutPrefix(key, "field."); ok && name != "" && len(values) > 0 {
+			if filter.Fields == nil {
+				filter.Fields = make(map[string]string)
+			}
+			filter.Fields[name] = values[0]
+		}
+	}
+	if value := c.Query("expiring_within"); value != "" {
+		within, err := utils.ParseDuration(value)
+		if err != nil {
+			return filter, "expiring_within", err
+		}
+		before := time.Now().Add(within)
+		filter.ExpiringBefore = &before
+	}
+
+	for _, bound := range []struct {
+		param string
+		at    **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+		{"updated_after", &filter.UpdatedAfter},
+		{"updated_before", &filter.UpdatedBefore},
+	} {
+		if value := c.Query(bound.param); value != "" {
+			at, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, bound.param, errors.New("expected an RFC 3339 time such as 2024-01-15T10:30:00Z")
+			}
+			*bound.at = &at
+		}
+	}
+
+	switch sort := models.SecretSort(c.DefaultQuery("sort", string(models.SortByCreatedAt))); sort {
+	case models.SortByCreatedAt, models.SortByUpdatedAt, models.SortByTitle:
+		filter.Sort = sort
+	default:
+		return filter, "sort", errors.New("sort must be created_at, updated_at or title")
+	}
+
+	switch c.Query("order") {
+	case "asc":
+		filter.Ascending = true
+	case "desc":
+	case "":
+		filter.Ascending = filter.Sort == models.SortByTitle
+	default:
+		return filter, "order", errors.New("order must be asc or desc")
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return filter, "limit", fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		after, err := services.DecodeSecretCursor(value, filter)
+		if err != nil {
+			return filter, "cursor", err
+		}
+		filter.After = after
+	}
+
+	return filter, "", nil
+}
+
 // Create creates a new secret
 // @Summary Create a new secret
-// @Description Create a new secret in the vault
+// @Description Create a new secret in the vault. Structured types take their fields in data; value fills the type's primary field, or generate has the server generate it.
 // @Tags secrets
 // @Accept json
 // @Produce json
@@ -55,7 +188,9 @@ func (h *SecretHandler) List(c *gin.Context) {
 // @Success 201 {object} models.SecretResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
 // @Router /api/secrets [post]
 func (h *SecretHandler) Create(c *gin.Context) {
 	var req models.CreateSecretRequest
@@ -68,23 +203,24 @@ func (h *SecretHandler) Create(c *gin.Context) {
 	}
 
 	// Basic validation
-	if req.Title == "" || req.Type == "" || req.Value == "" {
+	if req.Title == "" || req.Type == "" || (req.Value == "" && len(req.Data) == 0 && req.Generate == nil) {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Validation failed",
-			Message: "Title, type, and value are required",
+			Message: "Title, type, and value, data or generate are required",
 		})
 		return
 	}
 
 	secret, err := h.secretService.Create(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
 			Error:   "Failed to create secret",
 			Message: err.Error(),
 		})
 		return
 	}
 
+	setETag(c, secret.Revision)
 	c.JSON(http.StatusCreated, secret)
 }
 
@@ -97,6 +233,7 @@ func (h *SecretHandler) Create(c *gin.Context) {
 // @Success 200 {object} models.SecretResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /api/secrets/{id} [get]
 func (h *SecretHandler) Get(c *gin.Context) {
@@ -111,28 +248,34 @@ func (h *SecretHandler) Get(c *gin.Context) {
 
 	secret, err := h.secretService.Get(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
+		c.JSON(errorStatus(err, http.StatusNotFound), models.ErrorResponse{
 			Error:   "Secret not found",
 			Message: err.Error(),
 		})
 		return
 	}
 
+	setETag(c, secret.Revision)
 	c.JSON(http.StatusOK, secret)
 }
 
 // Update updates an existing secret
 // @Summary Update a secret
-// @Description Update an existing secret by its ID
+// @Description Update an existing secret by its ID. Structured types take their fields in data; value fills the type's primary field. If-Match must carry the ETag the secret was read with; a secret changed since is left alone and answered with 412. Callers who may update the secret but not read it get its metadata only, without value, data or fields.
 // @Tags secrets
 // @Accept json
 // @Produce json
 // @Param id path string true "Secret ID"
+// @Param If-Match header string true "ETag of the secret as last read"
 // @Param request body models.UpdateSecretRequest true "Secret update request"
 // @Success 200 {object} models.SecretResponse
+// @Header 200 {string} ETag "Revision of the updated secret"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 428 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/secrets/{id} [put]
 func (h *SecretHandler) Update(c *gin.Context) {
@@ -145,6 +288,11 @@ func (h *SecretHandler) Update(c *gin.Context) {
 		return
 	}
 
+	revision, ok := ifMatchRevision(c)
+	if !ok {
+		return
+	}
+
 	var req models.UpdateSecretRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
@@ -155,35 +303,105 @@ func (h *SecretHandler) Update(c *gin.Context) {
 	}
 
 	// Basic validation
-	if req.Title == "" || req.Type == "" || req.Value == "" {
+	if req.Title == "" || req.Type == "" || (req.Value == "" && len(req.Data) == 0) {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Validation failed",
-			Message: "Title, type, and value are required",
+			Message: "Title, type, and value or data are required",
 		})
 		return
 	}
 
-	secret, err := h.secretService.Update(c.Request.Context(), id, &req)
+	secret, err := h.secretService.Update(c.Request.Context(), id, revision, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
 			Error:   "Failed to update secret",
 			Message: err.Error(),
 		})
 		return
 	}
 
+	setETag(c, secret.Revision)
+	c.JSON(http.StatusOK, secret)
+}
+
+// Patch changes some members of a secret
+// @Summary Patch a secret
+// @Description Change a secret with a JSON merge patch (RFC 7396) against its update request form: members left out keep their value, null removes them, objects such as data are merged member by member and arrays such as fields and tags are replaced. The merged secret is validated as a whole. The value and concealed fields are only encrypted again when they change. If-Match must carry the ETag the secret was read with; a secret changed since is left alone and answered with 412. Callers who may update the secret but not read it get its metadata only, without value, data or fields.
+// @Tags secrets
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "Secret ID"
+// @Param If-Match header string true "ETag of the secret as last read"
+// @Param patch body models.UpdateSecretRequest true "Members to change; null removes a member"
+// @Success 200 {object} models.SecretResponse
+// @Header 200 {string} ETag "Revision of the patched secret"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 415 {object} models.ErrorResponse
+// @Failure 428 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/secrets/{id} [patch]
+func (h *SecretHandler) Patch(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: "Secret ID is required",
+		})
+		return
+	}
+
+	if contentType := c.ContentType(); contentType != "application/merge-patch+json" && contentType != "application/json" {
+		c.JSON(http.StatusUnsupportedMediaType, models.ErrorResponse{
+			Error:   "Unsupported media type",
+			Message: "Send the patch as application/merge-patch+json",
+		})
+		return
+	}
+
+	revision, ok := ifMatchRevision(c)
+	if !ok {
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: "Failed to read request body",
+		})
+		return
+	}
+
+	secret, err := h.secretService.Patch(c.Request.Context(), id, revision, patch)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to patch secret",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	setETag(c, secret.Revision)
 	c.JSON(http.StatusOK, secret)
 }
 
-// Delete removes a secret
+// Delete moves a secret to the trash
 // @Summary Delete a secret
-// @Description Delete a secret by its ID
+// @Description Move a secret to the trash; it can be restored until the trash is purged. If-Match must carry the ETag the secret was read with; a secret changed since is left alone and answered with 412.
 // @Tags secrets
 // @Param id path string true "Secret ID"
+// @Param If-Match header string true "ETag of the secret as last read"
 // @Success 204 "No Content"
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 428 {object} models.ErrorResponse
 // @Router /api/secrets/{id} [delete]
 func (h *SecretHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
@@ -195,8 +413,13 @@ func (h *SecretHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.secretService.Delete(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+	revision, ok := ifMatchRevision(c)
+	if !ok {
+		return
+	}
+
+	if err := h.secretService.Delete(c.Request.Context(), id, revision); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
 			Error:   "Failed to delete secret",
 			Message: err.Error(),
 		})
@@ -204,4 +427,230 @@ func (h *SecretHandler) Delete(c *gin.Context) {
 	}
 
 	c.Status(http.StatusNoContent)
-} 
\ No newline at end of file
+}
+
+// Move moves a secret to another folder
+// @Summary Move a secret
+// @Description Move a secret to another folder without changing its value. Returns the secret's metadata only. If-Match must carry the ETag the secret was read with; a secret changed since is left alone and answered with 412.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param id path string true "Secret ID"
+// @Param If-Match header string true "ETag of the secret as last read"
+// @Param request body models.MoveSecretRequest true "Move request"
+// @Success 200 {object} models.SecretSummary
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 428 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/secrets/{id}/move [post]
+func (h *SecretHandler) Move(c *gin.Context) {
+	var req models.MoveSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: "Failed to parse request body",
+		})
+		return
+	}
+
+	revision, ok := ifMatchRevision(c)
+	if !ok {
+		return
+	}
+
+	secret, err := h.secretService.Move(c.Request.Context(), c.Param("id"), revision, &req)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to move secret",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	setETag(c, secret.Revision)
+	c.JSON(http.StatusOK, secret)
+}
+
+// ListFolders retrieves the folder hierarchy
+// @Summary List folders
+// @Description Retrieve every folder holding secrets, including intermediate folders
+// @Tags folders
+// @Produce json
+// @Success 200 {array} models.Folder
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/folders [get]
+func (h *SecretHandler) ListFolders(c *gin.Context) {
+	folders, err := h.secretService.ListFolders(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to list folders",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, folders)
+}
+
+// RenameFolder renames a folder in bulk
+// @Summary Rename a folder
+// @Description Rename a folder, moving every secret in it and its subfolders. The caller must be allowed to update every one of them; if a secret in the folder changes, or one is added, while the rename is checked, nothing is renamed and the request fails with 412.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param request body models.RenameFolderRequest true "Rename request"
+// @Success 200 {object} models.RenameFolderResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/folders/rename [post]
+func (h *SecretHandler) RenameFolder(c *gin.Context) {
+	var req models.RenameFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: "Failed to parse request body",
+		})
+		return
+	}
+
+	result, err := h.secretService.RenameFolder(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to rename folder",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ListTypes returns the built-in secret types
+// @Summary List secret types
+// @Description Retrieve the built-in secret types and their typed field sets
+// @Tags secrets
+// @Produce json
+// @Success 200 {array} models.SecretTypeInfo
+// @Router /api/secret-types [get]
+func (h *SecretHandler) ListTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, services.SecretTypes())
+}
+
+// ListVersions retrieves the version history of a secret
+// @Summary List secret versions
+// @Description Retrieve the version history of a secret, current version first, without values
+// @Tags versions
+// @Produce json
+// @Param id path string true "Secret ID"
+// @Success 200 {array} models.SecretVersionInfo
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/secrets/{id}/versions [get]
+func (h *SecretHandler) ListVersions(c *gin.Context) {
+	versions, err := h.secretService.ListVersions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to list versions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// GetVersion retrieves one version of a secret
+// @Summary Get a secret version
+// @Description Retrieve and decrypt one specific version of a secret
+// @Tags versions
+// @Produce json
+// @Param id path string true "Secret ID"
+// @Param version path int true "Version number"
+// @Success 200 {object} models.SecretResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /api/secrets/{id}/versions/{version} [get]
+func (h *SecretHandler) GetVersion(c *gin.Context) {
+	version, ok := versionParam(c)
+	if !ok {
+		return
+	}
+
+	secret, err := h.secretService.GetVersion(c.Request.Context(), c.Param("id"), version)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), models.ErrorResponse{
+			Error:   "Version not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, secret)
+}
+
+// RestoreVersion restores a prior version of a secret
+// @Summary Restore a secret version
+// @Description Make a prior version current again; the replaced content is kept in the history. Callers who may not read the secret get its metadata only. If-Match must carry the ETag the secret was read with; a secret changed since is left alone and answered with 412.
+// @Tags versions
+// @Produce json
+// @Param id path string true "Secret ID"
+// @Param version path int true "Version number"
+// @Param If-Match header string true "ETag of the secret as last read"
+// @Success 200 {object} models.SecretResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 412 {object} models.ErrorResponse
+// @Failure 428 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/secrets/{id}/versions/{version}/restore [post]
+func (h *SecretHandler) RestoreVersion(c *gin.Context) {
+	version, ok := versionParam(c)
+	if !ok {
+		return
+	}
+
+	revision, ok := ifMatchRevision(c)
+	if !ok {
+		return
+	}
+
+	secret, err := h.secretService.RestoreVersion(c.Request.Context(), c.Param("id"), revision, version)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to restore version",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	setETag(c, secret.Revision)
+	c.JSON(http.StatusOK, secret)
+}
+
+// versionParam parses the version path parameter, responding with 400 when invalid
+func versionParam(c *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: "Version must be a positive integer",
+		})
+		return 0, false
+	}
+	return version, true
+}
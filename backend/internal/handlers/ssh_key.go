@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"my-vault/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportSSHKey exports the key pair of an ssh_key secret
+// @Summary Export an SSH key
+// @Description Export the private key of an ssh_key secret in the OpenSSH or PEM (PKCS#8) format, optionally encrypted with a passphrase, together with its public key and fingerprint
+// @Tags secrets
+// @Accept json
+// @Produce json
+// @Param id path string true "Secret ID"
+// @Param options body models.SSHKeyExportRequest true "Export options"
+// @Success 200 {object} models.SSHKeyExportResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/secrets/{id}/ssh-key/export [post]
+func (h *SecretHandler) ExportSSHKey(c *gin.Context) {
+	var req models.SSHKeyExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	exported, err := h.secretService.ExportSSHKey(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to export SSH key",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, exported)
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"my-vault/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TOTP returns the current one-time password of a secret
+// @Summary Get the current TOTP code
+// @Description Generate the current time-based one-time password from a secret holding an otpauth:// URI or base32 seed, either as a login's totp field or as the primary value
+// @Tags secrets
+// @Produce json
+// @Param id path string true "Secret ID"
+// @Success 200 {object} models.TOTPResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/secrets/{id}/totp [get]
+func (h *SecretHandler) TOTP(c *gin.Context) {
+	totp, err := h.secretService.TOTP(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to generate TOTP code",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, totp)
+}
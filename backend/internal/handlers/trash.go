@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+
+	"my-vault/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListTrash lists trashed secrets
+// @Summary List trashed secrets
+// @Description Get the metadata of all deleted secrets that have not been purged yet, most recently deleted first
+// @Tags trash
+// @Produce json
+// @Success 200 {array} models.SecretSummary
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/trash [get]
+func (h *SecretHandler) ListTrash(c *gin.Context) {
+	secrets, err := h.secretService.ListTrash(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to list trash",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, secrets)
+}
+
+// RestoreFromTrash restores a trashed secret
+// @Summary Restore a trashed secret
+// @Description Move a deleted secret out of the trash. Requires both delete and update on the secret; callers who may not read it get its metadata only.
+// @Tags trash
+// @Produce json
+// @Param id path string true "Secret ID"
+// @Success 200 {object} models.SecretResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/trash/{id}/restore [post]
+func (h *SecretHandler) RestoreFromTrash(c *gin.Context) {
+	secret, err := h.secretService.RestoreFromTrash(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to restore secret",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	setETag(c, secret.Revision)
+	c.JSON(http.StatusOK, secret)
+}
+
+// Purge permanently deletes a trashed secret
+// @Summary Purge a trashed secret
+// @Description Permanently delete a secret from the trash together with its version history
+// @Tags trash
+// @Param id path string true "Secret ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/trash/{id} [delete]
+func (h *SecretHandler) Purge(c *gin.Context) {
+	if err := h.secretService.Purge(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to purge secret",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"my-vault/internal/models"
@@ -21,9 +22,54 @@ func NewVaultHandler(vaultService *services.VaultService) *VaultHandler {
 	}
 }
 
+// Initialize sets the master password of a new vault
+// @Summary Initialize vault
+// @Description Set the master password of a new vault and unlock it. Only the vault owner may initialize the vault, and only once.
+// @Tags vault
+// @Accept json
+// @Produce json
+// @Param request body models.UnlockRequest true "Master password of the new vault"
+// @Success 201 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/init [post]
+func (h *VaultHandler) Initialize(c *gin.Context) {
+	var req models.UnlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: "Failed to parse request body",
+		})
+		return
+	}
+
+	if req.MasterPassword == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: "Master password is required",
+		})
+		return
+	}
+
+	if err := h.vaultService.Initialize(c.Request.Context(), req.MasterPassword); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+			Error:   "Failed to initialize vault",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Message: "Vault initialized and unlocked successfully",
+	})
+}
+
 // Unlock unlocks the vault with the provided master password
 // @Summary Unlock vault
-// @Description Unlock the vault using the master password
+// @Description Unlock the vault using the master password. Only the vault owner may unlock it; a vault that was never initialized is answered with 409.
 // @Tags vault
 // @Accept json
 // @Produce json
@@ -31,6 +77,9 @@ func NewVaultHandler(vaultService *services.VaultService) *VaultHandler {
 // @Success 200 {object} models.SuccessResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /api/unlock [post]
 func (h *VaultHandler) Unlock(c *gin.Context) {
 	var req models.UnlockRequest
@@ -50,7 +99,14 @@ func (h *VaultHandler) Unlock(c *gin.Context) {
 		return
 	}
 
-	if err := h.vaultService.Unlock(req.MasterPassword); err != nil {
+	if err := h.vaultService.Unlock(c.Request.Context(), req.MasterPassword); err != nil {
+		if !errors.Is(err, services.ErrInvalidPassword) {
+			c.JSON(errorStatus(err, http.StatusInternalServerError), models.ErrorResponse{
+				Error:   "Failed to unlock vault",
+				Message: err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 			Error:   "Authentication failed",
 			Message: "Failed to unlock vault",
@@ -65,10 +121,12 @@ func (h *VaultHandler) Unlock(c *gin.Context) {
 
 // Lock locks the vault
 // @Summary Lock vault
-// @Description Lock the vault and clear encryption key from memory
+// @Description Lock the vault and clear encryption key from memory. Only the vault owner may lock it.
 // @Tags vault
 // @Produce json
 // @Success 200 {object} models.SuccessResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Router /api/lock [post]
 func (h *VaultHandler) Lock(c *gin.Context) {
 	h.vaultService.Lock()
@@ -86,7 +144,7 @@ func (h *VaultHandler) Lock(c *gin.Context) {
 // @Success 200 {object} models.VaultStatus
 // @Router /api/status [get]
 func (h *VaultHandler) Status(c *gin.Context) {
-	status := h.vaultService.GetStatus()
+	status := h.vaultService.GetStatus(c.Request.Context())
 
 	c.JSON(http.StatusOK, status)
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// Attachment is the stored metadata of a file attached to a secret.
+// The content itself lives encrypted in the blob store.
+type Attachment struct {
+	ID            string    `json:"id" db:"id"`
+	SecretID      string    `json:"secret_id" db:"secret_id"`
+	EncryptedName []byte    `json:"encrypted_name" db:"encrypted_name"`
+	Size          int64     `json:"size" db:"size"`
+	CreatedBy     string    `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// AttachmentInfo represents a file attached to a secret
+// @Description Attachment metadata; the filename is stored encrypted
+type AttachmentInfo struct {
+	ID        string    `json:"id" example:"8d3e4a51-6c0b-4f7e-9a43-2f1f0c9d2b7e"`
+	SecretID  string    `json:"secret_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name      string    `json:"name" example:"service-account.json"`
+	Size      int64     `json:"size" example:"2310"`
+	CreatedBy string    `json:"created_by" example:"user:alice"`
+	CreatedAt time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// Audit actions
+const (
+	AuditSecretRevealed     = "secret_revealed"
+	AuditAttachmentRevealed = "attachment_revealed"
+)
+
+// AuditEvent records that a principal acted on a secret; it never carries the secret's value
+type AuditEvent struct {
+	Time      time.Time `json:"time" example:"2024-01-15T10:30:00Z"`
+	Action    string    `json:"action" example:"secret_revealed"`
+	Principal string    `json:"principal" example:"user:alice"`
+	SecretID  string    `json:"secret_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Version   int       `json:"version" example:"3"`
+
+	AttachmentID string `json:"attachment_id,omitempty" example:"9b2d7c1e-3f4a-5b6c-7d8e-9f0a1b2c3d4e"`
+}
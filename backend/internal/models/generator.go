@@ -0,0 +1,37 @@
+package models
+
+// Generator modes
+const (
+	GenerateModePassword   = "password"
+	GenerateModePassphrase = "passphrase"
+	GenerateModeSSHKey     = "ssh_key"
+)
+
+// GenerateRequest configures the password, passphrase and SSH key generator
+// @Description Generator options; character classes default to enabled when omitted
+type GenerateRequest struct {
+	Mode             string  `json:"mode" example:"password" enums:"password,passphrase,ssh_key"`
+	Length           int     `json:"length" example:"24"`
+	Lowercase        *bool   `json:"lowercase,omitempty" example:"true"`
+	Uppercase        *bool   `json:"uppercase,omitempty" example:"true"`
+	Digits           *bool   `json:"digits,omitempty" example:"true"`
+	Symbols          *bool   `json:"symbols,omitempty" example:"true"`
+	ExcludeAmbiguous bool    `json:"exclude_ambiguous" example:"true"`
+	Words            int     `json:"words" example:"6"`
+	Separator        *string `json:"separator,omitempty" example:"-"`
+	Capitalize       bool    `json:"capitalize" example:"false"`
+	IncludeNumber    bool    `json:"include_number" example:"false"`
+	KeyAlgorithm     string  `json:"key_algorithm" example:"ed25519" enums:"ed25519,rsa"`
+	KeyBits          int     `json:"key_bits" example:"3072"`
+	Comment          string  `json:"comment" example:"deploy@ci"`
+}
+
+// GenerateResponse represents a generated password, passphrase or SSH private key
+// @Description Generated value with an estimate of its entropy in bits; SSH keys report their security strength
+type GenerateResponse struct {
+	Mode        string  `json:"mode" example:"password"`
+	Value       string  `json:"value" example:"q7R!vZ2m#Lk9Wd@4pXs8&hTe"`
+	Entropy     float64 `json:"entropy_bits" example:"155.4"`
+	PublicKey   string  `json:"public_key,omitempty" example:"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAI... deploy@ci"`
+	Fingerprint string  `json:"fingerprint,omitempty" example:"SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// Notification kinds
+const (
+	NotificationExpiring    = "expiring"
+	NotificationExpired     = "expired"
+	NotificationRotationDue = "rotation_due"
+)
+
+// Notification is a reminder about a secret; it never carries the secret's value
+type Notification struct {
+	Kind     string    `json:"kind" example:"expiring"`
+	SecretID string    `json:"secret_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Title    string    `json:"title" example:"GitHub API Token"`
+	Type     string    `json:"type" example:"api_token"`
+	Folder   string    `json:"folder" example:"prod/payments/stripe"`
+	DueAt    time.Time `json:"due_at" example:"2025-01-15T00:00:00Z"`
+	SentAt   time.Time `json:"sent_at" example:"2025-01-08T00:00:00Z"`
+}
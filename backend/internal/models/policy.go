@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+)
+
+// Policy subject types
+const (
+	SubjectUser  = "user"
+	SubjectGroup = "group"
+	SubjectToken = "token"
+)
+
+// Policy statement effects
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// Policy actions on secrets
+const (
+	ActionRead   = "read"
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Policy represents a stored authorization policy
+// @Description Named policy document attached to users, groups and tokens
+type Policy struct {
+	ID          string          `json:"id" db:"id" example:"7a1c2d3e-4f50-6172-8394-a5b6c7d8e9f0"`
+	Name        string          `json:"name" db:"name" example:"prod-api-token-readers"`
+	Description string          `json:"description" db:"description" example:"Read access to production API tokens"`
+	Document    PolicyDocument  `json:"document" db:"document"`
+	Subjects    []PolicySubject `json:"subjects"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// PolicyDocument is the JSON policy language evaluated on every secret access
+// @Description Ordered list of allow/deny statements
+type PolicyDocument struct {
+	Statements []PolicyStatement `json:"statements"`
+}
+
+// PolicyStatement grants or denies actions on resources matching all conditions
+// @Description Single allow/deny rule, e.g. allow read on secrets with tag=prod, type=api_token
+type PolicyStatement struct {
+	Effect     string              `json:"effect" example:"allow"`
+	Actions    []string            `json:"actions" example:"read"`
+	Resource   string              `json:"resource" example:"secrets"`
+	Conditions map[string][]string `json:"conditions,omitempty"`
+}
+
+// PolicySubject identifies who a policy is attached to
+// @Description User, group or token a policy applies to
+type PolicySubject struct {
+	Type string `json:"type" example:"group"`
+	ID   string `json:"id" example:"ops"`
+}
+
+// CreatePolicyRequest represents the request to create a new policy
+// @Description Request payload for creating a new policy
+type CreatePolicyRequest struct {
+	Name        string          `json:"name" validate:"required" example:"prod-api-token-readers" binding:"required"`
+	Description string          `json:"description" example:"Read access to production API tokens"`
+	Document    PolicyDocument  `json:"document" validate:"required" binding:"required"`
+	Subjects    []PolicySubject `json:"subjects"`
+}
+
+// UpdatePolicyRequest represents the request to update an existing policy
+// @Description Request payload for updating an existing policy
+type UpdatePolicyRequest struct {
+	Name        string          `json:"name" validate:"required" example:"prod-api-token-readers" binding:"required"`
+	Description string          `json:"description" example:"Read access to production API tokens"`
+	Document    PolicyDocument  `json:"document" validate:"required" binding:"required"`
+	Subjects    []PolicySubject `json:"subjects"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+)
+
+// HealthReportOptions tunes the password health report
+type HealthReportOptions struct {
+	// MinScore is the lowest zxcvbn score (0-4) not reported as weak
+	MinScore int
+
+	// MaxAge reports secrets not updated for longer than this
+	MaxAge time.Duration
+}
+
+// HealthReport summarizes weak, reused, breached, old and incomplete secrets
+// @Description Password health report over every secret the caller may read
+type HealthReport struct {
+	GeneratedAt        time.Time          `json:"generated_at" example:"2024-01-15T10:30:00Z"`
+	SecretsScanned     int                `json:"secrets_scanned" example:"42"`
+	BreachCheckEnabled bool               `json:"breach_check_enabled" example:"true"`
+	Weak               []WeakSecret       `json:"weak"`
+	Reused             []ReusedValue      `json:"reused"`
+	Breached           []BreachedSecret   `json:"breached"`
+	Old                []OldSecret        `json:"old"`
+	Incomplete         []IncompleteSecret `json:"incomplete"`
+}
+
+// SecretFieldRef identifies one field of a secret in a report
+// @Description Reference to a field of a secret
+type SecretFieldRef struct {
+	ID    string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Title string `json:"title" example:"GitHub"`
+	Field string `json:"field" example:"password"`
+}
+
+// WeakSecret is a credential with a low strength estimate
+// @Description Credential whose zxcvbn-style score is below the threshold
+type WeakSecret struct {
+	SecretFieldRef
+	Score     int    `json:"score" example:"1"`
+	CrackTime string `json:"crack_time" example:"3 hours"`
+}
+
+// ReusedValue groups secrets sharing the same credential value
+// @Description Secrets whose credentials have identical keyed hashes
+type ReusedValue struct {
+	Count   int              `json:"count" example:"3"`
+	Secrets []SecretFieldRef `json:"secrets"`
+}
+
+// BreachedSecret is a credential found in the breached passwords dataset
+// @Description Credential whose SHA-1 hash appears in the local Have I Been Pwned dataset
+type BreachedSecret struct {
+	SecretFieldRef
+	Occurrences int `json:"occurrences" example:"52256179"`
+}
+
+// BreachReport lists stored credentials found in the breached passwords dataset
+// @Description Breached credentials among the secrets the caller may read
+type BreachReport struct {
+	GeneratedAt    time.Time        `json:"generated_at" example:"2024-01-15T10:30:00Z"`
+	SecretsScanned int              `json:"secrets_scanned" example:"42"`
+	Breached       []BreachedSecret `json:"breached"`
+}
+
+// OldSecret is a secret not updated for longer than the report's maximum age
+// @Description Secret not updated within the maximum age
+type OldSecret struct {
+	ID        string    `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Title     string    `json:"title" example:"GitHub"`
+	UpdatedAt time.Time `json:"updated_at" example:"2023-01-15T10:30:00Z"`
+	AgeDays   int       `json:"age_days" example:"365"`
+}
+
+// IncompleteSecret is a secret missing fields its type expects
+// @Description Secret missing fields expected for its type
+type IncompleteSecret struct {
+	ID            string   `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Title         string   `json:"title" example:"GitHub"`
+	Type          string   `json:"type" example:"login"`
+	MissingFields []string `json:"missing_fields" example:"username,urls"`
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"my-vault/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrTooLarge is returned when stored content would exceed a size limit
+var ErrTooLarge = errors.New("content too large")
+
+// CreateAttachment stores the metadata of an attachment, provided the
+// secret's attachments stay within maxTotalSize bytes (zero disables the limit)
+func (r *SecretRepository) CreateAttachment(ctx context.Context, attachment *models.Attachment, maxTotalSize int64) error {
+	query := `
+		INSERT INTO attachments (id, secret_id, encrypted_name, size, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if maxTotalSize > 0 {
+			// Locking the secret makes concurrent uploads to it check the total one at a time
+			if _, err := tx.Exec(ctx, `SELECT 1 FROM secrets WHERE id = $1 FOR NO KEY UPDATE`, attachment.SecretID); err != nil {
+				return err
+			}
+
+			var used int64
+			err := tx.QueryRow(ctx, `SELECT COALESCE(SUM(size), 0) FROM attachments WHERE secret_id = $1`, attachment.SecretID).Scan(&used)
+			if err != nil {
+				return err
+			}
+			if used+attachment.Size > maxTotalSize {
+				return fmt.Errorf("%w: secret attachments exceed %d bytes", ErrTooLarge, maxTotalSize)
+			}
+		}
+
+		_, err := tx.Exec(ctx, query,
+			attachment.ID,
+			attachment.SecretID,
+			attachment.EncryptedName,
+			attachment.Size,
+			attachment.CreatedBy,
+			attachment.CreatedAt,
+		)
+		return err
+	})
+
+	if errors.Is(err, ErrTooLarge) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	return nil
+}
+
+// ListAttachments retrieves the attachments of a secret, oldest first
+func (r *SecretRepository) ListAttachments(ctx context.Context, secretID string) ([]*models.Attachment, error) {
+	query := `
+		SELECT id, secret_id, encrypted_name, size, created_by, created_at
+		FROM attachments
+		WHERE secret_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.pool.Query(ctx, query, secretID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []*models.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// GetAttachment retrieves one attachment of a secret
+func (r *SecretRepository) GetAttachment(ctx context.Context, secretID, id string) (*models.Attachment, error) {
+	query := `
+		SELECT id, secret_id, encrypted_name, size, created_by, created_at
+		FROM attachments
+		WHERE secret_id = $1 AND id = $2
+	`
+
+	attachment, err := scanAttachment(r.pool.QueryRow(ctx, query, secretID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("attachment %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return attachment, nil
+}
+
+// DeleteAttachment removes the metadata of an attachment
+func (r *SecretRepository) DeleteAttachment(ctx context.Context, secretID, id string) error {
+	query := `DELETE FROM attachments WHERE secret_id = $1 AND id = $2`
+
+	result, err := r.pool.Exec(ctx, query, secretID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("attachment %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// AttachmentsSize returns the total size of a secret's attachments in bytes
+func (r *SecretRepository) AttachmentsSize(ctx context.Context, secretID string) (int64, error) {
+	var size int64
+	err := r.pool.QueryRow(ctx, `SELECT COALESCE(SUM(size), 0) FROM attachments WHERE secret_id = $1`, secretID).Scan(&size)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum attachment sizes: %w", err)
+	}
+
+	return size, nil
+}
+
+// scanAttachment scans an attachments row
+func scanAttachment(row pgx.Row) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := row.Scan(
+		&attachment.ID,
+		&attachment.SecretID,
+		&attachment.EncryptedName,
+		&attachment.Size,
+		&attachment.CreatedBy,
+		&attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
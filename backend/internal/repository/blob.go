@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// BlobStore keeps attachment contents as files on disk, one directory per secret.
+// It stores bytes as given; callers encrypt them before writing.
+type BlobStore struct {
+	dir string
+}
+
+// NewBlobStore creates a blob store rooted at dir, creating the directory if needed
+func NewBlobStore(dir string) (*BlobStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	return &BlobStore{dir: dir}, nil
+}
+
+// Write stores a blob by streaming it through write into a temporary file
+// that is synced and renamed into place, so readers never see partial blobs
+func (s *BlobStore) Write(secretID, id string, write func(w io.Writer) error) error {
+	dir := filepath.Join(s.dir, secretID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, id+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := write(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close blob: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, id)); err != nil {
+		return fmt.Errorf("failed to store blob: %w", err)
+	}
+
+	return nil
+}
+
+// Open opens a blob for reading
+func (s *BlobStore) Open(secretID, id string) (*os.File, error) {
+	file, err := os.Open(filepath.Join(s.dir, secretID, id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("blob %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+
+	return file, nil
+}
+
+// Delete removes a blob; removing a missing blob is not an error
+func (s *BlobStore) Delete(secretID, id string) error {
+	err := os.Remove(filepath.Join(s.dir, secretID, id))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAll removes every blob of a secret
+func (s *BlobStore) DeleteAll(secretID string) error {
+	if err := os.RemoveAll(filepath.Join(s.dir, secretID)); err != nil {
+		return fmt.Errorf("failed to delete blobs: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+// ErrLocked is returned by backends that cannot read their contents before the vault is unlocked
+var ErrLocked = errors.New("storage is locked")
+
+// MemoryDB holds a whole vault in memory and is safe for concurrent use.
+// Opened with OpenVaultFile, every write is persisted to the encrypted vault
+// file before it becomes visible; created with NewMemoryDB, the vault is gone
+// once the process exits.
+type MemoryDB struct {
+	mu     sync.RWMutex
+	header *models.VaultHeader
+	data   *memoryData // nil while a vault file is locked
+	file   *vaultFile  // nil for a vault kept in memory only
+}
+
+// NewMemoryDB creates an empty vault that lives in memory only. It needs no
+// unlock to be readable, which makes it suitable for tests and throwaway servers.
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{data: newMemoryData()}
+}
+
+// Close releases the vault file, if any, and drops the vault content
+func (db *MemoryDB) Close() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.data = nil
+	if db.file != nil {
+		db.file.forget()
+		db.file.lock.Unlock()
+	}
+}
+
+// memoryData is the vault content, encrypted as a whole in the vault file
+type memoryData struct {
+	Secrets     map[string]*memorySecret           `json:"secrets"`
+	Versions    map[string][]*models.SecretVersion `json:"versions"` // by secret ID, oldest first
+	Attachments map[string]*models.Attachment      `json:"attachments"`
+	Policies    map[string]*models.Policy          `json:"policies"`
+}
+
+// memorySecret is the stored form of a secret, including its reminder state
+type memorySecret struct {
+	ID                 string               `json:"id"`
+	Title              string               `json:"title"`
+	Type               string               `json:"type"`
+	EncryptedValue     []byte               `json:"encrypted_value"`
+	Fields             []models.SecretField `json:"fields"`
+	Folder             string               `json:"folder"`
+	Tags               []string             `json:"tags"`
+	Version            int                  `json:"version"`
+	Revision           int                  `json:"revision"`
+	UpdatedBy          string               `json:"updated_by"`
+	CreatedAt          time.Time            `json:"created_at"`
+	UpdatedAt          time.Time            `json:"updated_at"`
+	DeletedAt          *time.Time           `json:"deleted_at,omitempty"`
+	ExpiresAt          *time.Time           `json:"expires_at,omitempty"`
+	RotateEvery        time.Duration        `json:"rotate_every,omitempty"`
+	RotatedAt          time.Time            `json:"rotated_at"`
+	ExpiryNotifiedAt   *time.Time           `json:"expiry_notified_at,omitempty"`
+	RotationNotifiedAt *time.Time           `json:"rotation_notified_at,omitempty"`
+}
+
+// newMemoryData returns the content of an empty vault
+func newMemoryData() *memoryData {
+	return &memoryData{
+		Secrets:     map[string]*memorySecret{},
+		Versions:    map[string][]*models.SecretVersion{},
+		Attachments: map[string]*models.Attachment{},
+		Policies:    map[string]*models.Policy{},
+	}
+}
+
+// clone copies the record maps. Records are never modified in place, so the
+// copy can be changed freely without affecting d.
+func (d *memoryData) clone() *memoryData {
+	return &memoryData{
+		Secrets:     maps.Clone(d.Secrets),
+		Versions:    maps.Clone(d.Versions),
+		Attachments: maps.Clone(d.Attachments),
+		Policies:    maps.Clone(d.Policies),
+	}
+}
+
+// read runs fn against the vault content
+func (db *MemoryDB) read(fn func(d *memoryData) error) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.data == nil {
+		return ErrLocked
+	}
+
+	return fn(db.data)
+}
+
+// write runs fn against a copy of the vault content and keeps the copy once
+// it has been persisted. fn must replace the records it changes rather than
+// modify them, since they are shared with the current content.
+func (db *MemoryDB) write(fn func(d *memoryData) error) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.data == nil {
+		return ErrLocked
+	}
+
+	next := db.data.clone()
+	if err := fn(next); err != nil {
+		return err
+	}
+
+	if db.file != nil {
+		if err := db.file.save(db.header, next); err != nil {
+			return err
+		}
+	}
+
+	db.data = next
+	return nil
+}
+
+// newMemorySecret copies a secret into its stored form
+func newMemorySecret(secret *models.Secret) *memorySecret {
+	return &memorySecret{
+		ID:             secret.ID,
+		Title:          secret.Title,
+		Type:           secret.Type,
+		EncryptedValue: secret.EncryptedValue,
+		Fields:         slices.Clone(fieldsOrEmpty(secret.Fields)),
+		Folder:         secret.Folder,
+		Tags:           normalizeTags(secret.Tags),
+		Version:        secret.Version,
+		Revision:       secret.Revision,
+		UpdatedBy:      secret.UpdatedBy,
+		CreatedAt:      secret.CreatedAt,
+		UpdatedAt:      secret.UpdatedAt,
+		DeletedAt:      cloneTime(secret.DeletedAt),
+		ExpiresAt:      cloneTime(secret.ExpiresAt),
+		RotateEvery:    secret.RotateEvery.Truncate(time.Second),
+		RotatedAt:      secret.RotatedAt,
+	}
+}
+
+// model returns a copy of the stored secret
+func (s *memorySecret) model() *models.Secret {
+	return &models.Secret{
+		ID:             s.ID,
+		Title:          s.Title,
+		Type:           s.Type,
+		EncryptedValue: s.EncryptedValue,
+		Fields:         slices.Clone(s.Fields),
+		Folder:         s.Folder,
+		Tags:           slices.Clone(s.Tags),
+		Version:        s.Version,
+		Revision:       s.Revision,
+		UpdatedBy:      s.UpdatedBy,
+		CreatedAt:      s.CreatedAt,
+		UpdatedAt:      s.UpdatedAt,
+		DeletedAt:      cloneTime(s.DeletedAt),
+		ExpiresAt:      cloneTime(s.ExpiresAt),
+		RotateEvery:    s.RotateEvery,
+		RotatedAt:      s.RotatedAt,
+	}
+}
+
+// normalizeTags returns the distinct tags in sorted order, as the SQL backends store them
+func normalizeTags(tags []string) []string {
+	normalized := slices.Clone(tags)
+	if normalized == nil {
+		normalized = []string{}
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+// cloneTime copies an optional timestamp
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+// cloneVersion copies an archived version
+func cloneVersion(v *models.SecretVersion) *models.SecretVersion {
+	c := *v
+	c.Fields = slices.Clone(v.Fields)
+	return &c
+}
+
+// clonePolicy copies a policy, including its document
+func clonePolicy(p *models.Policy) *models.Policy {
+	c := *p
+	c.Subjects = slices.Clone(p.Subjects)
+	c.Document.Statements = slices.Clone(p.Document.Statements)
+	for i := range c.Document.Statements {
+		statement := &c.Document.Statements[i]
+		statement.Actions = slices.Clone(statement.Actions)
+		statement.Conditions = maps.Clone(statement.Conditions)
+		for key, values := range statement.Conditions {
+			statement.Conditions[key] = slices.Clone(values)
+		}
+	}
+	return &c
+}
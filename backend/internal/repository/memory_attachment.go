@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"my-vault/internal/models"
+)
+
+// CreateAttachment stores the metadata of an attachment, provided the
+// secret's attachments stay within maxTotalSize bytes (zero disables the limit)
+func (r *MemorySecretRepository) CreateAttachment(ctx context.Context, attachment *models.Attachment, maxTotalSize int64) error {
+	return r.db.write(func(d *memoryData) error {
+		if _, ok := d.Secrets[attachment.SecretID]; !ok {
+			return fmt.Errorf("failed to create attachment: secret %w", ErrNotFound)
+		}
+		if _, ok := d.Attachments[attachment.ID]; ok {
+			return fmt.Errorf("attachment %w", ErrConflict)
+		}
+
+		if maxTotalSize > 0 {
+			used := attachment.Size
+			for _, stored := range d.Attachments {
+				if stored.SecretID == attachment.SecretID {
+					used += stored.Size
+				}
+			}
+			if used > maxTotalSize {
+				return fmt.Errorf("%w: secret attachments exceed %d bytes", ErrTooLarge, maxTotalSize)
+			}
+		}
+
+		stored := *attachment
+		d.Attachments[attachment.ID] = &stored
+		return nil
+	})
+}
+
+// ListAttachments retrieves the attachments of a secret, oldest first
+func (r *MemorySecretRepository) ListAttachments(ctx context.Context, secretID string) ([]*models.Attachment, error) {
+	var attachments []*models.Attachment
+	err := r.db.read(func(d *memoryData) error {
+		for _, stored := range d.Attachments {
+			if stored.SecretID == secretID {
+				attachment := *stored
+				attachments = append(attachments, &attachment)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(attachments, func(a, b *models.Attachment) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.ID, b.ID))
+	})
+
+	return attachments, nil
+}
+
+// GetAttachment retrieves the metadata of one attachment of a secret
+func (r *MemorySecretRepository) GetAttachment(ctx context.Context, secretID, id string) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.db.read(func(d *memoryData) error {
+		stored, ok := d.Attachments[id]
+		if !ok || stored.SecretID != secretID {
+			return fmt.Errorf("attachment %w", ErrNotFound)
+		}
+
+		attachment = *stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// DeleteAttachment removes the metadata of an attachment
+func (r *MemorySecretRepository) DeleteAttachment(ctx context.Context, secretID, id string) error {
+	return r.db.write(func(d *memoryData) error {
+		stored, ok := d.Attachments[id]
+		if !ok || stored.SecretID != secretID {
+			return fmt.Errorf("attachment %w", ErrNotFound)
+		}
+
+		delete(d.Attachments, id)
+		return nil
+	})
+}
+
+// AttachmentsSize returns the total size of a secret's attachments in bytes
+func (r *MemorySecretRepository) AttachmentsSize(ctx context.Context, secretID string) (int64, error) {
+	var size int64
+	err := r.db.read(func(d *memoryData) error {
+		for _, stored := range d.Attachments {
+			if stored.SecretID == secretID {
+				size += stored.Size
+			}
+		}
+		return nil
+	})
+
+	return size, err
+}
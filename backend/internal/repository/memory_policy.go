@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"my-vault/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MemoryPolicyRepository handles in-memory operations for policies
+type MemoryPolicyRepository struct {
+	db *MemoryDB
+}
+
+// NewMemoryPolicyRepository creates a new in-memory policy repository
+func NewMemoryPolicyRepository(db *MemoryDB) *MemoryPolicyRepository {
+	return &MemoryPolicyRepository{
+		db: db,
+	}
+}
+
+// Create creates a new policy and its subject attachments
+func (r *MemoryPolicyRepository) Create(ctx context.Context, policy *models.Policy) error {
+	return r.db.write(func(d *memoryData) error {
+		if d.policyNameTaken(policy.Name, "") {
+			return fmt.Errorf("policy name %w", ErrConflict)
+		}
+
+		policy.ID = uuid.New().String()
+		now := time.Now()
+		policy.CreatedAt = now
+		policy.UpdatedAt = now
+
+		d.Policies[policy.ID] = newMemoryPolicy(policy)
+		return nil
+	})
+}
+
+// Get retrieves a policy by ID
+func (r *MemoryPolicyRepository) Get(ctx context.Context, id string) (*models.Policy, error) {
+	var policy *models.Policy
+	err := r.db.read(func(d *memoryData) error {
+		stored, ok := d.Policies[id]
+		if !ok {
+			return fmt.Errorf("policy %w", ErrNotFound)
+		}
+
+		policy = clonePolicy(stored)
+		return nil
+	})
+
+	return policy, err
+}
+
+// List retrieves all policies
+func (r *MemoryPolicyRepository) List(ctx context.Context) ([]*models.Policy, error) {
+	return r.collect(func(p *models.Policy) bool { return true })
+}
+
+// ListForSubjects retrieves the policies attached to any of the given subjects
+func (r *MemoryPolicyRepository) ListForSubjects(ctx context.Context, subjects []models.PolicySubject) ([]*models.Policy, error) {
+	if len(subjects) == 0 {
+		return nil, nil
+	}
+
+	return r.collect(func(p *models.Policy) bool {
+		return slices.ContainsFunc(p.Subjects, func(s models.PolicySubject) bool {
+			return slices.Contains(subjects, s)
+		})
+	})
+}
+
+// Update updates an existing policy and replaces its subject attachments
+func (r *MemoryPolicyRepository) Update(ctx context.Context, policy *models.Policy) error {
+	return r.db.write(func(d *memoryData) error {
+		stored, ok := d.Policies[policy.ID]
+		if !ok {
+			return fmt.Errorf("policy %w", ErrNotFound)
+		}
+		if d.policyNameTaken(policy.Name, policy.ID) {
+			return fmt.Errorf("policy name %w", ErrConflict)
+		}
+
+		policy.UpdatedAt = time.Now()
+
+		updated := newMemoryPolicy(policy)
+		updated.CreatedAt = stored.CreatedAt
+		d.Policies[policy.ID] = updated
+		return nil
+	})
+}
+
+// Delete removes a policy by ID
+func (r *MemoryPolicyRepository) Delete(ctx context.Context, id string) error {
+	return r.db.write(func(d *memoryData) error {
+		if _, ok := d.Policies[id]; !ok {
+			return fmt.Errorf("policy %w", ErrNotFound)
+		}
+
+		delete(d.Policies, id)
+		return nil
+	})
+}
+
+// collect returns copies of the policies matching keep, ordered by name
+func (r *MemoryPolicyRepository) collect(keep func(p *models.Policy) bool) ([]*models.Policy, error) {
+	var policies []*models.Policy
+	err := r.db.read(func(d *memoryData) error {
+		for _, stored := range d.Policies {
+			if keep(stored) {
+				policies = append(policies, clonePolicy(stored))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(policies, func(a, b *models.Policy) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return policies, nil
+}
+
+// policyNameTaken reports whether a policy other than exceptID uses name
+func (d *memoryData) policyNameTaken(name, exceptID string) bool {
+	for id, stored := range d.Policies {
+		if id != exceptID && stored.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// newMemoryPolicy copies a policy into its stored form, with its subjects
+// distinct and sorted as the SQL backends return them
+func newMemoryPolicy(policy *models.Policy) *models.Policy {
+	stored := clonePolicy(policy)
+	if stored.Subjects == nil {
+		stored.Subjects = []models.PolicySubject{}
+	}
+	slices.SortFunc(stored.Subjects, func(a, b models.PolicySubject) int {
+		return cmp.Or(strings.Compare(a.Type, b.Type), strings.Compare(a.ID, b.ID))
+	})
+	stored.Subjects = slices.Compact(stored.Subjects)
+	return stored
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+// ListExpiryReminders retrieves secrets expiring before cutoff, including
+// expired ones, whose expiry reminder has not been sent yet
+func (r *MemorySecretRepository) ListExpiryReminders(ctx context.Context, cutoff time.Time) ([]*models.Secret, error) {
+	return r.collect(func(s *memorySecret) bool {
+		return s.DeletedAt == nil && s.ExpiresAt != nil && !s.ExpiresAt.After(cutoff) && s.ExpiryNotifiedAt == nil
+	}, func(a, b *memorySecret) int {
+		return a.ExpiresAt.Compare(*b.ExpiresAt)
+	})
+}
+
+// ListRotationReminders retrieves secrets whose rotation is due at now and
+// whose rotation reminder has not been sent yet
+func (r *MemorySecretRepository) ListRotationReminders(ctx context.Context, now time.Time) ([]*models.Secret, error) {
+	return r.collect(func(s *memorySecret) bool {
+		return s.DeletedAt == nil && s.RotateEvery > 0 && !s.RotatedAt.Add(s.RotateEvery).After(now) && s.RotationNotifiedAt == nil
+	}, func(a, b *memorySecret) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+}
+
+// MarkExpiryNotified records that the expiry reminder of a secret was sent
+func (r *MemorySecretRepository) MarkExpiryNotified(ctx context.Context, id string, at time.Time) error {
+	return r.db.write(func(d *memoryData) error {
+		if stored, ok := d.Secrets[id]; ok {
+			marked := *stored
+			marked.ExpiryNotifiedAt = &at
+			d.Secrets[id] = &marked
+		}
+		return nil
+	})
+}
+
+// MarkRotationNotified records that the rotation reminder of a secret was sent
+func (r *MemorySecretRepository) MarkRotationNotified(ctx context.Context, id string, at time.Time) error {
+	return r.db.write(func(d *memoryData) error {
+		if stored, ok := d.Secrets[id]; ok {
+			marked := *stored
+			marked.RotationNotifiedAt = &at
+			d.Secrets[id] = &marked
+		}
+		return nil
+	})
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"my-vault/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MemorySecretRepository handles in-memory operations for secrets
+type MemorySecretRepository struct {
+	db *MemoryDB
+}
+
+// NewMemorySecretRepository creates a new in-memory secret repository
+func NewMemorySecretRepository(db *MemoryDB) *MemorySecretRepository {
+	return &MemorySecretRepository{
+		db: db,
+	}
+}
+
+// Create creates a new secret
+func (r *MemorySecretRepository) Create(ctx context.Context, secret *models.Secret) error {
+	return r.db.write(func(d *memoryData) error {
+		secret.ID = uuid.New().String()
+		secret.Version = 1
+		secret.Revision = 1
+		now := time.Now()
+		secret.CreatedAt = now
+		secret.UpdatedAt = now
+		secret.RotatedAt = now
+
+		d.Secrets[secret.ID] = newMemorySecret(secret)
+		return nil
+	})
+}
+
+// Get retrieves a secret by ID
+func (r *MemorySecretRepository) Get(ctx context.Context, id string) (*models.Secret, error) {
+	var secret *models.Secret
+	err := r.db.read(func(d *memoryData) error {
+		stored, ok := d.Secrets[id]
+		if !ok || stored.DeletedAt != nil {
+			return fmt.Errorf("secret %w", ErrNotFound)
+		}
+
+		secret = stored.model()
+		return nil
+	})
+
+	return secret, err
+}
+
+// List retrieves all secrets matching the filter, excluding the trash
+func (r *MemorySecretRepository) List(ctx context.Context, filter models.SecretFilter) ([]*models.Secret, error) {
+	compare := compareSecrets(filter)
+	secrets, err := r.collect(func(s *memorySecret) bool {
+		return s.DeletedAt == nil && matchesFilter(s, filter) &&
+			(filter.After == nil || compare(s, cursorSecret(filter.After)) > 0)
+	}, compare)
+
+	if filter.Limit > 0 && len(secrets) > filter.Limit {
+		secrets = secrets[:filter.Limit]
+	}
+
+	return secrets, err
+}
+
+// Update updates an existing secret without recording a new version.
+// Use UpdateWithVersion when the secret's content changes.
+func (r *MemorySecretRepository) Update(ctx context.Context, secret *models.Secret) error {
+	return r.db.write(func(d *memoryData) error {
+		stored := d.Secrets[secret.ID]
+		if err := checkRevision(stored, secret.Revision); err != nil {
+			return err
+		}
+
+		secret.UpdatedAt = time.Now()
+		secret.Revision = stored.Revision + 1
+
+		updated := *stored
+		updated.Title = secret.Title
+		updated.Type = secret.Type
+		updated.EncryptedValue = secret.EncryptedValue
+		updated.Fields = slices.Clone(fieldsOrEmpty(secret.Fields))
+		updated.Folder = secret.Folder
+		updated.Tags = normalizeTags(secret.Tags)
+		updated.UpdatedAt = secret.UpdatedAt
+		updated.Revision = secret.Revision
+		d.Secrets[secret.ID] = &updated
+		return nil
+	})
+}
+
+// Delete moves a secret at the given revision to the trash
+func (r *MemorySecretRepository) Delete(ctx context.Context, id string, revision int) error {
+	return r.db.write(func(d *memoryData) error {
+		stored := d.Secrets[id]
+		if err := checkRevision(stored, revision); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		deleted := *stored
+		deleted.DeletedAt = &now
+		deleted.Revision++
+		d.Secrets[id] = &deleted
+		return nil
+	})
+}
+
+// RenameFolder moves every secret in a folder, including subfolders, under a new path.
+// It returns the number of secrets moved.
+func (r *MemorySecretRepository) RenameFolder(ctx context.Context, from, to string, revisions map[string]int) (int64, error) {
+	var count int64
+	err := r.db.write(func(d *memoryData) error {
+		current := make(map[string]int)
+		for id, stored := range d.Secrets {
+			if stored.DeletedAt == nil && inFolder(stored.Folder, from) {
+				current[id] = stored.Revision
+			}
+		}
+		if err := matchFolderRevisions(current, revisions); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for id := range current {
+			stored := d.Secrets[id]
+			moved := *stored
+			moved.Folder = to + strings.TrimPrefix(stored.Folder, from)
+			moved.UpdatedAt = now
+			moved.Revision++
+			d.Secrets[id] = &moved
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// checkRevision fails unless a stored secret is live and at the expected revision
+func checkRevision(stored *memorySecret, revision int) error {
+	if stored == nil || stored.DeletedAt != nil {
+		return fmt.Errorf("secret %w", ErrNotFound)
+	}
+	if stored.Revision != revision {
+		return fmt.Errorf("secret %w", ErrRevisionMismatch)
+	}
+	return nil
+}
+
+// collect returns copies of the secrets matching keep, sorted by compare
+func (r *MemorySecretRepository) collect(keep func(s *memorySecret) bool, compare func(a, b *memorySecret) int) ([]*models.Secret, error) {
+	var matched []*memorySecret
+	err := r.db.read(func(d *memoryData) error {
+		for _, stored := range d.Secrets {
+			if keep(stored) {
+				matched = append(matched, stored)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Map iteration order is random, so ties fall back to the ID
+	slices.SortFunc(matched, func(a, b *memorySecret) int {
+		return cmp.Or(compare(a, b), strings.Compare(a.ID, b.ID))
+	})
+
+	var secrets []*models.Secret
+	for _, stored := range matched {
+		secrets = append(secrets, stored.model())
+	}
+
+	return secrets, nil
+}
+
+// compareSecrets orders secrets as a listing with filter is sorted, ties broken by ID
+func compareSecrets(filter models.SecretFilter) func(a, b *memorySecret) int {
+	return func(a, b *memorySecret) int {
+		var c int
+		switch filter.Sort {
+		case models.SortByTitle:
+			c = strings.Compare(a.Title, b.Title)
+		case models.SortByUpdatedAt:
+			c = a.UpdatedAt.Compare(b.UpdatedAt)
+		default:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		c = cmp.Or(c, strings.Compare(a.ID, b.ID))
+
+		if !filter.Ascending {
+			return -c
+		}
+		return c
+	}
+}
+
+// cursorSecret returns a secret holding a cursor's sort keys, for comparing against
+func cursorSecret(cursor *models.SecretCursor) *memorySecret {
+	return &memorySecret{ID: cursor.ID, Title: cursor.Title, CreatedAt: cursor.At, UpdatedAt: cursor.At}
+}
+
+// matchesFilter reports whether a secret meets all set filter criteria
+func matchesFilter(s *memorySecret, filter models.SecretFilter) bool {
+	// Match plain custom fields; concealed fields carry no plain value
+	for name, value := range filter.Fields {
+		if !slices.ContainsFunc(s.Fields, func(f models.SecretField) bool {
+			return f.Name == name && !f.Concealed && f.Value == value
+		}) {
+			return false
+		}
+	}
+
+	if filter.Tag != "" && !slices.Contains(s.Tags, filter.Tag) {
+		return false
+	}
+
+	if filter.FolderPrefix != "" && !inFolder(s.Folder, filter.FolderPrefix) {
+		return false
+	}
+
+	if filter.ExpiringBefore != nil && (s.ExpiresAt == nil || s.ExpiresAt.After(*filter.ExpiringBefore)) {
+		return false
+	}
+
+	for _, word := range filter.SearchWords {
+		if !strings.Contains(strings.ToLower(s.Title), word) && !slices.ContainsFunc(s.Fields, func(f models.SecretField) bool {
+			return strings.Contains(strings.ToLower(f.Name), word) || strings.Contains(strings.ToLower(f.Value), word)
+		}) {
+			return false
+		}
+	}
+
+	if filter.Type != "" && s.Type != filter.Type {
+		return false
+	}
+
+	if !strings.HasPrefix(s.Title, filter.TitlePrefix) {
+		return false
+	}
+
+	if !inRange(s.CreatedAt, filter.CreatedAfter, filter.CreatedBefore) ||
+		!inRange(s.UpdatedAt, filter.UpdatedAfter, filter.UpdatedBefore) {
+		return false
+	}
+
+	return true
+}
+
+// inRange reports whether t lies strictly between the set bounds
+func inRange(t time.Time, after, before *time.Time) bool {
+	return (after == nil || t.After(*after)) && (before == nil || t.Before(*before))
+}
+
+// inFolder reports whether folder is prefix itself or lies below it
+func inFolder(folder, prefix string) bool {
+	return folder == prefix || strings.HasPrefix(folder, prefix+"/")
+}
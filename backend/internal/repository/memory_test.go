@@ -0,0 +1,36 @@
+package repository_test
+
+import (
+	"testing"
+
+	"my-vault/internal/repository"
+	"my-vault/internal/repository/storetest"
+)
+
+// newTestMemoryDB creates an empty in-memory vault
+func newTestMemoryDB(t *testing.T) *repository.MemoryDB {
+	t.Helper()
+
+	db := repository.NewMemoryDB()
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+func TestMemorySecretStore(t *testing.T) {
+	storetest.TestSecretStore(t, func(t *testing.T) repository.SecretStore {
+		return repository.NewMemorySecretRepository(newTestMemoryDB(t))
+	})
+}
+
+func TestMemoryPolicyStore(t *testing.T) {
+	storetest.TestPolicyStore(t, func(t *testing.T) repository.PolicyStore {
+		return repository.NewMemoryPolicyRepository(newTestMemoryDB(t))
+	})
+}
+
+func TestMemoryVaultStore(t *testing.T) {
+	storetest.TestVaultStore(t, func(t *testing.T) repository.VaultStore {
+		return repository.NewMemoryVaultRepository(newTestMemoryDB(t))
+	})
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+// ListTrash retrieves all soft-deleted secrets, most recently deleted first
+func (r *MemorySecretRepository) ListTrash(ctx context.Context) ([]*models.Secret, error) {
+	return r.collect(func(s *memorySecret) bool {
+		return s.DeletedAt != nil
+	}, func(a, b *memorySecret) int {
+		return b.DeletedAt.Compare(*a.DeletedAt)
+	})
+}
+
+// GetTrashed retrieves a soft-deleted secret by ID
+func (r *MemorySecretRepository) GetTrashed(ctx context.Context, id string) (*models.Secret, error) {
+	var secret *models.Secret
+	err := r.db.read(func(d *memoryData) error {
+		stored, ok := d.Secrets[id]
+		if !ok || stored.DeletedAt == nil {
+			return fmt.Errorf("trashed secret %w", ErrNotFound)
+		}
+
+		secret = stored.model()
+		return nil
+	})
+
+	return secret, err
+}
+
+// Restore moves a secret out of the trash
+func (r *MemorySecretRepository) Restore(ctx context.Context, id string) error {
+	return r.db.write(func(d *memoryData) error {
+		stored, ok := d.Secrets[id]
+		if !ok || stored.DeletedAt == nil {
+			return fmt.Errorf("trashed secret %w", ErrNotFound)
+		}
+
+		restored := *stored
+		restored.DeletedAt = nil
+		restored.Revision++
+		d.Secrets[id] = &restored
+		return nil
+	})
+}
+
+// Purge permanently removes a trashed secret together with its history
+func (r *MemorySecretRepository) Purge(ctx context.Context, id string) error {
+	return r.db.write(func(d *memoryData) error {
+		stored, ok := d.Secrets[id]
+		if !ok || stored.DeletedAt == nil {
+			return fmt.Errorf("trashed secret %w", ErrNotFound)
+		}
+
+		d.purge(id)
+		return nil
+	})
+}
+
+// PurgeDeletedBefore permanently removes secrets trashed before cutoff.
+// It returns the IDs of the secrets removed.
+func (r *MemorySecretRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	var ids []string
+	err := r.db.write(func(d *memoryData) error {
+		for id, stored := range d.Secrets {
+			if stored.DeletedAt != nil && stored.DeletedAt.Before(cutoff) {
+				ids = append(ids, id)
+			}
+		}
+
+		for _, id := range ids {
+			d.purge(id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// purge removes a secret with its versions and attachment metadata
+func (d *memoryData) purge(id string) {
+	delete(d.Secrets, id)
+	delete(d.Versions, id)
+	for attachmentID, attachment := range d.Attachments {
+		if attachment.SecretID == id {
+			delete(d.Attachments, attachmentID)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"my-vault/internal/models"
+)
+
+// MemoryVaultRepository handles the vault header of an in-memory vault.
+// It implements KeyedStore: the content of a vault file is only readable while unlocked.
+type MemoryVaultRepository struct {
+	db *MemoryDB
+}
+
+// NewMemoryVaultRepository creates a new in-memory vault repository
+func NewMemoryVaultRepository(db *MemoryDB) *MemoryVaultRepository {
+	return &MemoryVaultRepository{
+		db: db,
+	}
+}
+
+// GetHeader retrieves the vault header
+func (r *MemoryVaultRepository) GetHeader(ctx context.Context) (*models.VaultHeader, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	if r.db.header == nil {
+		return nil, fmt.Errorf("vault header %w", ErrNotFound)
+	}
+
+	header := *r.db.header
+	return &header, nil
+}
+
+// CreateHeader stores the vault header unless the vault is already initialized
+func (r *MemoryVaultRepository) CreateHeader(ctx context.Context, header *models.VaultHeader) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.header != nil {
+		return fmt.Errorf("vault header %w", ErrConflict)
+	}
+
+	stored := *header
+	if r.db.file != nil {
+		if err := r.db.file.save(&stored, r.db.data); err != nil {
+			return err
+		}
+	}
+
+	r.db.header = &stored
+	return nil
+}
+
+// Unlock loads the content of a vault file, decrypting it with the vault key.
+// A vault kept in memory only is always readable.
+func (r *MemoryVaultRepository) Unlock(ctx context.Context, key []byte) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.file == nil {
+		return nil
+	}
+
+	header, data, err := r.db.file.load(key)
+	if err != nil {
+		return err
+	}
+
+	r.db.header = header
+	r.db.data = data
+	return nil
+}
+
+// Lock drops the content of a vault file and the key from memory. A vault
+// kept in memory only has nowhere to reload from and keeps its content.
+func (r *MemoryVaultRepository) Lock() {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.file == nil {
+		return
+	}
+
+	r.db.data = nil
+	r.db.file.forget()
+}
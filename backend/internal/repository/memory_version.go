@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+// UpdateWithVersion updates a secret's content, archiving the replaced content
+// as a prior version and bumping the version number in one write
+func (r *MemorySecretRepository) UpdateWithVersion(ctx context.Context, secret *models.Secret) error {
+	return r.db.write(func(d *memoryData) error {
+		stored := d.Secrets[secret.ID]
+		if err := checkRevision(stored, secret.Revision); err != nil {
+			return err
+		}
+
+		archived := &models.SecretVersion{
+			SecretID:       stored.ID,
+			Version:        stored.Version,
+			Title:          stored.Title,
+			Type:           stored.Type,
+			EncryptedValue: stored.EncryptedValue,
+			Fields:         stored.Fields,
+			Author:         stored.UpdatedBy,
+			CreatedAt:      stored.UpdatedAt,
+		}
+		d.Versions[secret.ID] = append(slices.Clip(d.Versions[secret.ID]), archived)
+
+		secret.UpdatedAt = time.Now()
+		secret.Version = stored.Version + 1
+		secret.Revision = stored.Revision + 1
+
+		updated := newMemorySecret(secret)
+		updated.CreatedAt = stored.CreatedAt
+
+		// Reminders already sent are reset when the dates they were sent for change
+		if sameTime(stored.ExpiresAt, updated.ExpiresAt) {
+			updated.ExpiryNotifiedAt = stored.ExpiryNotifiedAt
+		}
+		if stored.RotateEvery == updated.RotateEvery && stored.RotatedAt.Equal(updated.RotatedAt) {
+			updated.RotationNotifiedAt = stored.RotationNotifiedAt
+		}
+
+		d.Secrets[secret.ID] = updated
+		return nil
+	})
+}
+
+// ListVersions retrieves the archived versions of a secret, newest first
+func (r *MemorySecretRepository) ListVersions(ctx context.Context, secretID string) ([]*models.SecretVersion, error) {
+	var versions []*models.SecretVersion
+	err := r.db.read(func(d *memoryData) error {
+		stored := d.Versions[secretID]
+		for i := len(stored) - 1; i >= 0; i-- {
+			versions = append(versions, cloneVersion(stored[i]))
+		}
+		return nil
+	})
+
+	return versions, err
+}
+
+// GetVersion retrieves one archived version of a secret
+func (r *MemorySecretRepository) GetVersion(ctx context.Context, secretID string, version int) (*models.SecretVersion, error) {
+	var found *models.SecretVersion
+	err := r.db.read(func(d *memoryData) error {
+		for _, stored := range d.Versions[secretID] {
+			if stored.Version == version {
+				found = cloneVersion(stored)
+				return nil
+			}
+		}
+		return fmt.Errorf("version %w", ErrNotFound)
+	})
+
+	return found, err
+}
+
+// PruneVersions deletes archived versions beyond the newest keep versions
+// or created before olderThan. Zero values disable the respective limit.
+func (r *MemorySecretRepository) PruneVersions(ctx context.Context, secretID string, keep int, olderThan time.Time) error {
+	return r.db.write(func(d *memoryData) error {
+		versions := d.Versions[secretID]
+		if keep > 0 && len(versions) > keep {
+			versions = versions[len(versions)-keep:]
+		}
+
+		if !olderThan.IsZero() {
+			versions = slices.DeleteFunc(slices.Clone(versions), func(v *models.SecretVersion) bool {
+				return v.CreatedAt.Before(olderThan)
+			})
+		}
+
+		if len(versions) == 0 {
+			delete(d.Versions, secretID)
+		} else {
+			d.Versions[secretID] = slices.Clip(versions)
+		}
+		return nil
+	})
+}
+
+// PruneVersionsBefore deletes the archived versions of every secret created before cutoff.
+// It returns the number of versions removed.
+func (r *MemorySecretRepository) PruneVersionsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var pruned int64
+	err := r.db.write(func(d *memoryData) error {
+		for secretID, versions := range d.Versions {
+			kept := slices.DeleteFunc(slices.Clone(versions), func(v *models.SecretVersion) bool {
+				return v.CreatedAt.Before(cutoff)
+			})
+			pruned += int64(len(versions) - len(kept))
+
+			if len(kept) == 0 {
+				delete(d.Versions, secretID)
+			} else {
+				d.Versions[secretID] = slices.Clip(kept)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return pruned, nil
+}
+
+// sameTime reports whether two optional timestamps are both unset or equal
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
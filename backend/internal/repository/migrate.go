@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationFiles holds the numbered PostgreSQL migrations, one NNNN_name.up.sql
+// and NNNN_name.down.sql pair per version
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationFilePattern matches a migration file name
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migrationLockID is the advisory lock key held while migrating, so
+// concurrently starting servers apply each migration exactly once
+const migrationLockID int64 = 0x7661756c74626f78
+
+// Migration is one numbered schema change with its rollback
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// String returns the migration's file name stem, e.g. 0003_add_secret_folders
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Migration
+	// AppliedAt is nil for pending migrations
+	AppliedAt *time.Time
+	// Unknown marks versions applied to the database but missing from this build
+	Unknown bool
+}
+
+// Migrator applies and rolls back the embedded migrations, recording the
+// applied versions in schema_migrations
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the embedded migrations
+func NewMigrator(db *PostgresDB) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{pool: db.GetPool(), migrations: migrations}, nil
+}
+
+// Status lists every known migration, and any unknown applied version, in version order
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *pgx.Conn, applied map[int]appliedMigration) error {
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if a, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &a.at
+				delete(applied, migration.Version)
+			}
+			statuses = append(statuses, status)
+		}
+
+		for version, a := range applied {
+			statuses = append(statuses, MigrationStatus{
+				Migration: Migration{Version: version, Name: a.name},
+				AppliedAt: &a.at,
+				Unknown:   true,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(statuses, func(a, b MigrationStatus) int {
+		return a.Version - b.Version
+	})
+
+	return statuses, nil
+}
+
+// Up applies all pending migrations in version order and returns them.
+// It refuses to run against a schema newer than this build.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *pgx.Conn, applied map[int]appliedMigration) error {
+		latest := m.migrations[len(m.migrations)-1].Version
+		for version := range applied {
+			if version > latest {
+				return fmt.Errorf("database schema version %d is newer than this build, which knows up to %d", version, latest)
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := applyMigration(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Down rolls back the most recently applied migration and returns it,
+// or nil when no migration is applied
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var rolledBack *Migration
+	err := m.withLock(ctx, func(conn *pgx.Conn, applied map[int]appliedMigration) error {
+		migration, err := m.latestApplied(applied)
+		if err != nil || migration == nil {
+			return err
+		}
+
+		if err := applyMigration(ctx, conn, *migration, false); err != nil {
+			return err
+		}
+		rolledBack = migration
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Redo rolls back the most recently applied migration and applies it again,
+// or returns nil when no migration is applied
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+	err := m.withLock(ctx, func(conn *pgx.Conn, applied map[int]appliedMigration) error {
+		migration, err := m.latestApplied(applied)
+		if err != nil || migration == nil {
+			return err
+		}
+
+		if err := applyMigration(ctx, conn, *migration, false); err != nil {
+			return err
+		}
+		if err := applyMigration(ctx, conn, *migration, true); err != nil {
+			return err
+		}
+		redone = migration
+		return nil
+	})
+
+	return redone, err
+}
+
+// latestApplied returns the applied migration with the highest version
+func (m *Migrator) latestApplied(applied map[int]appliedMigration) (*Migration, error) {
+	if len(applied) == 0 {
+		return nil, nil
+	}
+
+	latest := slices.Max(slices.Collect(maps.Keys(applied)))
+	for _, migration := range m.migrations {
+		if migration.Version == latest {
+			return &migration, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot roll back version %d: it is unknown to this build", latest)
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	name string
+	at   time.Time
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory
+// lock, passing the versions applied so far
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgx.Conn, applied map[int]appliedMigration) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	// DB_STATEMENT_TIMEOUT is meant for requests, not for waiting on the lock or
+	// for long migrations; RESET restores it before the connection returns to the pool
+	if _, err := conn.Exec(ctx, `SET statement_timeout = 0`); err != nil {
+		return fmt.Errorf("failed to disable statement timeout: %w", err)
+	}
+	defer conn.Exec(context.Background(), `RESET statement_timeout`)
+
+	// Session-level lock: waits for any other migrator to finish
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			// Closing the connection ends the session and with it the lock
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	createTable := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)
+	`
+	if _, err := conn.Exec(ctx, createTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, `SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	applied := map[int]appliedMigration{}
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.name, &a.at); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		applied[version] = a
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	return fn(conn.Conn(), applied)
+}
+
+// applyMigration runs one direction of a migration and records it in the same transaction
+func applyMigration(ctx context.Context, conn *pgx.Conn, migration Migration, up bool) error {
+	script, record := migration.down, `DELETE FROM schema_migrations WHERE version = $1`
+	if up {
+		script, record = migration.up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+	}
+
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, script); err != nil {
+			return err
+		}
+
+		args := []any{migration.Version}
+		if up {
+			args = append(args, migration.Name)
+		}
+		_, err := tx.Exec(ctx, record, args...)
+		return err
+	})
+
+	if err != nil && up {
+		return fmt.Errorf("failed to apply migration %s: %w", migration, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to roll back migration %s: %w", migration, err)
+	}
+
+	if up {
+		log.Printf("Applied migration %s", migration)
+	} else {
+		log.Printf("Rolled back migration %s", migration)
+	}
+	return nil
+}
+
+// loadMigrations reads the migrations in dir, checking that versions are
+// numbered from 1 without gaps and that each has both directions
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.up = string(content)
+		} else {
+			migration.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version := 1; version <= len(byVersion); version++ {
+		migration, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("migration %04d is missing", version)
+		}
+		if migration.up == "" || migration.down == "" {
+			return nil, fmt.Errorf("migration %s needs both an up and a down script", migration)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migrations found")
+	}
+
+	return migrations, nil
+}
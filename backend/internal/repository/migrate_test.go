@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("migration %d has version %d", i, migration.Version)
+		}
+	}
+}
+
+func TestLoadMigrationsRejectsBrokenSets(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"missing down": {
+			"m/0001_a.up.sql": {Data: []byte("SELECT 1")},
+		},
+		"gap": {
+			"m/0001_a.up.sql":   {Data: []byte("SELECT 1")},
+			"m/0001_a.down.sql": {Data: []byte("SELECT 1")},
+			"m/0003_c.up.sql":   {Data: []byte("SELECT 1")},
+			"m/0003_c.down.sql": {Data: []byte("SELECT 1")},
+		},
+		"two names": {
+			"m/0001_a.up.sql":   {Data: []byte("SELECT 1")},
+			"m/0001_b.down.sql": {Data: []byte("SELECT 1")},
+		},
+		"stray file": {
+			"m/0001_a.up.sql":   {Data: []byte("SELECT 1")},
+			"m/0001_a.down.sql": {Data: []byte("SELECT 1")},
+			"m/notes.txt":       {Data: []byte("todo")},
+		},
+		"empty": {
+			"m": {Mode: fs.ModeDir | 0o755},
+		},
+	}
+
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadMigrations(fsys, "m"); err == nil {
+				t.Error("loadMigrations succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+-- Create secrets table
+CREATE TABLE IF NOT EXISTS secrets (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	title VARCHAR(255) NOT NULL,
+	type VARCHAR(100) NOT NULL,
+	encrypted_value BYTEA NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create index on title for faster searches
+CREATE INDEX IF NOT EXISTS idx_secrets_title ON secrets(title);
+
+-- Create index on type for filtering
+CREATE INDEX IF NOT EXISTS idx_secrets_type ON secrets(type);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_secrets_fields;
+ALTER TABLE secrets DROP COLUMN IF EXISTS fields;
@@ -0,0 +1,3 @@
+-- Add ordered custom fields; plain values are searchable through the GIN index
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS fields JSONB NOT NULL DEFAULT '[]';
+CREATE INDEX IF NOT EXISTS idx_secrets_fields ON secrets USING GIN (fields jsonb_path_ops);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_secrets_folder;
+ALTER TABLE secrets DROP COLUMN IF EXISTS folder;
@@ -0,0 +1,3 @@
+-- Add hierarchical folder path, e.g. prod/payments/stripe
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS folder VARCHAR(1024) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_secrets_folder ON secrets(folder text_pattern_ops);
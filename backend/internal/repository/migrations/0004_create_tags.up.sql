@@ -0,0 +1,14 @@
+-- Create tags and their many-to-many links to secrets
+CREATE TABLE IF NOT EXISTS tags (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	name VARCHAR(100) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS secret_tags (
+	secret_id UUID NOT NULL REFERENCES secrets(id) ON DELETE CASCADE,
+	tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+	PRIMARY KEY (secret_id, tag_id)
+);
+
+-- Create index on tag for filtering by tag
+CREATE INDEX IF NOT EXISTS idx_secret_tags_tag ON secret_tags(tag_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS secret_versions;
+ALTER TABLE secrets DROP COLUMN IF EXISTS updated_by;
+ALTER TABLE secrets DROP COLUMN IF EXISTS version;
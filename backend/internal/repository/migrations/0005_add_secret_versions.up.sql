@@ -0,0 +1,16 @@
+-- Track the content version and its author
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS updated_by VARCHAR(255) NOT NULL DEFAULT 'owner';
+
+-- Create history of prior secret versions, still encrypted
+CREATE TABLE IF NOT EXISTS secret_versions (
+	secret_id UUID NOT NULL REFERENCES secrets(id) ON DELETE CASCADE,
+	version INTEGER NOT NULL,
+	title VARCHAR(255) NOT NULL,
+	type VARCHAR(100) NOT NULL,
+	encrypted_value BYTEA NOT NULL,
+	fields JSONB NOT NULL DEFAULT '[]',
+	author VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	PRIMARY KEY (secret_id, version)
+);
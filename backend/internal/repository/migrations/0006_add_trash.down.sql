@@ -0,0 +1,4 @@
+-- Trashed secrets would reappear as live ones, so they are removed
+DELETE FROM secrets WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_secrets_deleted_at;
+ALTER TABLE secrets DROP COLUMN IF EXISTS deleted_at;
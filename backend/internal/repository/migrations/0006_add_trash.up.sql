@@ -0,0 +1,3 @@
+-- Soft-deleted secrets stay in the trash until purged
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_secrets_deleted_at ON secrets(deleted_at) WHERE deleted_at IS NOT NULL;
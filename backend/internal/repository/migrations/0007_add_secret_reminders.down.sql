@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_secrets_expires_at;
+ALTER TABLE secrets DROP COLUMN IF EXISTS rotation_notified_at;
+ALTER TABLE secrets DROP COLUMN IF EXISTS expiry_notified_at;
+ALTER TABLE secrets DROP COLUMN IF EXISTS rotated_at;
+ALTER TABLE secrets DROP COLUMN IF EXISTS rotate_every;
+ALTER TABLE secrets DROP COLUMN IF EXISTS expires_at;
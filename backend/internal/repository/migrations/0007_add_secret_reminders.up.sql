@@ -0,0 +1,7 @@
+-- Track expiry and rotation schedules, and which reminders were sent
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS rotate_every BIGINT;
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS expiry_notified_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS rotation_notified_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_secrets_expires_at ON secrets(expires_at) WHERE expires_at IS NOT NULL;
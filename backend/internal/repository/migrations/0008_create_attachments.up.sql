@@ -0,0 +1,12 @@
+-- Create metadata of encrypted file attachments; contents live in the blob store
+CREATE TABLE IF NOT EXISTS attachments (
+	id UUID PRIMARY KEY,
+	secret_id UUID NOT NULL REFERENCES secrets(id) ON DELETE CASCADE,
+	encrypted_name BYTEA NOT NULL,
+	size BIGINT NOT NULL,
+	created_by VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create index on secret for listing attachments
+CREATE INDEX IF NOT EXISTS idx_attachments_secret ON attachments(secret_id);
@@ -0,0 +1,7 @@
+-- Create the single-row vault header holding the key derivation salt
+CREATE TABLE IF NOT EXISTS vault_header (
+	id SMALLINT PRIMARY KEY CHECK (id = 1),
+	salt BYTEA NOT NULL,
+	verifier BYTEA NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS policy_subjects;
+DROP TABLE IF EXISTS policies;
@@ -0,0 +1,20 @@
+-- Create policies table
+CREATE TABLE IF NOT EXISTS policies (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	name VARCHAR(255) NOT NULL UNIQUE,
+	description TEXT NOT NULL DEFAULT '',
+	document JSONB NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create policy attachments to users, groups and tokens
+CREATE TABLE IF NOT EXISTS policy_subjects (
+	policy_id UUID NOT NULL REFERENCES policies(id) ON DELETE CASCADE,
+	subject_type VARCHAR(20) NOT NULL,
+	subject_id VARCHAR(255) NOT NULL,
+	PRIMARY KEY (policy_id, subject_type, subject_id)
+);
+
+-- Create index on subject for policy lookups
+CREATE INDEX IF NOT EXISTS idx_policy_subjects_subject ON policy_subjects(subject_type, subject_id);
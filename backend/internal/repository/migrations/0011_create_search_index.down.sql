@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS search_index;
+DROP TABLE IF EXISTS secret_search_tokens;
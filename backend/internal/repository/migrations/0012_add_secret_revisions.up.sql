@@ -0,0 +1,2 @@
+-- Count every write to a secret so updates can be made conditional on the revision read
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS revision INTEGER NOT NULL DEFAULT 1;
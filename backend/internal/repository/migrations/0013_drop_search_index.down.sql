@@ -0,0 +1,15 @@
+-- Recreate the blind search index of earlier releases, which rebuild it on first use
+CREATE TABLE IF NOT EXISTS secret_search_tokens (
+	secret_id UUID NOT NULL REFERENCES secrets(id) ON DELETE CASCADE,
+	token BYTEA NOT NULL,
+	PRIMARY KEY (secret_id, token)
+);
+
+CREATE INDEX IF NOT EXISTS idx_secret_search_tokens_token ON secret_search_tokens(token);
+
+-- Create the single-row search index header holding the salt of the index key
+CREATE TABLE IF NOT EXISTS search_index (
+	id SMALLINT PRIMARY KEY CHECK (id = 1),
+	salt BYTEA NOT NULL,
+	built_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
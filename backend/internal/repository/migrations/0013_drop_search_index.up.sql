@@ -0,0 +1,4 @@
+-- Search matches titles and plain custom fields directly; they are stored in
+-- cleartext, so the blind index hid nothing
+DROP TABLE IF EXISTS search_index;
+DROP TABLE IF EXISTS secret_search_tokens;
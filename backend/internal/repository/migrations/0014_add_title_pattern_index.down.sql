@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_secrets_title_pattern;
@@ -0,0 +1,3 @@
+-- Index titles by byte order so title prefix filters (LIKE 'prefix%') can use
+-- an index under any collation; idx_secrets_title keeps serving title sorting
+CREATE INDEX IF NOT EXISTS idx_secrets_title_pattern ON secrets(title text_pattern_ops);
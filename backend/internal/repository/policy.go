@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"my-vault/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrConflict is returned when a record violates a uniqueness constraint
+var ErrConflict = errors.New("already exists")
+
+// policyColumns selects a policy together with its aggregated subjects
+const policyColumns = `
+	p.id, p.name, p.description, p.document, p.created_at, p.updated_at,
+	COALESCE((
+		SELECT json_agg(json_build_object('type', s.subject_type, 'id', s.subject_id)
+			ORDER BY s.subject_type, s.subject_id)
+		FROM policy_subjects s
+		WHERE s.policy_id = p.id
+	), '[]'::json)
+`
+
+// PolicyRepository handles database operations for policies
+type PolicyRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPolicyRepository creates a new policy repository
+func NewPolicyRepository(db *PostgresDB) *PolicyRepository {
+	return &PolicyRepository{
+		pool: db.GetPool(),
+	}
+}
+
+// Create creates a new policy and its subject attachments
+func (r *PolicyRepository) Create(ctx context.Context, policy *models.Policy) error {
+	policy.ID = uuid.New().String()
+	now := time.Now()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		query := `
+			INSERT INTO policies (id, name, description, document, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`
+
+		_, err := tx.Exec(ctx, query,
+			policy.ID,
+			policy.Name,
+			policy.Description,
+			policy.Document,
+			policy.CreatedAt,
+			policy.UpdatedAt,
+		)
+		if err != nil {
+			return policyWriteError("create", err)
+		}
+
+		return replaceSubjects(ctx, tx, policy)
+	})
+}
+
+// Get retrieves a policy by ID
+func (r *PolicyRepository) Get(ctx context.Context, id string) (*models.Policy, error) {
+	query := `SELECT ` + policyColumns + ` FROM policies p WHERE p.id = $1`
+
+	policy, err := scanPolicy(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("policy %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// List retrieves all policies
+func (r *PolicyRepository) List(ctx context.Context) ([]*models.Policy, error) {
+	query := `SELECT ` + policyColumns + ` FROM policies p ORDER BY p.name`
+
+	return r.query(ctx, query)
+}
+
+// ListForSubjects retrieves the policies attached to any of the given subjects
+func (r *PolicyRepository) ListForSubjects(ctx context.Context, subjects []models.PolicySubject) ([]*models.Policy, error) {
+	if len(subjects) == 0 {
+		return nil, nil
+	}
+
+	types := make([]string, len(subjects))
+	ids := make([]string, len(subjects))
+	for i, subject := range subjects {
+		types[i] = subject.Type
+		ids[i] = subject.ID
+	}
+
+	query := `
+		SELECT ` + policyColumns + `
+		FROM policies p
+		WHERE EXISTS (
+			SELECT 1
+			FROM policy_subjects s
+			JOIN unnest($1::text[], $2::text[]) AS q(subject_type, subject_id)
+				ON q.subject_type = s.subject_type AND q.subject_id = s.subject_id
+			WHERE s.policy_id = p.id
+		)
+		ORDER BY p.name
+	`
+
+	return r.query(ctx, query, types, ids)
+}
+
+// Update updates an existing policy and replaces its subject attachments
+func (r *PolicyRepository) Update(ctx context.Context, policy *models.Policy) error {
+	policy.UpdatedAt = time.Now()
+
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		query := `
+			UPDATE policies
+			SET name = $1, description = $2, document = $3, updated_at = $4
+			WHERE id = $5
+		`
+
+		result, err := tx.Exec(ctx, query,
+			policy.Name,
+			policy.Description,
+			policy.Document,
+			policy.UpdatedAt,
+			policy.ID,
+		)
+		if err != nil {
+			return policyWriteError("update", err)
+		}
+
+		if result.RowsAffected() == 0 {
+			return fmt.Errorf("policy %w", ErrNotFound)
+		}
+
+		return replaceSubjects(ctx, tx, policy)
+	})
+}
+
+// Delete removes a policy by ID
+func (r *PolicyRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM policies WHERE id = $1`
+
+	result, err := r.pool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete policy: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("policy %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// query runs a policy select and scans every row
+func (r *PolicyRepository) query(ctx context.Context, query string, args ...any) ([]*models.Policy, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []*models.Policy
+	for rows.Next() {
+		policy, err := scanPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan policy: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating policies: %w", err)
+	}
+
+	return policies, nil
+}
+
+// scanPolicy scans a row selected with policyColumns
+func scanPolicy(row pgx.Row) (*models.Policy, error) {
+	var policy models.Policy
+	err := row.Scan(
+		&policy.ID,
+		&policy.Name,
+		&policy.Description,
+		&policy.Document,
+		&policy.CreatedAt,
+		&policy.UpdatedAt,
+		&policy.Subjects,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// replaceSubjects rewrites the subject attachments of a policy inside a transaction
+func replaceSubjects(ctx context.Context, tx pgx.Tx, policy *models.Policy) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM policy_subjects WHERE policy_id = $1`, policy.ID); err != nil {
+		return fmt.Errorf("failed to clear policy subjects: %w", err)
+	}
+
+	query := `
+		INSERT INTO policy_subjects (policy_id, subject_type, subject_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`
+
+	for _, subject := range policy.Subjects {
+		if _, err := tx.Exec(ctx, query, policy.ID, subject.Type, subject.ID); err != nil {
+			return fmt.Errorf("failed to attach policy subject: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// policyWriteError maps unique violations on the policy name to ErrConflict
+func policyWriteError(op string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return fmt.Errorf("policy name %w", ErrConflict)
+	}
+	return fmt.Errorf("failed to %s policy: %w", op, err)
+}
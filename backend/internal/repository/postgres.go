@@ -2,11 +2,17 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
 	"time"
 
+	"my-vault/internal/utils"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -15,38 +21,161 @@ type PostgresDB struct {
 	pool *pgxpool.Pool
 }
 
-// NewPostgresDB creates a new PostgreSQL database connection
+// NewPostgresDB connects to PostgreSQL and applies pending schema migrations
 func NewPostgresDB() (*PostgresDB, error) {
-	// Get database configuration from environment
-	host := getEnv("DB_HOST", "localhost")
-	port := getEnv("DB_PORT", "5432")
-	user := getEnv("DB_USER", "vaultbox")
-	password := getEnv("DB_PASSWORD", "supersecret")
-	dbname := getEnv("DB_NAME", "vaultbox")
-
-	// Build connection string
-	connString := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=disable",
-		user, password, host, port, dbname)
-
-	// Create connection pool
-	pool, err := pgxpool.New(context.Background(), connString)
+	db, err := ConnectPostgres()
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := NewMigrator(db)
+	if err == nil {
+		_, err = migrator.Up(context.Background())
+	}
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	log.Println("Database schema is up to date")
+	return db, nil
+}
+
+// ConnectPostgres creates a new PostgreSQL database connection without
+// touching the schema, for the migrate command. While the server is not
+// reachable yet, e.g. still booting next to us in docker-compose, it retries
+// with exponential backoff for up to DB_CONNECT_TIMEOUT.
+func ConnectPostgres() (*PostgresDB, error) {
+	config, err := postgresConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	connectTimeout, err := envDuration("DB_CONNECT_TIMEOUT", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create connection pool; connections are opened lazily
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
 
-	// Test connection
-	if err := pool.Ping(context.Background()); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	// Test connection, retrying until the deadline
+	deadline := time.Now().Add(connectTimeout)
+	backoff := 500 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = pool.Ping(ctx)
+		cancel()
+		if err == nil {
+			break
+		}
+
+		if time.Now().Add(backoff).After(deadline) {
+			pool.Close()
+			return nil, fmt.Errorf("failed to ping database after %d attempts: %w", attempt, err)
+		}
+
+		log.Printf("Database not ready (attempt %d): %v; retrying in %s", attempt, err, backoff)
+		time.Sleep(backoff)
+		backoff = min(2*backoff, maxConnectBackoff)
 	}
 
 	log.Println("Successfully connected to PostgreSQL database")
 
-	// Initialize database schema
-	if err := initSchema(pool); err != nil {
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	return &PostgresDB{pool: pool}, nil
+}
+
+// maxConnectBackoff caps the wait between connection attempts
+const maxConnectBackoff = 10 * time.Second
+
+// postgresConfig builds the pool configuration from the environment.
+// DATABASE_URL, when set, replaces the DB_* connection settings; pgx reads
+// sslmode, sslrootcert, sslcert, sslkey and pool_* parameters from it.
+// The DB_POOL_* and DB_STATEMENT_TIMEOUT variables apply on top of either.
+func postgresConfig() (*pgxpool.Config, error) {
+	connString := os.Getenv("DATABASE_URL")
+	if connString == "" {
+		query := url.Values{}
+		query.Set("sslmode", getEnv("DB_SSLMODE", "disable"))
+		for param, key := range map[string]string{
+			"sslrootcert": "DB_SSLROOTCERT",
+			"sslcert":     "DB_SSLCERT",
+			"sslkey":      "DB_SSLKEY",
+		} {
+			if value := os.Getenv(key); value != "" {
+				query.Set(param, value)
+			}
+		}
+
+		dsn := url.URL{
+			Scheme:   "postgresql",
+			User:     url.UserPassword(getEnv("DB_USER", "vaultbox"), getEnv("DB_PASSWORD", "supersecret")),
+			Host:     net.JoinHostPort(getEnv("DB_HOST", "localhost"), getEnv("DB_PORT", "5432")),
+			Path:     "/" + getEnv("DB_NAME", "vaultbox"),
+			RawQuery: query.Encode(),
+		}
+		connString = dsn.String()
 	}
 
-	return &PostgresDB{pool: pool}, nil
+	config, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		// The parse error may echo the connection string, password included
+		return nil, errors.New("invalid database connection settings")
+	}
+
+	if value := os.Getenv("DB_POOL_MAX_CONNS"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid DB_POOL_MAX_CONNS %q", value)
+		}
+		config.MaxConns = int32(n)
+	}
+
+	if value := os.Getenv("DB_POOL_MIN_CONNS"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || n < 0 || int32(n) > config.MaxConns {
+			return nil, fmt.Errorf("invalid DB_POOL_MIN_CONNS %q", value)
+		}
+		config.MinConns = int32(n)
+	}
+
+	if lifetime, err := envDuration("DB_CONN_MAX_LIFETIME", config.MaxConnLifetime); err != nil {
+		return nil, err
+	} else {
+		config.MaxConnLifetime = lifetime
+	}
+
+	if idle, err := envDuration("DB_CONN_MAX_IDLE_TIME", config.MaxConnIdleTime); err != nil {
+		return nil, err
+	} else {
+		config.MaxConnIdleTime = idle
+	}
+
+	// Sent as a startup parameter, so every pooled connection gets it
+	if timeout, err := envDuration("DB_STATEMENT_TIMEOUT", 0); err != nil {
+		return nil, err
+	} else if timeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(timeout.Milliseconds(), 10)
+	}
+
+	return config, nil
+}
+
+// envDuration reads a duration such as 30s or 1h from the environment
+func envDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	d, err := utils.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return d, nil
 }
 
 // Close closes the database connection pool
@@ -61,42 +190,10 @@ func (db *PostgresDB) GetPool() *pgxpool.Pool {
 	return db.pool
 }
 
-// initSchema creates the necessary database tables
-func initSchema(pool *pgxpool.Pool) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Create secrets table
-	createTableSQL := `
-		CREATE TABLE IF NOT EXISTS secrets (
-			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-			title VARCHAR(255) NOT NULL,
-			type VARCHAR(100) NOT NULL,
-			encrypted_value BYTEA NOT NULL,
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
-		);
-		
-		-- Create index on title for faster searches
-		CREATE INDEX IF NOT EXISTS idx_secrets_title ON secrets(title);
-		
-		-- Create index on type for filtering
-		CREATE INDEX IF NOT EXISTS idx_secrets_type ON secrets(type);
-	`
-
-	_, err := pool.Exec(ctx, createTableSQL)
-	if err != nil {
-		return fmt.Errorf("failed to create secrets table: %w", err)
-	}
-
-	log.Println("Database schema initialized successfully")
-	return nil
-}
-
 // getEnv gets an environment variable with a fallback default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
-} 
\ No newline at end of file
+}
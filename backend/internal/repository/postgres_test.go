@@ -0,0 +1,97 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"my-vault/internal/repository"
+	"my-vault/internal/repository/storetest"
+)
+
+// newTestPostgres connects to the scratch database named by TEST_DB_NAME and
+// empties it. The other DB_* variables select the server as usual. Tests are
+// skipped when TEST_DB_NAME is unset, since the database is wiped.
+func newTestPostgres(t *testing.T) *repository.PostgresDB {
+	t.Helper()
+
+	name := os.Getenv("TEST_DB_NAME")
+	if name == "" {
+		t.Skip("TEST_DB_NAME not set; skipping PostgreSQL tests")
+	}
+	t.Setenv("DB_NAME", name)
+
+	db, err := repository.NewPostgresDB()
+	if err != nil {
+		t.Fatalf("NewPostgresDB: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	truncate := `TRUNCATE secrets, secret_versions, tags, secret_tags, attachments, policies, policy_subjects, vault_header CASCADE`
+	if _, err := db.GetPool().Exec(context.Background(), truncate); err != nil {
+		t.Fatalf("failed to empty test database: %v", err)
+	}
+
+	return db
+}
+
+func TestPostgresSecretStore(t *testing.T) {
+	storetest.TestSecretStore(t, func(t *testing.T) repository.SecretStore {
+		return repository.NewSecretRepository(newTestPostgres(t))
+	})
+}
+
+func TestPostgresPolicyStore(t *testing.T) {
+	storetest.TestPolicyStore(t, func(t *testing.T) repository.PolicyStore {
+		return repository.NewPolicyRepository(newTestPostgres(t))
+	})
+}
+
+func TestPostgresVaultStore(t *testing.T) {
+	storetest.TestVaultStore(t, func(t *testing.T) repository.VaultStore {
+		return repository.NewVaultRepository(newTestPostgres(t))
+	})
+}
+
+func TestPostgresMigrations(t *testing.T) {
+	ctx := context.Background()
+
+	migrator, err := repository.NewMigrator(newTestPostgres(t))
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	latest := statuses[len(statuses)-1]
+	for _, status := range statuses {
+		if status.AppliedAt == nil || status.Unknown {
+			t.Errorf("migration %s not applied by NewPostgresDB", status.Migration)
+		}
+	}
+
+	if redone, err := migrator.Redo(ctx); err != nil || redone == nil || redone.Version != latest.Version {
+		t.Fatalf("Redo = %v, %v, want %s", redone, err, latest.Migration)
+	}
+
+	if down, err := migrator.Down(ctx); err != nil || down == nil || down.Version != latest.Version {
+		t.Fatalf("Down = %v, %v, want %s", down, err, latest.Migration)
+	}
+	statuses, err = migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if statuses[len(statuses)-1].AppliedAt != nil {
+		t.Errorf("migration %s still applied after Down", latest.Migration)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != 1 || applied[0].Version != latest.Version {
+		t.Errorf("Up applied %v, want only %s", applied, latest.Migration)
+	}
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// pwnedHashLength is the length of a hex-encoded SHA-1 hash
+	pwnedHashLength = 40
+
+	// pwnedRangePrefix is the hash prefix length naming range files
+	pwnedRangePrefix = 5
+
+	// pwnedScanSize is the span below which the binary search reads sequentially;
+	// it must exceed the longest line
+	pwnedScanSize = 4096
+)
+
+// PwnedPasswords looks up SHA-1 hashes in a local copy of the Have I Been Pwned
+// Pwned Passwords dataset, either the single file ordered by hash ("HASH:COUNT"
+// lines) or a directory of range files named by 5-character hash prefix
+// ("SUFFIX:COUNT" lines). Lookups never touch the network.
+type PwnedPasswords struct {
+	path   string
+	ranges bool
+	file   *os.File
+	size   int64
+}
+
+// OpenPwnedPasswords opens a dataset file or range directory
+func OpenPwnedPasswords(path string) (*PwnedPasswords, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pwned passwords: %w", err)
+	}
+
+	if info.IsDir() {
+		return &PwnedPasswords{path: path, ranges: true}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pwned passwords: %w", err)
+	}
+
+	// Check the file looks like the hash-ordered SHA-1 dataset
+	first, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		file.Close()
+		return nil, fmt.Errorf("failed to read pwned passwords: %w", err)
+	}
+	if _, _, ok := parsePwnedLine(first, pwnedHashLength); !ok {
+		file.Close()
+		return nil, fmt.Errorf("pwned passwords file must hold SHA-1 HASH:COUNT lines ordered by hash")
+	}
+
+	return &PwnedPasswords{path: path, file: file, size: info.Size()}, nil
+}
+
+// Close releases the dataset file
+func (p *PwnedPasswords) Close() error {
+	if p.file == nil {
+		return nil
+	}
+	return p.file.Close()
+}
+
+// Lookup returns how often a SHA-1 hash (hex, any case) appears in the dataset; 0 means never
+func (p *PwnedPasswords) Lookup(hash string) (int, error) {
+	hash = strings.ToUpper(hash)
+	if len(hash) != pwnedHashLength {
+		return 0, fmt.Errorf("invalid SHA-1 hash")
+	}
+
+	if p.ranges {
+		return p.lookupRange(hash)
+	}
+	return p.lookupSorted(hash)
+}
+
+// lookupRange scans the range file holding the hash's prefix
+func (p *PwnedPasswords) lookupRange(hash string) (int, error) {
+	prefix, suffix := hash[:pwnedRangePrefix], hash[pwnedRangePrefix:]
+
+	data, err := os.ReadFile(filepath.Join(p.path, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		// Some mirrors store range files without an extension
+		data, err = os.ReadFile(filepath.Join(p.path, prefix))
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, fmt.Errorf("range file %s is missing from the pwned passwords directory", prefix)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read range file: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if lineHash, count, ok := parsePwnedLine(line, len(suffix)); ok && strings.EqualFold(lineHash, suffix) {
+			return count, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// lookupSorted binary searches the hash-ordered file by byte offset, then scans
+// the remaining small span line by line
+func (p *PwnedPasswords) lookupSorted(hash string) (int, error) {
+	lo, hi := int64(0), p.size
+
+	for hi-lo > pwnedScanSize {
+		mid := lo + (hi-lo)/2
+
+		line, err := p.lineAt(mid)
+		if err != nil {
+			return 0, err
+		}
+		lineHash, count, ok := parsePwnedLine(line, pwnedHashLength)
+
+		// The first line starting at or after mid decides which half holds the hash
+		switch {
+		case !ok || strings.ToUpper(lineHash) > hash:
+			hi = mid
+		case strings.ToUpper(lineHash) == hash:
+			return count, nil
+		default:
+			lo = mid
+		}
+	}
+
+	return p.scan(lo, hi, hash)
+}
+
+// lineAt returns the first complete line starting at or after offset
+func (p *PwnedPasswords) lineAt(offset int64) (string, error) {
+	start := offset
+	if start > 0 {
+		start--
+	}
+
+	buf := make([]byte, 2*pwnedScanSize)
+	n, err := p.file.ReadAt(buf, start)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read pwned passwords: %w", err)
+	}
+	buf = buf[:n]
+
+	// Unless the read began at the file start, skip to just past the first newline
+	if offset > 0 {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			return "", nil
+		}
+		buf = buf[i+1:]
+	}
+
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		buf = buf[:i]
+	}
+	return string(buf), nil
+}
+
+// scan compares every line starting within [lo, hi) against hash
+func (p *PwnedPasswords) scan(lo, hi int64, hash string) (int, error) {
+	start := lo
+	if start > 0 {
+		start--
+	}
+
+	buf := make([]byte, hi-start+pwnedScanSize)
+	n, err := p.file.ReadAt(buf, start)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, fmt.Errorf("failed to read pwned passwords: %w", err)
+	}
+
+	lines := strings.Split(string(buf[:n]), "\n")
+	if lo > 0 {
+		// The first element is the tail of a line starting before lo
+		lines = lines[1:]
+	}
+
+	for _, line := range lines {
+		lineHash, count, ok := parsePwnedLine(line, pwnedHashLength)
+		if !ok {
+			continue
+		}
+		switch upper := strings.ToUpper(lineHash); {
+		case upper == hash:
+			return count, nil
+		case upper > hash:
+			return 0, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// parsePwnedLine splits a "HASH:COUNT" line, checking the hash has hashLength hex digits
+func parsePwnedLine(line string, hashLength int) (string, int, bool) {
+	hash, countText, ok := strings.Cut(strings.TrimSpace(line), ":")
+	if !ok || len(hash) != hashLength {
+		return "", 0, false
+	}
+	for _, c := range hash {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return "", 0, false
+		}
+	}
+
+	count, err := strconv.Atoi(countText)
+	if err != nil {
+		return "", 0, false
+	}
+
+	return hash, count, true
+}
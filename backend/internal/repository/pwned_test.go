@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// pwnedEntry is one hash of a generated dataset
+type pwnedEntry struct {
+	hash  string
+	count int
+}
+
+// newPwnedEntries returns n distinct hashes ordered by hash, with distinct counts
+func newPwnedEntries(n int) []pwnedEntry {
+	entries := make([]pwnedEntry, n)
+	for i := range entries {
+		sum := sha1.Sum([]byte(fmt.Sprintf("password-%d", i)))
+		entries[i] = pwnedEntry{hash: strings.ToUpper(hex.EncodeToString(sum[:])), count: i + 1}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].hash < entries[j].hash })
+	return entries
+}
+
+// writePwnedFile writes entries as a hash-ordered dataset and opens it
+func writePwnedFile(t *testing.T, entries []pwnedEntry, newline string, trailing bool) *PwnedPasswords {
+	t.Helper()
+
+	lines := make([]string, len(entries))
+	for i, entry := range entries {
+		lines[i] = fmt.Sprintf("%s:%d", entry.hash, entry.count)
+	}
+	content := strings.Join(lines, newline)
+	if trailing {
+		content += newline
+	}
+
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	pwned, err := OpenPwnedPasswords(path)
+	if err != nil {
+		t.Fatalf("OpenPwnedPasswords: %v", err)
+	}
+	t.Cleanup(func() { pwned.Close() })
+
+	return pwned
+}
+
+func lookup(t *testing.T, pwned *PwnedPasswords, hash string) int {
+	t.Helper()
+
+	count, err := pwned.Lookup(hash)
+	if err != nil {
+		t.Fatalf("Lookup(%s): %v", hash, err)
+	}
+	return count
+}
+
+func TestPwnedPasswordsSortedFile(t *testing.T) {
+	// Large enough for several binary search steps before the final scan
+	entries := newPwnedEntries(2000)
+	if size := len(entries) * (pwnedHashLength + 6); size < 16*pwnedScanSize {
+		t.Fatalf("dataset of %d bytes is too small to exercise the binary search", size)
+	}
+
+	tests := []struct {
+		name     string
+		newline  string
+		trailing bool
+	}{
+		{name: "LF", newline: "\n", trailing: true},
+		{name: "CRLF", newline: "\r\n", trailing: true},
+		{name: "no trailing newline", newline: "\n", trailing: false},
+		{name: "CRLF without trailing newline", newline: "\r\n", trailing: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pwned := writePwnedFile(t, entries, tt.newline, tt.trailing)
+
+			first, last := entries[0], entries[len(entries)-1]
+			if got := lookup(t, pwned, first.hash); got != first.count {
+				t.Errorf("first line: got %d, want %d", got, first.count)
+			}
+			if got := lookup(t, pwned, last.hash); got != last.count {
+				t.Errorf("last line: got %d, want %d", got, last.count)
+			}
+			if got := lookup(t, pwned, strings.ToLower(last.hash)); got != last.count {
+				t.Errorf("lowercase hash: got %d, want %d", got, last.count)
+			}
+
+			for _, entry := range entries {
+				if got := lookup(t, pwned, entry.hash); got != entry.count {
+					t.Errorf("Lookup(%s) = %d, want %d", entry.hash, got, entry.count)
+				}
+			}
+
+			// Before the first line, after the last one and between lines
+			missing := append([]string{strings.Repeat("0", pwnedHashLength), strings.Repeat("F", pwnedHashLength)}, missingHashes(entries, 50)...)
+			for _, hash := range missing {
+				if got := lookup(t, pwned, hash); got != 0 {
+					t.Errorf("missing hash %s: got %d, want 0", hash, got)
+				}
+			}
+		})
+	}
+}
+
+func TestPwnedPasswordsSmallFile(t *testing.T) {
+	entries := newPwnedEntries(3)
+	pwned := writePwnedFile(t, entries, "\n", false)
+
+	for _, entry := range entries {
+		if got := lookup(t, pwned, entry.hash); got != entry.count {
+			t.Errorf("Lookup(%s) = %d, want %d", entry.hash, got, entry.count)
+		}
+	}
+	if got := lookup(t, pwned, strings.Repeat("F", pwnedHashLength)); got != 0 {
+		t.Errorf("missing hash: got %d, want 0", got)
+	}
+	if _, err := pwned.Lookup("ABC"); err == nil {
+		t.Error("Lookup of a short hash succeeded")
+	}
+}
+
+func TestPwnedPasswordsRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ntlm.txt")
+	if err := os.WriteFile(path, []byte("8846F7EAEE8FB117AD06BDD830B7586C:3\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if pwned, err := OpenPwnedPasswords(path); err == nil {
+		pwned.Close()
+		t.Error("OpenPwnedPasswords accepted an NTLM dataset")
+	}
+}
+
+func TestPwnedPasswordsRangeDirectory(t *testing.T) {
+	entries := newPwnedEntries(50)
+	dir := t.TempDir()
+
+	// Group suffixes by prefix; mirrors differ on the file extension and line endings
+	ranges := make(map[string][]string)
+	for _, entry := range entries {
+		prefix := entry.hash[:pwnedRangePrefix]
+		ranges[prefix] = append(ranges[prefix], fmt.Sprintf("%s:%d", entry.hash[pwnedRangePrefix:], entry.count))
+	}
+	i := 0
+	for prefix, lines := range ranges {
+		name, newline := prefix+".txt", "\r\n"
+		if i%2 == 1 {
+			name, newline = prefix, "\n"
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, newline)), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		i++
+	}
+
+	pwned, err := OpenPwnedPasswords(dir)
+	if err != nil {
+		t.Fatalf("OpenPwnedPasswords: %v", err)
+	}
+	defer pwned.Close()
+
+	for _, entry := range entries {
+		if got := lookup(t, pwned, strings.ToLower(entry.hash)); got != entry.count {
+			t.Errorf("Lookup(%s) = %d, want %d", entry.hash, got, entry.count)
+		}
+	}
+
+	// A hash missing from an existing range file was never seen
+	for _, entry := range entries {
+		prefix := entry.hash[:pwnedRangePrefix]
+		if got := lookup(t, pwned, prefix+strings.Repeat("0", pwnedHashLength-pwnedRangePrefix)); got != 0 {
+			t.Errorf("missing hash in range %s: got %d, want 0", prefix, got)
+		}
+	}
+
+	// A missing range file means an incomplete copy, not an unseen password
+	for _, hash := range missingHashes(entries, 50) {
+		if _, ok := ranges[hash[:pwnedRangePrefix]]; ok {
+			continue
+		}
+		if _, err := pwned.Lookup(hash); err == nil {
+			t.Errorf("Lookup(%s) without a range file succeeded", hash)
+		}
+		break
+	}
+}
+
+// missingHashes returns n hashes absent from entries
+func missingHashes(entries []pwnedEntry, n int) []string {
+	present := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		present[entry.hash] = true
+	}
+
+	var hashes []string
+	for i := 0; len(hashes) < n; i++ {
+		sum := sha1.Sum([]byte(fmt.Sprintf("not-pwned-%d", i)))
+		if hash := strings.ToUpper(hex.EncodeToString(sum[:])); !present[hash] {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
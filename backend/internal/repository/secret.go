@@ -2,15 +2,39 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"my-vault/internal/models"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrNotFound is returned when a requested record does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrRevisionMismatch is returned when a conditional write finds the record
+// changed since the caller read it
+var ErrRevisionMismatch = errors.New("revision does not match")
+
+// secretColumns selects a secret together with its aggregated tag names
+const secretColumns = `
+	s.id, s.title, s.type, s.encrypted_value, s.fields, s.folder,
+	COALESCE((
+		SELECT array_agg(t.name ORDER BY t.name)
+		FROM secret_tags st
+		JOIN tags t ON t.id = st.tag_id
+		WHERE st.secret_id = s.id
+	), '{}'::text[]),
+	s.version, s.revision, s.updated_by, s.created_at, s.updated_at, s.deleted_at,
+	s.expires_at, s.rotate_every, COALESCE(s.rotated_at, s.updated_at)
+`
+
 // SecretRepository handles database operations for secrets
 type SecretRepository struct {
 	pool *pgxpool.Pool
@@ -26,23 +50,42 @@ func NewSecretRepository(db *PostgresDB) *SecretRepository {
 // Create creates a new secret in the database
 func (r *SecretRepository) Create(ctx context.Context, secret *models.Secret) error {
 	query := `
-		INSERT INTO secrets (id, title, type, encrypted_value, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO secrets (id, title, type, encrypted_value, fields, folder, version, revision, updated_by, created_at,
+			updated_at, expires_at, rotate_every, rotated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 
 	secret.ID = uuid.New().String()
+	secret.Version = 1
+	secret.Revision = 1
 	now := time.Now()
 	secret.CreatedAt = now
 	secret.UpdatedAt = now
+	secret.RotatedAt = now
 
-	_, err := r.pool.Exec(ctx, query,
-		secret.ID,
-		secret.Title,
-		secret.Type,
-		secret.EncryptedValue,
-		secret.CreatedAt,
-		secret.UpdatedAt,
-	)
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query,
+			secret.ID,
+			secret.Title,
+			secret.Type,
+			secret.EncryptedValue,
+			fieldsOrEmpty(secret.Fields),
+			secret.Folder,
+			secret.Version,
+			secret.Revision,
+			secret.UpdatedBy,
+			secret.CreatedAt,
+			secret.UpdatedAt,
+			secret.ExpiresAt,
+			rotateEverySeconds(secret.RotateEvery),
+			secret.RotatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		return replaceTags(ctx, tx, secret.ID, secret.Tags)
+	})
 
 	if err != nil {
 		return fmt.Errorf("failed to create secret: %w", err)
@@ -53,38 +96,278 @@ func (r *SecretRepository) Create(ctx context.Context, secret *models.Secret) er
 
 // Get retrieves a secret by ID
 func (r *SecretRepository) Get(ctx context.Context, id string) (*models.Secret, error) {
+	query := `SELECT ` + secretColumns + ` FROM secrets s WHERE s.id = $1 AND s.deleted_at IS NULL`
+
+	secret, err := scanSecret(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("secret %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	return secret, nil
+}
+
+// List retrieves all secrets matching the filter, excluding the trash
+func (r *SecretRepository) List(ctx context.Context, filter models.SecretFilter) ([]*models.Secret, error) {
+	conditions := []string{"s.deleted_at IS NULL"}
+	var args []any
+
+	// Match plain custom fields by JSONB containment so the GIN index is used
+	for name, value := range filter.Fields {
+		contains, err := json.Marshal([]models.SecretField{{Name: name, Value: value}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode field filter: %w", err)
+		}
+		args = append(args, string(contains))
+		conditions = append(conditions, fmt.Sprintf("s.fields @> $%d::jsonb", len(args)))
+	}
+
+	if filter.Tag != "" {
+		args = append(args, filter.Tag)
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM secret_tags st JOIN tags t ON t.id = st.tag_id
+			WHERE st.secret_id = s.id AND t.name = $%d
+		)`, len(args)))
+	}
+
+	// Match the folder itself and everything below it
+	if filter.FolderPrefix != "" {
+		args = append(args, filter.FolderPrefix, escapeLike(filter.FolderPrefix)+"/%")
+		conditions = append(conditions, fmt.Sprintf("(s.folder = $%d OR s.folder LIKE $%d)", len(args)-1, len(args)))
+	}
+
+	if filter.ExpiringBefore != nil {
+		args = append(args, *filter.ExpiringBefore)
+		conditions = append(conditions, fmt.Sprintf("s.expires_at <= $%d", len(args)))
+	}
+
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		conditions = append(conditions, fmt.Sprintf("s.type = $%d", len(args)))
+	}
+
+	// A prefix LIKE can use idx_secrets_title_pattern under any collation, unlike idx_secrets_title
+	if filter.TitlePrefix != "" {
+		args = append(args, escapeLike(filter.TitlePrefix)+"%")
+		conditions = append(conditions, fmt.Sprintf("s.title LIKE $%d", len(args)))
+	}
+
+	for _, bound := range dateBounds(filter) {
+		args = append(args, bound.at)
+		conditions = append(conditions, fmt.Sprintf("%s $%d", bound.condition, len(args)))
+	}
+
+	// Match every search word in the title, a custom field name or a plain field value
+	for _, word := range filter.SearchWords {
+		args = append(args, "%"+escapeLike(word)+"%")
+		conditions = append(conditions, fmt.Sprintf(`(lower(s.title) LIKE $%[1]d OR EXISTS (
+			SELECT 1 FROM jsonb_array_elements(s.fields) f
+			WHERE lower(f->>'name') LIKE $%[1]d OR lower(f->>'value') LIKE $%[1]d
+		))`, len(args)))
+	}
+
+	column, direction, after := sortColumn(filter)
+
+	// Continue behind the cursor; the row comparison lets an index on the sort column serve it
+	if filter.After != nil {
+		var key any = filter.After.At
+		if filter.Sort == models.SortByTitle {
+			key = filter.After.Title
+		}
+		args = append(args, key, filter.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, s.id) %s ($%d, $%d)", column, after, len(args)-1, len(args)))
+	}
+
 	query := `
-		SELECT id, title, type, encrypted_value, created_at, updated_at
-		FROM secrets
-		WHERE id = $1
+		SELECT ` + secretColumns + `
+		FROM secrets s
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ` + column + ` ` + direction + `, s.id ` + direction
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	return r.query(ctx, query, args...)
+}
+
+// dateBound is a time range condition, e.g. "s.created_at >", with its bound
+type dateBound struct {
+	condition string
+	at        time.Time
+}
+
+// dateBounds returns the set creation and update time bounds of a filter
+func dateBounds(filter models.SecretFilter) []dateBound {
+	var bounds []dateBound
+	for _, b := range []struct {
+		condition string
+		at        *time.Time
+	}{
+		{"s.created_at >", filter.CreatedAfter},
+		{"s.created_at <", filter.CreatedBefore},
+		{"s.updated_at >", filter.UpdatedAfter},
+		{"s.updated_at <", filter.UpdatedBefore},
+	} {
+		if b.at != nil {
+			bounds = append(bounds, dateBound{b.condition, *b.at})
+		}
+	}
+	return bounds
+}
+
+// sortColumn returns the column a listing is ordered by, the direction, and
+// the comparison operator selecting the rows after a given row
+func sortColumn(filter models.SecretFilter) (column, direction, after string) {
+	switch filter.Sort {
+	case models.SortByTitle:
+		column = "s.title"
+	case models.SortByUpdatedAt:
+		column = "s.updated_at"
+	default:
+		column = "s.created_at"
+	}
+
+	if filter.Ascending {
+		return column, "ASC", ">"
+	}
+	return column, "DESC", "<"
+}
+
+// Update updates an existing secret without recording a new version.
+// Use UpdateWithVersion when the secret's content changes.
+func (r *SecretRepository) Update(ctx context.Context, secret *models.Secret) error {
+	query := `
+		UPDATE secrets
+		SET title = $1, type = $2, encrypted_value = $3, fields = $4, folder = $5, updated_at = $6,
+			revision = revision + 1
+		WHERE id = $7
+		RETURNING revision
 	`
 
-	var secret models.Secret
-	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&secret.ID,
-		&secret.Title,
-		&secret.Type,
-		&secret.EncryptedValue,
-		&secret.CreatedAt,
-		&secret.UpdatedAt,
-	)
+	secret.UpdatedAt = time.Now()
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := lockSecretRevision(ctx, tx, secret.ID, secret.Revision); err != nil {
+			return err
+		}
 
+		err := tx.QueryRow(ctx, query,
+			secret.Title,
+			secret.Type,
+			secret.EncryptedValue,
+			fieldsOrEmpty(secret.Fields),
+			secret.Folder,
+			secret.UpdatedAt,
+			secret.ID,
+		).Scan(&secret.Revision)
+		if err != nil {
+			return err
+		}
+
+		return replaceTags(ctx, tx, secret.ID, secret.Tags)
+	})
+
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrRevisionMismatch) {
+		return err
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get secret: %w", err)
+		return fmt.Errorf("failed to update secret: %w", err)
 	}
 
-	return &secret, nil
+	return nil
 }
 
-// List retrieves all secrets
-func (r *SecretRepository) List(ctx context.Context) ([]*models.Secret, error) {
-	query := `
-		SELECT id, title, type, encrypted_value, created_at, updated_at
-		FROM secrets
-		ORDER BY created_at DESC
+// Delete moves a secret at the given revision to the trash
+func (r *SecretRepository) Delete(ctx context.Context, id string, revision int) error {
+	query := `UPDATE secrets SET deleted_at = $2, revision = revision + 1 WHERE id = $1`
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := lockSecretRevision(ctx, tx, id, revision); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, query, id, time.Now())
+		return err
+	})
+
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrRevisionMismatch) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+
+	return nil
+}
+
+// RenameFolder moves every secret in a folder, including subfolders, under a new path.
+// It returns the number of secrets moved.
+func (r *SecretRepository) RenameFolder(ctx context.Context, from, to string, revisions map[string]int) (int64, error) {
+	selectQuery := `
+		SELECT id, revision FROM secrets
+		WHERE (folder = $1 OR folder LIKE $2) AND deleted_at IS NULL
+		FOR UPDATE
+	`
+	updateQuery := `
+		UPDATE secrets
+		SET folder = $2 || substr(folder, length($1) + 1), updated_at = $4, revision = revision + 1
+		WHERE id = ANY($3::uuid[])
 	`
 
-	rows, err := r.pool.Query(ctx, query)
+	var count int64
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, selectQuery, from, escapeLike(from)+"/%")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		current := make(map[string]int)
+		for rows.Next() {
+			var id string
+			var revision int
+			if err := rows.Scan(&id, &revision); err != nil {
+				return err
+			}
+			current[id] = revision
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if err := matchFolderRevisions(current, revisions); err != nil {
+			return err
+		}
+
+		ids := make([]string, 0, len(current))
+		for id := range current {
+			ids = append(ids, id)
+		}
+		result, err := tx.Exec(ctx, updateQuery, from, to, ids, time.Now())
+		if err != nil {
+			return err
+		}
+		count = result.RowsAffected()
+		return nil
+	})
+
+	if errors.Is(err, ErrRevisionMismatch) {
+		return 0, err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to rename folder: %w", err)
+	}
+
+	return count, nil
+}
+
+// query runs a secret select and scans every row
+func (r *SecretRepository) query(ctx context.Context, query string, args ...any) ([]*models.Secret, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list secrets: %w", err)
 	}
@@ -92,19 +375,11 @@ func (r *SecretRepository) List(ctx context.Context) ([]*models.Secret, error) {
 
 	var secrets []*models.Secret
 	for rows.Next() {
-		var secret models.Secret
-		err := rows.Scan(
-			&secret.ID,
-			&secret.Title,
-			&secret.Type,
-			&secret.EncryptedValue,
-			&secret.CreatedAt,
-			&secret.UpdatedAt,
-		)
+		secret, err := scanSecret(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan secret: %w", err)
 		}
-		secrets = append(secrets, &secret)
+		secrets = append(secrets, secret)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -114,47 +389,120 @@ func (r *SecretRepository) List(ctx context.Context) ([]*models.Secret, error) {
 	return secrets, nil
 }
 
-// Update updates an existing secret
-func (r *SecretRepository) Update(ctx context.Context, secret *models.Secret) error {
-	query := `
-		UPDATE secrets
-		SET title = $1, type = $2, encrypted_value = $3, updated_at = $4
-		WHERE id = $5
-	`
+// scanSecret scans a row selected with secretColumns
+func scanSecret(row pgx.Row) (*models.Secret, error) {
+	var secret models.Secret
+	var rotateEvery *int64
+	err := row.Scan(
+		&secret.ID,
+		&secret.Title,
+		&secret.Type,
+		&secret.EncryptedValue,
+		&secret.Fields,
+		&secret.Folder,
+		&secret.Tags,
+		&secret.Version,
+		&secret.Revision,
+		&secret.UpdatedBy,
+		&secret.CreatedAt,
+		&secret.UpdatedAt,
+		&secret.DeletedAt,
+		&secret.ExpiresAt,
+		&rotateEvery,
+		&secret.RotatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
 
-	secret.UpdatedAt = time.Now()
+	if rotateEvery != nil {
+		secret.RotateEvery = time.Duration(*rotateEvery) * time.Second
+	}
 
-	result, err := r.pool.Exec(ctx, query,
-		secret.Title,
-		secret.Type,
-		secret.EncryptedValue,
-		secret.UpdatedAt,
-		secret.ID,
-	)
+	return &secret, nil
+}
 
+// lockSecretRevision locks the row of a live secret for the rest of the
+// transaction, failing unless the secret is at the expected revision
+func lockSecretRevision(ctx context.Context, tx pgx.Tx, id string, revision int) error {
+	var current int
+	err := tx.QueryRow(ctx, `SELECT revision FROM secrets WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("secret %w", ErrNotFound)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update secret: %w", err)
+		return err
 	}
 
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("secret not found")
+	if current != revision {
+		return fmt.Errorf("secret %w", ErrRevisionMismatch)
 	}
 
 	return nil
 }
 
-// Delete removes a secret by ID
-func (r *SecretRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM secrets WHERE id = $1`
+// matchFolderRevisions fails unless the live secrets of a folder are exactly
+// the checked ones, each still at the revision it was checked at
+func matchFolderRevisions(current, checked map[string]int) error {
+	if len(current) != len(checked) {
+		return fmt.Errorf("folder %w", ErrRevisionMismatch)
+	}
+	for id, revision := range current {
+		if want, ok := checked[id]; !ok || want != revision {
+			return fmt.Errorf("folder %w", ErrRevisionMismatch)
+		}
+	}
+	return nil
+}
+
+// replaceTags rewrites the tag links of a secret inside a transaction, creating missing tags
+func replaceTags(ctx context.Context, tx pgx.Tx, secretID string, tags []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM secret_tags WHERE secret_id = $1`, secretID); err != nil {
+		return fmt.Errorf("failed to clear tags: %w", err)
+	}
 
-	result, err := r.pool.Exec(ctx, query, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete secret: %w", err)
+	if len(tags) == 0 {
+		return nil
 	}
 
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("secret not found")
+	createTags := `
+		INSERT INTO tags (name)
+		SELECT unnest($1::text[])
+		ON CONFLICT (name) DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, createTags, tags); err != nil {
+		return fmt.Errorf("failed to create tags: %w", err)
+	}
+
+	linkTags := `
+		INSERT INTO secret_tags (secret_id, tag_id)
+		SELECT $1, id FROM tags WHERE name = ANY($2::text[])
+	`
+	if _, err := tx.Exec(ctx, linkTags, secretID, tags); err != nil {
+		return fmt.Errorf("failed to link tags: %w", err)
 	}
 
 	return nil
-} 
\ No newline at end of file
+}
+
+// rotateEverySeconds stores a rotation interval as whole seconds, NULL when unset
+func rotateEverySeconds(d time.Duration) *int64 {
+	if d <= 0 {
+		return nil
+	}
+	seconds := int64(d / time.Second)
+	return &seconds
+}
+
+// fieldsOrEmpty stores a missing field list as an empty JSON array
+func fieldsOrEmpty(fields []models.SecretField) []models.SecretField {
+	if fields == nil {
+		return []models.SecretField{}
+	}
+	return fields
+}
+
+// escapeLike escapes LIKE wildcards so a value matches literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+func init() {
+	// lower() only folds ASCII letters; search compares text folded like strings.ToLower
+	sqlite.MustRegisterDeterministicScalarFunction("unicode_lower", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		text, ok := args[0].(string)
+		if !ok {
+			return args[0], nil
+		}
+		return strings.ToLower(text), nil
+	})
+}
+
+// SQLiteDB wraps an embedded SQLite database file
+type SQLiteDB struct {
+	db *sql.DB
+}
+
+// NewSQLiteDB opens the SQLite database at path, creating it and its
+// directory when missing. The database runs in WAL mode with foreign keys
+// enforced; write transactions take the write lock up front.
+func NewSQLiteDB(path string) (*SQLiteDB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	params := url.Values{}
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "synchronous(NORMAL)")
+	params.Set("_txlock", "immediate")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Check the connection and that WAL mode took effect
+	var mode string
+	if err := db.QueryRow(`PRAGMA journal_mode`).Scan(&mode); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if mode != "wal" {
+		db.Close()
+		return nil, fmt.Errorf("failed to enable WAL mode, journal mode is %s", mode)
+	}
+
+	// Keep the database file private to the vault's user
+	if err := os.Chmod(path, 0o600); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to restrict database permissions: %w", err)
+	}
+
+	log.Printf("Successfully opened SQLite database %s", path)
+
+	if err := initSQLiteSchema(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	}
+
+	return &SQLiteDB{db: db}, nil
+}
+
+// Close closes the database
+func (db *SQLiteDB) Close() {
+	if db.db != nil {
+		db.db.Close()
+	}
+}
+
+// GetDB returns the underlying database handle
+func (db *SQLiteDB) GetDB() *sql.DB {
+	return db.db
+}
+
+// sqliteAddedColumns lists the columns added to the schema after its first release
+var sqliteAddedColumns = []struct{ table, column, definition string }{
+	{"secrets", "revision", "INTEGER NOT NULL DEFAULT 1"},
+}
+
+// initSQLiteSchema creates the necessary tables. Timestamps are stored as
+// Unix nanoseconds so they compare numerically.
+func initSQLiteSchema(db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	createTableSQL := `
+		CREATE TABLE IF NOT EXISTS secrets (
+			id TEXT PRIMARY KEY,
+			title TEXT NOT NULL,
+			type TEXT NOT NULL,
+			encrypted_value BLOB NOT NULL,
+			fields TEXT NOT NULL DEFAULT '[]',
+			folder TEXT NOT NULL DEFAULT '',
+			version INTEGER NOT NULL DEFAULT 1,
+			revision INTEGER NOT NULL DEFAULT 1,
+			updated_by TEXT NOT NULL DEFAULT 'owner',
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL,
+			deleted_at INTEGER,
+			expires_at INTEGER,
+			rotate_every INTEGER,
+			rotated_at INTEGER,
+			expiry_notified_at INTEGER,
+			rotation_notified_at INTEGER
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_secrets_title ON secrets(title);
+		CREATE INDEX IF NOT EXISTS idx_secrets_type ON secrets(type);
+		CREATE INDEX IF NOT EXISTS idx_secrets_folder ON secrets(folder);
+		CREATE INDEX IF NOT EXISTS idx_secrets_deleted_at ON secrets(deleted_at) WHERE deleted_at IS NOT NULL;
+		CREATE INDEX IF NOT EXISTS idx_secrets_expires_at ON secrets(expires_at) WHERE expires_at IS NOT NULL;
+
+		CREATE TABLE IF NOT EXISTS secret_tags (
+			secret_id TEXT NOT NULL REFERENCES secrets(id) ON DELETE CASCADE,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (secret_id, tag)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_secret_tags_tag ON secret_tags(tag);
+
+		CREATE TABLE IF NOT EXISTS secret_versions (
+			secret_id TEXT NOT NULL REFERENCES secrets(id) ON DELETE CASCADE,
+			version INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			type TEXT NOT NULL,
+			encrypted_value BLOB NOT NULL,
+			fields TEXT NOT NULL DEFAULT '[]',
+			author TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			PRIMARY KEY (secret_id, version)
+		);
+
+		CREATE TABLE IF NOT EXISTS attachments (
+			id TEXT PRIMARY KEY,
+			secret_id TEXT NOT NULL REFERENCES secrets(id) ON DELETE CASCADE,
+			encrypted_name BLOB NOT NULL,
+			size INTEGER NOT NULL,
+			created_by TEXT NOT NULL,
+			created_at INTEGER NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_attachments_secret ON attachments(secret_id);
+
+		CREATE TABLE IF NOT EXISTS vault_header (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			salt BLOB NOT NULL,
+			verifier BLOB NOT NULL,
+			created_at INTEGER NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS policies (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT NOT NULL DEFAULT '',
+			document TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS policy_subjects (
+			policy_id TEXT NOT NULL REFERENCES policies(id) ON DELETE CASCADE,
+			subject_type TEXT NOT NULL,
+			subject_id TEXT NOT NULL,
+			PRIMARY KEY (policy_id, subject_type, subject_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_policy_subjects_subject ON policy_subjects(subject_type, subject_id);
+
+		-- Earlier releases kept a blind search index; search now matches the columns directly
+		DROP TABLE IF EXISTS secret_search_tokens;
+		DROP TABLE IF EXISTS search_index;
+	`
+
+	if _, err := db.ExecContext(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	// Databases created by earlier releases lack columns added since
+	for _, added := range sqliteAddedColumns {
+		var present int
+		err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, added.table, added.column).Scan(&present)
+		if err != nil {
+			return fmt.Errorf("failed to inspect table %s: %w", added.table, err)
+		}
+		if present > 0 {
+			continue
+		}
+
+		if _, err := db.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, added.table, added.column, added.definition)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", added.table, added.column, err)
+		}
+	}
+
+	log.Println("Database schema initialized successfully")
+	return nil
+}
+
+// inTx runs fn in a transaction, committing when it returns nil
+func (db *SQLiteDB) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// isUniqueViolation reports whether err is a SQLite unique constraint failure
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY)
+}
+
+// toUnix stores a timestamp as Unix nanoseconds
+func toUnix(t time.Time) int64 {
+	return t.UnixNano()
+}
+
+// toNullUnix stores an optional timestamp as Unix nanoseconds or NULL
+func toNullUnix(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixNano(), Valid: true}
+}
+
+// fromUnix reads a timestamp stored as Unix nanoseconds
+func fromUnix(n int64) time.Time {
+	return time.Unix(0, n)
+}
+
+// fromNullUnix reads an optional timestamp stored as Unix nanoseconds
+func fromNullUnix(n sql.NullInt64) *time.Time {
+	if !n.Valid {
+		return nil
+	}
+	t := time.Unix(0, n.Int64)
+	return &t
+}
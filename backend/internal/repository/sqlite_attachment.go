@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"my-vault/internal/models"
+)
+
+// CreateAttachment stores the metadata of an attachment, provided the
+// secret's attachments stay within maxTotalSize bytes (zero disables the limit)
+func (r *SQLiteSecretRepository) CreateAttachment(ctx context.Context, attachment *models.Attachment, maxTotalSize int64) error {
+	query := `
+		INSERT INTO attachments (id, secret_id, encrypted_name, size, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	// Write transactions take the database lock up front, so the total cannot change before the insert
+	err := r.db.inTx(ctx, func(tx *sql.Tx) error {
+		if maxTotalSize > 0 {
+			var used int64
+			err := tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(size), 0) FROM attachments WHERE secret_id = ?`, attachment.SecretID).Scan(&used)
+			if err != nil {
+				return err
+			}
+			if used+attachment.Size > maxTotalSize {
+				return fmt.Errorf("%w: secret attachments exceed %d bytes", ErrTooLarge, maxTotalSize)
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, query,
+			attachment.ID,
+			attachment.SecretID,
+			attachment.EncryptedName,
+			attachment.Size,
+			attachment.CreatedBy,
+			toUnix(attachment.CreatedAt),
+		)
+		return err
+	})
+
+	if errors.Is(err, ErrTooLarge) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	return nil
+}
+
+// ListAttachments retrieves the attachments of a secret, oldest first
+func (r *SQLiteSecretRepository) ListAttachments(ctx context.Context, secretID string) ([]*models.Attachment, error) {
+	query := `
+		SELECT id, secret_id, encrypted_name, size, created_by, created_at
+		FROM attachments
+		WHERE secret_id = ?
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.db.QueryContext(ctx, query, secretID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []*models.Attachment
+	for rows.Next() {
+		attachment, err := scanSQLiteAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// GetAttachment retrieves the metadata of one attachment of a secret
+func (r *SQLiteSecretRepository) GetAttachment(ctx context.Context, secretID, id string) (*models.Attachment, error) {
+	query := `
+		SELECT id, secret_id, encrypted_name, size, created_by, created_at
+		FROM attachments
+		WHERE secret_id = ? AND id = ?
+	`
+
+	attachment, err := scanSQLiteAttachment(r.db.db.QueryRowContext(ctx, query, secretID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("attachment %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return attachment, nil
+}
+
+// DeleteAttachment removes the metadata of an attachment
+func (r *SQLiteSecretRepository) DeleteAttachment(ctx context.Context, secretID, id string) error {
+	query := `DELETE FROM attachments WHERE secret_id = ? AND id = ?`
+
+	result, err := r.db.db.ExecContext(ctx, query, secretID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	return expectAffected(result, "attachment")
+}
+
+// AttachmentsSize returns the total size of a secret's attachments in bytes
+func (r *SQLiteSecretRepository) AttachmentsSize(ctx context.Context, secretID string) (int64, error) {
+	var size int64
+	err := r.db.db.QueryRowContext(ctx, `SELECT COALESCE(SUM(size), 0) FROM attachments WHERE secret_id = ?`, secretID).Scan(&size)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum attachment sizes: %w", err)
+	}
+
+	return size, nil
+}
+
+// scanSQLiteAttachment scans an attachments row
+func scanSQLiteAttachment(row sqliteRow) (*models.Attachment, error) {
+	var attachment models.Attachment
+	var createdAt int64
+	err := row.Scan(
+		&attachment.ID,
+		&attachment.SecretID,
+		&attachment.EncryptedName,
+		&attachment.Size,
+		&attachment.CreatedBy,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment.CreatedAt = fromUnix(createdAt)
+	return &attachment, nil
+}
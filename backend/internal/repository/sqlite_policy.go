@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"my-vault/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// sqlitePolicyColumns selects a policy together with its subjects as a JSON array
+const sqlitePolicyColumns = `
+	p.id, p.name, p.description, p.document, p.created_at, p.updated_at,
+	(
+		SELECT json_group_array(json_object('type', subject_type, 'id', subject_id))
+		FROM (
+			SELECT subject_type, subject_id FROM policy_subjects
+			WHERE policy_id = p.id
+			ORDER BY subject_type, subject_id
+		)
+	)
+`
+
+// SQLitePolicyRepository handles SQLite operations for policies
+type SQLitePolicyRepository struct {
+	db *SQLiteDB
+}
+
+// NewSQLitePolicyRepository creates a new SQLite policy repository
+func NewSQLitePolicyRepository(db *SQLiteDB) *SQLitePolicyRepository {
+	return &SQLitePolicyRepository{
+		db: db,
+	}
+}
+
+// Create creates a new policy and its subject attachments
+func (r *SQLitePolicyRepository) Create(ctx context.Context, policy *models.Policy) error {
+	document, err := json.Marshal(policy.Document)
+	if err != nil {
+		return fmt.Errorf("failed to encode policy document: %w", err)
+	}
+
+	policy.ID = uuid.New().String()
+	now := time.Now()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+
+	return r.db.inTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO policies (id, name, description, document, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`
+
+		_, err := tx.ExecContext(ctx, query,
+			policy.ID,
+			policy.Name,
+			policy.Description,
+			string(document),
+			toUnix(policy.CreatedAt),
+			toUnix(policy.UpdatedAt),
+		)
+		if err != nil {
+			return sqlitePolicyWriteError("create", err)
+		}
+
+		return replaceSQLiteSubjects(ctx, tx, policy)
+	})
+}
+
+// Get retrieves a policy by ID
+func (r *SQLitePolicyRepository) Get(ctx context.Context, id string) (*models.Policy, error) {
+	query := `SELECT ` + sqlitePolicyColumns + ` FROM policies p WHERE p.id = ?`
+
+	policy, err := scanSQLitePolicy(r.db.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("policy %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// List retrieves all policies
+func (r *SQLitePolicyRepository) List(ctx context.Context) ([]*models.Policy, error) {
+	query := `SELECT ` + sqlitePolicyColumns + ` FROM policies p ORDER BY p.name`
+
+	return r.query(ctx, query)
+}
+
+// ListForSubjects retrieves the policies attached to any of the given subjects
+func (r *SQLitePolicyRepository) ListForSubjects(ctx context.Context, subjects []models.PolicySubject) ([]*models.Policy, error) {
+	if len(subjects) == 0 {
+		return nil, nil
+	}
+
+	matches := make([]string, len(subjects))
+	args := make([]any, 0, 2*len(subjects))
+	for i, subject := range subjects {
+		matches[i] = "(s.subject_type = ? AND s.subject_id = ?)"
+		args = append(args, subject.Type, subject.ID)
+	}
+
+	query := `
+		SELECT ` + sqlitePolicyColumns + `
+		FROM policies p
+		WHERE EXISTS (
+			SELECT 1
+			FROM policy_subjects s
+			WHERE s.policy_id = p.id AND (` + strings.Join(matches, " OR ") + `)
+		)
+		ORDER BY p.name
+	`
+
+	return r.query(ctx, query, args...)
+}
+
+// Update updates an existing policy and replaces its subject attachments
+func (r *SQLitePolicyRepository) Update(ctx context.Context, policy *models.Policy) error {
+	document, err := json.Marshal(policy.Document)
+	if err != nil {
+		return fmt.Errorf("failed to encode policy document: %w", err)
+	}
+
+	policy.UpdatedAt = time.Now()
+
+	return r.db.inTx(ctx, func(tx *sql.Tx) error {
+		query := `
+			UPDATE policies
+			SET name = ?, description = ?, document = ?, updated_at = ?
+			WHERE id = ?
+		`
+
+		result, err := tx.ExecContext(ctx, query,
+			policy.Name,
+			policy.Description,
+			string(document),
+			toUnix(policy.UpdatedAt),
+			policy.ID,
+		)
+		if err != nil {
+			return sqlitePolicyWriteError("update", err)
+		}
+
+		if err := expectAffected(result, "policy"); err != nil {
+			return err
+		}
+
+		return replaceSQLiteSubjects(ctx, tx, policy)
+	})
+}
+
+// Delete removes a policy by ID
+func (r *SQLitePolicyRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.db.ExecContext(ctx, `DELETE FROM policies WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete policy: %w", err)
+	}
+
+	return expectAffected(result, "policy")
+}
+
+// query runs a policy select and scans every row
+func (r *SQLitePolicyRepository) query(ctx context.Context, query string, args ...any) ([]*models.Policy, error) {
+	rows, err := r.db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []*models.Policy
+	for rows.Next() {
+		policy, err := scanSQLitePolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan policy: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating policies: %w", err)
+	}
+
+	return policies, nil
+}
+
+// scanSQLitePolicy scans a row selected with sqlitePolicyColumns
+func scanSQLitePolicy(row sqliteRow) (*models.Policy, error) {
+	var policy models.Policy
+	var document, subjects string
+	var createdAt, updatedAt int64
+	err := row.Scan(
+		&policy.ID,
+		&policy.Name,
+		&policy.Description,
+		&document,
+		&createdAt,
+		&updatedAt,
+		&subjects,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(document), &policy.Document); err != nil {
+		return nil, fmt.Errorf("failed to decode policy document: %w", err)
+	}
+	if err := json.Unmarshal([]byte(subjects), &policy.Subjects); err != nil {
+		return nil, fmt.Errorf("failed to decode policy subjects: %w", err)
+	}
+	policy.CreatedAt = fromUnix(createdAt)
+	policy.UpdatedAt = fromUnix(updatedAt)
+
+	return &policy, nil
+}
+
+// replaceSQLiteSubjects rewrites the subject attachments of a policy inside a transaction
+func replaceSQLiteSubjects(ctx context.Context, tx *sql.Tx, policy *models.Policy) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM policy_subjects WHERE policy_id = ?`, policy.ID); err != nil {
+		return fmt.Errorf("failed to clear policy subjects: %w", err)
+	}
+
+	query := `
+		INSERT INTO policy_subjects (policy_id, subject_type, subject_id)
+		VALUES (?, ?, ?)
+		ON CONFLICT DO NOTHING
+	`
+
+	for _, subject := range policy.Subjects {
+		if _, err := tx.ExecContext(ctx, query, policy.ID, subject.Type, subject.ID); err != nil {
+			return fmt.Errorf("failed to attach policy subject: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// sqlitePolicyWriteError maps unique violations on the policy name to ErrConflict
+func sqlitePolicyWriteError(op string, err error) error {
+	if isUniqueViolation(err) {
+		return fmt.Errorf("policy name %w", ErrConflict)
+	}
+	return fmt.Errorf("failed to %s policy: %w", op, err)
+}
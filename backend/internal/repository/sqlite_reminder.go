@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+// ListExpiryReminders retrieves secrets expiring before cutoff, including
+// expired ones, whose expiry reminder has not been sent yet
+func (r *SQLiteSecretRepository) ListExpiryReminders(ctx context.Context, cutoff time.Time) ([]*models.Secret, error) {
+	query := `
+		SELECT ` + sqliteSecretColumns + `
+		FROM secrets s
+		WHERE s.deleted_at IS NULL AND s.expires_at <= ? AND s.expiry_notified_at IS NULL
+		ORDER BY s.expires_at
+	`
+
+	return r.query(ctx, query, toUnix(cutoff))
+}
+
+// ListRotationReminders retrieves secrets whose rotation is due at now and
+// whose rotation reminder has not been sent yet
+func (r *SQLiteSecretRepository) ListRotationReminders(ctx context.Context, now time.Time) ([]*models.Secret, error) {
+	query := `
+		SELECT ` + sqliteSecretColumns + `
+		FROM secrets s
+		WHERE s.deleted_at IS NULL
+			AND s.rotate_every IS NOT NULL
+			AND COALESCE(s.rotated_at, s.updated_at) + s.rotate_every * 1000000000 <= ?
+			AND s.rotation_notified_at IS NULL
+		ORDER BY s.created_at
+	`
+
+	return r.query(ctx, query, toUnix(now))
+}
+
+// MarkExpiryNotified records that the expiry reminder of a secret was sent
+func (r *SQLiteSecretRepository) MarkExpiryNotified(ctx context.Context, id string, at time.Time) error {
+	if _, err := r.db.db.ExecContext(ctx, `UPDATE secrets SET expiry_notified_at = ? WHERE id = ?`, toUnix(at), id); err != nil {
+		return fmt.Errorf("failed to mark expiry reminder: %w", err)
+	}
+	return nil
+}
+
+// MarkRotationNotified records that the rotation reminder of a secret was sent
+func (r *SQLiteSecretRepository) MarkRotationNotified(ctx context.Context, id string, at time.Time) error {
+	if _, err := r.db.db.ExecContext(ctx, `UPDATE secrets SET rotation_notified_at = ? WHERE id = ?`, toUnix(at), id); err != nil {
+		return fmt.Errorf("failed to mark rotation reminder: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,459 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"my-vault/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// sqliteSecretColumns selects a secret together with its tag names as a JSON array
+const sqliteSecretColumns = `
+	s.id, s.title, s.type, s.encrypted_value, s.fields, s.folder,
+	(SELECT json_group_array(tag) FROM (SELECT tag FROM secret_tags WHERE secret_id = s.id ORDER BY tag)),
+	s.version, s.revision, s.updated_by, s.created_at, s.updated_at, s.deleted_at,
+	s.expires_at, s.rotate_every, COALESCE(s.rotated_at, s.updated_at)
+`
+
+// SQLiteSecretRepository handles SQLite operations for secrets
+type SQLiteSecretRepository struct {
+	db *SQLiteDB
+}
+
+// NewSQLiteSecretRepository creates a new SQLite secret repository
+func NewSQLiteSecretRepository(db *SQLiteDB) *SQLiteSecretRepository {
+	return &SQLiteSecretRepository{
+		db: db,
+	}
+}
+
+// Create creates a new secret in the database
+func (r *SQLiteSecretRepository) Create(ctx context.Context, secret *models.Secret) error {
+	query := `
+		INSERT INTO secrets (id, title, type, encrypted_value, fields, folder, version, revision, updated_by, created_at,
+			updated_at, expires_at, rotate_every, rotated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	fields, err := json.Marshal(fieldsOrEmpty(secret.Fields))
+	if err != nil {
+		return fmt.Errorf("failed to encode fields: %w", err)
+	}
+
+	secret.ID = uuid.New().String()
+	secret.Version = 1
+	secret.Revision = 1
+	now := time.Now()
+	secret.CreatedAt = now
+	secret.UpdatedAt = now
+	secret.RotatedAt = now
+
+	err = r.db.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query,
+			secret.ID,
+			secret.Title,
+			secret.Type,
+			secret.EncryptedValue,
+			string(fields),
+			secret.Folder,
+			secret.Version,
+			secret.Revision,
+			secret.UpdatedBy,
+			toUnix(secret.CreatedAt),
+			toUnix(secret.UpdatedAt),
+			toNullUnix(secret.ExpiresAt),
+			rotateEverySeconds(secret.RotateEvery),
+			toUnix(secret.RotatedAt),
+		)
+		if err != nil {
+			return err
+		}
+
+		return replaceSQLiteTags(ctx, tx, secret.ID, secret.Tags)
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves a secret by ID
+func (r *SQLiteSecretRepository) Get(ctx context.Context, id string) (*models.Secret, error) {
+	query := `SELECT ` + sqliteSecretColumns + ` FROM secrets s WHERE s.id = ? AND s.deleted_at IS NULL`
+
+	secret, err := scanSQLiteSecret(r.db.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("secret %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	return secret, nil
+}
+
+// List retrieves all secrets matching the filter, excluding the trash
+func (r *SQLiteSecretRepository) List(ctx context.Context, filter models.SecretFilter) ([]*models.Secret, error) {
+	conditions := []string{"s.deleted_at IS NULL"}
+	var args []any
+
+	// Match plain custom fields; concealed fields carry no plain value
+	for name, value := range filter.Fields {
+		args = append(args, name, value)
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM json_each(s.fields) f
+			WHERE json_extract(f.value, '$.name') = ?%d AND json_extract(f.value, '$.value') = ?%d
+		)`, len(args)-1, len(args)))
+	}
+
+	if filter.Tag != "" {
+		args = append(args, filter.Tag)
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM secret_tags st WHERE st.secret_id = s.id AND st.tag = ?%d
+		)`, len(args)))
+	}
+
+	// Match the folder itself and everything below it
+	if filter.FolderPrefix != "" {
+		args = append(args, filter.FolderPrefix)
+		conditions = append(conditions, sqliteInFolder(len(args)))
+	}
+
+	if filter.ExpiringBefore != nil {
+		args = append(args, toUnix(*filter.ExpiringBefore))
+		conditions = append(conditions, fmt.Sprintf("s.expires_at <= ?%d", len(args)))
+	}
+
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		conditions = append(conditions, fmt.Sprintf("s.type = ?%d", len(args)))
+	}
+
+	// GLOB, unlike LIKE, is case-sensitive and can use idx_secrets_title
+	if filter.TitlePrefix != "" {
+		args = append(args, escapeGlob(filter.TitlePrefix)+"*")
+		conditions = append(conditions, fmt.Sprintf("s.title GLOB ?%d", len(args)))
+	}
+
+	for _, bound := range dateBounds(filter) {
+		args = append(args, toUnix(bound.at))
+		conditions = append(conditions, fmt.Sprintf("%s ?%d", bound.condition, len(args)))
+	}
+
+	// Match every search word in the title, a custom field name or a plain field value
+	for _, word := range filter.SearchWords {
+		args = append(args, word)
+		conditions = append(conditions, fmt.Sprintf(`(instr(unicode_lower(s.title), ?%[1]d) > 0 OR EXISTS (
+			SELECT 1 FROM json_each(s.fields) f
+			WHERE instr(unicode_lower(json_extract(f.value, '$.name')), ?%[1]d) > 0
+				OR instr(unicode_lower(json_extract(f.value, '$.value')), ?%[1]d) > 0
+		))`, len(args)))
+	}
+
+	column, direction, after := sortColumn(filter)
+
+	// Continue behind the cursor
+	if filter.After != nil {
+		var key any = toUnix(filter.After.At)
+		if filter.Sort == models.SortByTitle {
+			key = filter.After.Title
+		}
+		args = append(args, key, filter.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, s.id) %s (?%d, ?%d)", column, after, len(args)-1, len(args)))
+	}
+
+	query := `
+		SELECT ` + sqliteSecretColumns + `
+		FROM secrets s
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ` + column + ` ` + direction + `, s.id ` + direction
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT ?%d", len(args))
+	}
+
+	return r.query(ctx, query, args...)
+}
+
+// Update updates an existing secret without recording a new version.
+// Use UpdateWithVersion when the secret's content changes.
+func (r *SQLiteSecretRepository) Update(ctx context.Context, secret *models.Secret) error {
+	query := `
+		UPDATE secrets
+		SET title = ?, type = ?, encrypted_value = ?, fields = ?, folder = ?, updated_at = ?,
+			revision = revision + 1
+		WHERE id = ?
+		RETURNING revision
+	`
+
+	fields, err := json.Marshal(fieldsOrEmpty(secret.Fields))
+	if err != nil {
+		return fmt.Errorf("failed to encode fields: %w", err)
+	}
+
+	secret.UpdatedAt = time.Now()
+
+	err = r.db.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkSQLiteRevision(ctx, tx, secret.ID, secret.Revision); err != nil {
+			return err
+		}
+
+		err := tx.QueryRowContext(ctx, query,
+			secret.Title,
+			secret.Type,
+			secret.EncryptedValue,
+			string(fields),
+			secret.Folder,
+			toUnix(secret.UpdatedAt),
+			secret.ID,
+		).Scan(&secret.Revision)
+		if err != nil {
+			return err
+		}
+
+		return replaceSQLiteTags(ctx, tx, secret.ID, secret.Tags)
+	})
+
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrRevisionMismatch) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	return nil
+}
+
+// Delete moves a secret at the given revision to the trash
+func (r *SQLiteSecretRepository) Delete(ctx context.Context, id string, revision int) error {
+	query := `UPDATE secrets SET deleted_at = ?, revision = revision + 1 WHERE id = ?`
+
+	err := r.db.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkSQLiteRevision(ctx, tx, id, revision); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, query, toUnix(time.Now()), id)
+		return err
+	})
+
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrRevisionMismatch) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+
+	return nil
+}
+
+// RenameFolder moves every secret in a folder, including subfolders, under a new path.
+// It returns the number of secrets moved.
+func (r *SQLiteSecretRepository) RenameFolder(ctx context.Context, from, to string, revisions map[string]int) (int64, error) {
+	selectQuery := `SELECT s.id, s.revision FROM secrets AS s WHERE ` + sqliteInFolder(1) + ` AND s.deleted_at IS NULL`
+	updateQuery := `
+		UPDATE secrets
+		SET folder = ?2 || substr(folder, length(?1) + 1), updated_at = ?3, revision = revision + 1
+		WHERE id = ?4
+	`
+
+	var count int64
+	err := r.db.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, selectQuery, from)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		current := make(map[string]int)
+		for rows.Next() {
+			var id string
+			var revision int
+			if err := rows.Scan(&id, &revision); err != nil {
+				return err
+			}
+			current[id] = revision
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if err := matchFolderRevisions(current, revisions); err != nil {
+			return err
+		}
+
+		now := toUnix(time.Now())
+		for id := range current {
+			if _, err := tx.ExecContext(ctx, updateQuery, from, to, now, id); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+
+	if errors.Is(err, ErrRevisionMismatch) {
+		return 0, err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to rename folder: %w", err)
+	}
+
+	return count, nil
+}
+
+// query runs a secret select and scans every row
+func (r *SQLiteSecretRepository) query(ctx context.Context, query string, args ...any) ([]*models.Secret, error) {
+	return querySQLiteSecrets(ctx, r.db.db, query, args...)
+}
+
+// sqliteQueryer is a database or a transaction
+type sqliteQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// querySQLiteSecrets runs a secret select on db and scans every row
+func querySQLiteSecrets(ctx context.Context, db sqliteQueryer, query string, args ...any) ([]*models.Secret, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	defer rows.Close()
+
+	var secrets []*models.Secret
+	for rows.Next() {
+		secret, err := scanSQLiteSecret(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan secret: %w", err)
+		}
+		secrets = append(secrets, secret)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating secrets: %w", err)
+	}
+
+	return secrets, nil
+}
+
+// sqliteRow is satisfied by both *sql.Row and *sql.Rows
+type sqliteRow interface {
+	Scan(dest ...any) error
+}
+
+// scanSQLiteSecret scans a row selected with sqliteSecretColumns
+func scanSQLiteSecret(row sqliteRow) (*models.Secret, error) {
+	var secret models.Secret
+	var fields, tags string
+	var createdAt, updatedAt, rotatedAt int64
+	var deletedAt, expiresAt, rotateEvery sql.NullInt64
+	err := row.Scan(
+		&secret.ID,
+		&secret.Title,
+		&secret.Type,
+		&secret.EncryptedValue,
+		&fields,
+		&secret.Folder,
+		&tags,
+		&secret.Version,
+		&secret.Revision,
+		&secret.UpdatedBy,
+		&createdAt,
+		&updatedAt,
+		&deletedAt,
+		&expiresAt,
+		&rotateEvery,
+		&rotatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(fields), &secret.Fields); err != nil {
+		return nil, fmt.Errorf("failed to decode fields: %w", err)
+	}
+	if err := json.Unmarshal([]byte(tags), &secret.Tags); err != nil {
+		return nil, fmt.Errorf("failed to decode tags: %w", err)
+	}
+
+	secret.CreatedAt = fromUnix(createdAt)
+	secret.UpdatedAt = fromUnix(updatedAt)
+	secret.RotatedAt = fromUnix(rotatedAt)
+	secret.DeletedAt = fromNullUnix(deletedAt)
+	secret.ExpiresAt = fromNullUnix(expiresAt)
+	if rotateEvery.Valid {
+		secret.RotateEvery = time.Duration(rotateEvery.Int64) * time.Second
+	}
+
+	return &secret, nil
+}
+
+// replaceSQLiteTags rewrites the tags of a secret inside a transaction
+func replaceSQLiteTags(ctx context.Context, tx *sql.Tx, secretID string, tags []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM secret_tags WHERE secret_id = ?`, secretID); err != nil {
+		return fmt.Errorf("failed to clear tags: %w", err)
+	}
+
+	for _, tag := range tags {
+		query := `INSERT INTO secret_tags (secret_id, tag) VALUES (?, ?) ON CONFLICT DO NOTHING`
+		if _, err := tx.ExecContext(ctx, query, secretID, tag); err != nil {
+			return fmt.Errorf("failed to link tags: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// sqliteInFolder matches the folder bound to parameter n and everything below it.
+// Unlike LIKE, substr compares case-sensitively and without wildcards.
+func sqliteInFolder(n int) string {
+	return fmt.Sprintf("(s.folder = ?%[1]d OR substr(s.folder, 1, length(?%[1]d) + 1) = ?%[1]d || '/')", n)
+}
+
+// escapeGlob escapes the GLOB wildcards in s so it matches literally
+func escapeGlob(value string) string {
+	return strings.NewReplacer("*", "[*]", "?", "[?]", "[", "[[]").Replace(value)
+}
+
+// checkSQLiteRevision fails unless a live secret is at the expected revision.
+// Write transactions hold the database lock, so the revision cannot change before they commit.
+func checkSQLiteRevision(ctx context.Context, tx *sql.Tx, id string, revision int) error {
+	var current int
+	err := tx.QueryRowContext(ctx, `SELECT revision FROM secrets WHERE id = ? AND deleted_at IS NULL`, id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("secret %w", ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	if current != revision {
+		return fmt.Errorf("secret %w", ErrRevisionMismatch)
+	}
+
+	return nil
+}
+
+// expectAffected returns ErrNotFound for what when result changed no rows
+func expectAffected(result sql.Result, what string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", what, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s %w", what, ErrNotFound)
+	}
+
+	return nil
+}
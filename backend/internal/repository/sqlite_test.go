@@ -0,0 +1,92 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"my-vault/internal/repository"
+	"my-vault/internal/repository/storetest"
+)
+
+// newTestSQLite opens an empty SQLite database in a temporary directory
+func newTestSQLite(t *testing.T) *repository.SQLiteDB {
+	t.Helper()
+
+	db, err := repository.NewSQLiteDB(filepath.Join(t.TempDir(), "vault.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteDB: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+func TestSQLiteSecretStore(t *testing.T) {
+	storetest.TestSecretStore(t, func(t *testing.T) repository.SecretStore {
+		return repository.NewSQLiteSecretRepository(newTestSQLite(t))
+	})
+}
+
+func TestSQLitePolicyStore(t *testing.T) {
+	storetest.TestPolicyStore(t, func(t *testing.T) repository.PolicyStore {
+		return repository.NewSQLitePolicyRepository(newTestSQLite(t))
+	})
+}
+
+func TestSQLiteVaultStore(t *testing.T) {
+	storetest.TestVaultStore(t, func(t *testing.T) repository.VaultStore {
+		return repository.NewSQLiteVaultRepository(newTestSQLite(t))
+	})
+}
+
+func TestSQLiteAddsMissingColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.db")
+
+	// A secrets table as created before revisions were counted
+	old, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	_, err = old.Exec(`
+		CREATE TABLE secrets (
+			id TEXT PRIMARY KEY,
+			title TEXT NOT NULL,
+			type TEXT NOT NULL,
+			encrypted_value BLOB NOT NULL,
+			fields TEXT NOT NULL DEFAULT '[]',
+			folder TEXT NOT NULL DEFAULT '',
+			version INTEGER NOT NULL DEFAULT 1,
+			updated_by TEXT NOT NULL DEFAULT 'owner',
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL,
+			deleted_at INTEGER,
+			expires_at INTEGER,
+			rotate_every INTEGER,
+			rotated_at INTEGER,
+			expiry_notified_at INTEGER,
+			rotation_notified_at INTEGER
+		);
+		INSERT INTO secrets (id, title, type, encrypted_value, created_at, updated_at)
+		VALUES ('00000000-0000-4000-8000-000000000001', 'legacy', 'secure_note', x'01', 1, 1);
+	`)
+	old.Close()
+	if err != nil {
+		t.Fatalf("creating old schema: %v", err)
+	}
+
+	db, err := repository.NewSQLiteDB(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteDB: %v", err)
+	}
+	defer db.Close()
+
+	secret, err := repository.NewSQLiteSecretRepository(db).Get(context.Background(), "00000000-0000-4000-8000-000000000001")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if secret.Title != "legacy" || secret.Revision != 1 {
+		t.Errorf("Get = %q at revision %d, want the legacy secret at revision 1", secret.Title, secret.Revision)
+	}
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+// ListTrash retrieves all soft-deleted secrets, most recently deleted first
+func (r *SQLiteSecretRepository) ListTrash(ctx context.Context) ([]*models.Secret, error) {
+	query := `
+		SELECT ` + sqliteSecretColumns + `
+		FROM secrets s
+		WHERE s.deleted_at IS NOT NULL
+		ORDER BY s.deleted_at DESC
+	`
+
+	return r.query(ctx, query)
+}
+
+// GetTrashed retrieves a soft-deleted secret by ID
+func (r *SQLiteSecretRepository) GetTrashed(ctx context.Context, id string) (*models.Secret, error) {
+	query := `SELECT ` + sqliteSecretColumns + ` FROM secrets s WHERE s.id = ? AND s.deleted_at IS NOT NULL`
+
+	secret, err := scanSQLiteSecret(r.db.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("trashed secret %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trashed secret: %w", err)
+	}
+
+	return secret, nil
+}
+
+// Restore moves a secret out of the trash
+func (r *SQLiteSecretRepository) Restore(ctx context.Context, id string) error {
+	query := `UPDATE secrets SET deleted_at = NULL, revision = revision + 1 WHERE id = ? AND deleted_at IS NOT NULL`
+
+	result, err := r.db.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore secret: %w", err)
+	}
+
+	return expectAffected(result, "trashed secret")
+}
+
+// Purge permanently removes a trashed secret together with its history
+func (r *SQLiteSecretRepository) Purge(ctx context.Context, id string) error {
+	query := `DELETE FROM secrets WHERE id = ? AND deleted_at IS NOT NULL`
+
+	result, err := r.db.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to purge secret: %w", err)
+	}
+
+	return expectAffected(result, "trashed secret")
+}
+
+// PurgeDeletedBefore permanently removes secrets trashed before cutoff.
+// It returns the IDs of the secrets removed.
+func (r *SQLiteSecretRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	query := `DELETE FROM secrets WHERE deleted_at IS NOT NULL AND deleted_at < ? RETURNING id`
+
+	rows, err := r.db.db.QueryContext(ctx, query, toUnix(cutoff))
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to purge trash: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to purge trash: %w", err)
+	}
+
+	return ids, nil
+}
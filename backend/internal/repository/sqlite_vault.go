@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"my-vault/internal/models"
+)
+
+// SQLiteVaultRepository handles SQLite operations for the vault header
+type SQLiteVaultRepository struct {
+	db *SQLiteDB
+}
+
+// NewSQLiteVaultRepository creates a new SQLite vault repository
+func NewSQLiteVaultRepository(db *SQLiteDB) *SQLiteVaultRepository {
+	return &SQLiteVaultRepository{
+		db: db,
+	}
+}
+
+// GetHeader retrieves the vault header
+func (r *SQLiteVaultRepository) GetHeader(ctx context.Context) (*models.VaultHeader, error) {
+	query := `SELECT salt, verifier, created_at FROM vault_header WHERE id = 1`
+
+	var header models.VaultHeader
+	var createdAt int64
+	err := r.db.db.QueryRowContext(ctx, query).Scan(&header.Salt, &header.Verifier, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("vault header %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vault header: %w", err)
+	}
+
+	header.CreatedAt = fromUnix(createdAt)
+	return &header, nil
+}
+
+// CreateHeader stores the vault header unless the vault is already initialized
+func (r *SQLiteVaultRepository) CreateHeader(ctx context.Context, header *models.VaultHeader) error {
+	query := `
+		INSERT INTO vault_header (id, salt, verifier, created_at)
+		VALUES (1, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	result, err := r.db.db.ExecContext(ctx, query, header.Salt, header.Verifier, toUnix(header.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to create vault header: %w", err)
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to create vault header: %w", err)
+	} else if affected == 0 {
+		return fmt.Errorf("vault header %w", ErrConflict)
+	}
+
+	return nil
+}
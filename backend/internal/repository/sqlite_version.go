@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+// UpdateWithVersion updates a secret's content, archiving the replaced content
+// in secret_versions and bumping the version number in one transaction
+func (r *SQLiteSecretRepository) UpdateWithVersion(ctx context.Context, secret *models.Secret) error {
+	fields, err := json.Marshal(fieldsOrEmpty(secret.Fields))
+	if err != nil {
+		return fmt.Errorf("failed to encode fields: %w", err)
+	}
+
+	secret.UpdatedAt = time.Now()
+
+	// Write transactions take the database lock up front, so versions are archived in order
+	err = r.db.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkSQLiteRevision(ctx, tx, secret.ID, secret.Revision); err != nil {
+			return err
+		}
+
+		archive := `
+			INSERT INTO secret_versions (secret_id, version, title, type, encrypted_value, fields, author, created_at)
+			SELECT id, version, title, type, encrypted_value, fields, updated_by, updated_at
+			FROM secrets
+			WHERE id = ?
+		`
+		if _, err := tx.ExecContext(ctx, archive, secret.ID); err != nil {
+			return fmt.Errorf("failed to archive version: %w", err)
+		}
+
+		// Reminders already sent are reset when the dates they were sent for change
+		update := `
+			UPDATE secrets
+			SET title = ?1, type = ?2, encrypted_value = ?3, fields = ?4, folder = ?5,
+				version = version + 1, revision = revision + 1, updated_by = ?6, updated_at = ?7,
+				expires_at = ?9, rotate_every = ?10, rotated_at = ?11,
+				expiry_notified_at = CASE WHEN expires_at IS ?9 THEN expiry_notified_at ELSE NULL END,
+				rotation_notified_at = CASE
+					WHEN rotate_every IS ?10 AND rotated_at IS ?11 THEN rotation_notified_at
+					ELSE NULL
+				END
+			WHERE id = ?8
+			RETURNING version, revision
+		`
+		err := tx.QueryRowContext(ctx, update,
+			secret.Title,
+			secret.Type,
+			secret.EncryptedValue,
+			string(fields),
+			secret.Folder,
+			secret.UpdatedBy,
+			toUnix(secret.UpdatedAt),
+			secret.ID,
+			toNullUnix(secret.ExpiresAt),
+			rotateEverySeconds(secret.RotateEvery),
+			toUnix(secret.RotatedAt),
+		).Scan(&secret.Version, &secret.Revision)
+		if err != nil {
+			return err
+		}
+
+		return replaceSQLiteTags(ctx, tx, secret.ID, secret.Tags)
+	})
+
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrRevisionMismatch) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	return nil
+}
+
+// ListVersions retrieves the archived versions of a secret, newest first
+func (r *SQLiteSecretRepository) ListVersions(ctx context.Context, secretID string) ([]*models.SecretVersion, error) {
+	query := `
+		SELECT secret_id, version, title, type, encrypted_value, fields, author, created_at
+		FROM secret_versions
+		WHERE secret_id = ?
+		ORDER BY version DESC
+	`
+
+	rows, err := r.db.db.QueryContext(ctx, query, secretID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*models.SecretVersion
+	for rows.Next() {
+		version, err := scanSQLiteVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan version: %w", err)
+		}
+		versions = append(versions, version)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetVersion retrieves one archived version of a secret
+func (r *SQLiteSecretRepository) GetVersion(ctx context.Context, secretID string, version int) (*models.SecretVersion, error) {
+	query := `
+		SELECT secret_id, version, title, type, encrypted_value, fields, author, created_at
+		FROM secret_versions
+		WHERE secret_id = ? AND version = ?
+	`
+
+	v, err := scanSQLiteVersion(r.db.db.QueryRowContext(ctx, query, secretID, version))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("version %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version: %w", err)
+	}
+
+	return v, nil
+}
+
+// PruneVersions deletes archived versions beyond the newest keep versions
+// or created before olderThan. Zero values disable the respective limit.
+func (r *SQLiteSecretRepository) PruneVersions(ctx context.Context, secretID string, keep int, olderThan time.Time) error {
+	if keep > 0 {
+		query := `
+			DELETE FROM secret_versions
+			WHERE secret_id = ?1 AND version NOT IN (
+				SELECT version FROM secret_versions
+				WHERE secret_id = ?1
+				ORDER BY version DESC
+				LIMIT ?2
+			)
+		`
+		if _, err := r.db.db.ExecContext(ctx, query, secretID, keep); err != nil {
+			return fmt.Errorf("failed to prune versions: %w", err)
+		}
+	}
+
+	if !olderThan.IsZero() {
+		query := `DELETE FROM secret_versions WHERE secret_id = ? AND created_at < ?`
+		if _, err := r.db.db.ExecContext(ctx, query, secretID, toUnix(olderThan)); err != nil {
+			return fmt.Errorf("failed to prune versions: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// PruneVersionsBefore deletes the archived versions of every secret created before cutoff.
+// It returns the number of versions removed.
+func (r *SQLiteSecretRepository) PruneVersionsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `DELETE FROM secret_versions WHERE created_at < ?`
+
+	result, err := r.db.db.ExecContext(ctx, query, toUnix(cutoff))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune versions: %w", err)
+	}
+
+	pruned, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune versions: %w", err)
+	}
+
+	return pruned, nil
+}
+
+// scanSQLiteVersion scans a secret_versions row
+func scanSQLiteVersion(row sqliteRow) (*models.SecretVersion, error) {
+	var version models.SecretVersion
+	var fields string
+	var createdAt int64
+	err := row.Scan(
+		&version.SecretID,
+		&version.Version,
+		&version.Title,
+		&version.Type,
+		&version.EncryptedValue,
+		&fields,
+		&version.Author,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(fields), &version.Fields); err != nil {
+		return nil, fmt.Errorf("failed to decode fields: %w", err)
+	}
+	version.CreatedAt = fromUnix(createdAt)
+
+	return &version, nil
+}
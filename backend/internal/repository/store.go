@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+// SecretStore persists secrets together with their version history, trash,
+// attachment metadata and reminder state. Values are stored as given; the
+// store never sees plaintext. Every backend must pass storetest.TestSecretStore.
+//
+// Lookups of missing records return an error wrapping ErrNotFound. Get, List,
+// Update, UpdateWithVersion, Delete and RenameFolder only see secrets outside
+// the trash; the trash methods only see secrets inside it.
+type SecretStore interface {
+	// Create assigns the secret's ID, sets its version and revision to 1 and
+	// its timestamps to now, and stores it
+	Create(ctx context.Context, secret *models.Secret) error
+	Get(ctx context.Context, id string) (*models.Secret, error)
+	// List returns the secrets matching all set filter criteria, newest first
+	// unless the filter sets another order. Ties are broken by ID, so listing
+	// with After set to the last secret of a page returns the next page.
+	List(ctx context.Context, filter models.SecretFilter) ([]*models.Secret, error)
+	// Update, UpdateWithVersion and Delete only write a secret still at the
+	// caller's revision, returning ErrRevisionMismatch otherwise. Every write
+	// bumps the revision; Update and UpdateWithVersion set the new one.
+
+	// Update stores changed metadata without recording a new version
+	Update(ctx context.Context, secret *models.Secret) error
+	// UpdateWithVersion archives the stored content as a prior version, bumps
+	// the version and stores the secret, atomically
+	UpdateWithVersion(ctx context.Context, secret *models.Secret) error
+	// Delete moves a secret to the trash
+	Delete(ctx context.Context, id string, revision int) error
+	// RenameFolder moves every secret in from, including subfolders, under
+	// to. revisions holds the ID and revision of each secret the caller
+	// checked; the rename is refused with ErrRevisionMismatch unless those are
+	// exactly the folder's live secrets at those revisions.
+	RenameFolder(ctx context.Context, from, to string, revisions map[string]int) (int64, error)
+
+	ListVersions(ctx context.Context, secretID string) ([]*models.SecretVersion, error)
+	GetVersion(ctx context.Context, secretID string, version int) (*models.SecretVersion, error)
+	PruneVersions(ctx context.Context, secretID string, keep int, olderThan time.Time) error
+	// PruneVersionsBefore deletes the archived versions of every secret created before cutoff
+	PruneVersionsBefore(ctx context.Context, cutoff time.Time) (int64, error)
+
+	ListTrash(ctx context.Context) ([]*models.Secret, error)
+	GetTrashed(ctx context.Context, id string) (*models.Secret, error)
+	Restore(ctx context.Context, id string) error
+	// Purge permanently removes a trashed secret with its history and attachment metadata
+	Purge(ctx context.Context, id string) error
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]string, error)
+
+	// CreateAttachment fails with ErrTooLarge when the secret's attachments
+	// would exceed maxTotalSize bytes; zero disables the check
+	CreateAttachment(ctx context.Context, attachment *models.Attachment, maxTotalSize int64) error
+	ListAttachments(ctx context.Context, secretID string) ([]*models.Attachment, error)
+	GetAttachment(ctx context.Context, secretID, id string) (*models.Attachment, error)
+	DeleteAttachment(ctx context.Context, secretID, id string) error
+	AttachmentsSize(ctx context.Context, secretID string) (int64, error)
+
+	// Reminders already sent are re-armed when UpdateWithVersion changes the
+	// expiry date, the rotation interval or the rotation time
+	ListExpiryReminders(ctx context.Context, cutoff time.Time) ([]*models.Secret, error)
+	ListRotationReminders(ctx context.Context, now time.Time) ([]*models.Secret, error)
+	MarkExpiryNotified(ctx context.Context, id string, at time.Time) error
+	MarkRotationNotified(ctx context.Context, id string, at time.Time) error
+}
+
+// PolicyStore persists authorization policies and the subjects they are attached to.
+// Every backend must pass storetest.TestPolicyStore.
+type PolicyStore interface {
+	// Create assigns the policy's ID and timestamps; a taken name returns ErrConflict
+	Create(ctx context.Context, policy *models.Policy) error
+	Get(ctx context.Context, id string) (*models.Policy, error)
+	// List returns all policies ordered by name
+	List(ctx context.Context) ([]*models.Policy, error)
+	// ListForSubjects returns the policies attached to any of subjects, ordered by name
+	ListForSubjects(ctx context.Context, subjects []models.PolicySubject) ([]*models.Policy, error)
+	Update(ctx context.Context, policy *models.Policy) error
+	Delete(ctx context.Context, id string) error
+}
+
+// VaultStore persists the vault header, which holds what is needed to derive
+// and verify the vault key. Every backend must pass storetest.TestVaultStore.
+type VaultStore interface {
+	// GetHeader returns ErrNotFound until the vault has been initialized
+	GetHeader(ctx context.Context) (*models.VaultHeader, error)
+	// CreateHeader initializes the vault; it returns ErrConflict when already initialized
+	CreateHeader(ctx context.Context, header *models.VaultHeader) error
+}
+
+// KeyedStore is implemented by vault stores whose backend encrypts everything
+// but the header with the vault key. The vault service unlocks the store once
+// the master password is verified and locks it again with the vault; until
+// then the backend's other stores return ErrLocked.
+type KeyedStore interface {
+	Unlock(ctx context.Context, key []byte) error
+	Lock()
+}
+
+var (
+	_ SecretStore = (*SecretRepository)(nil)
+	_ PolicyStore = (*PolicyRepository)(nil)
+	_ VaultStore  = (*VaultRepository)(nil)
+
+	_ SecretStore = (*SQLiteSecretRepository)(nil)
+	_ PolicyStore = (*SQLitePolicyRepository)(nil)
+	_ VaultStore  = (*SQLiteVaultRepository)(nil)
+
+	_ SecretStore = (*MemorySecretRepository)(nil)
+	_ PolicyStore = (*MemoryPolicyRepository)(nil)
+	_ VaultStore  = (*MemoryVaultRepository)(nil)
+	_ KeyedStore  = (*MemoryVaultRepository)(nil)
+)
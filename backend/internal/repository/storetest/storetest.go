@@ -0,0 +1,1252 @@
+// Package storetest is the conformance suite every storage backend must pass.
+//
+// A backend's test calls the suite with a constructor returning an empty store:
+//
+//	func TestSecretStore(t *testing.T) {
+//		storetest.TestSecretStore(t, func(t *testing.T) repository.SecretStore {
+//			return newEmptyStore(t)
+//		})
+//	}
+package storetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"my-vault/internal/models"
+	"my-vault/internal/repository"
+)
+
+// TestSecretStore runs the SecretStore conformance tests. newStore must
+// return an empty store on every call.
+func TestSecretStore(t *testing.T, newStore func(t *testing.T) repository.SecretStore) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store repository.SecretStore)
+	}{
+		{"CreateGet", testCreateGet},
+		{"GetMissing", testGetMissing},
+		{"List", testList},
+		{"ListPages", testListPages},
+		{"Update", testUpdate},
+		{"UpdateWithVersion", testUpdateWithVersion},
+		{"Revisions", testRevisions},
+		{"PruneVersions", testPruneVersions},
+		{"PruneVersionsBefore", testPruneVersionsBefore},
+		{"RenameFolder", testRenameFolder},
+		{"Trash", testTrash},
+		{"PurgeDeletedBefore", testPurgeDeletedBefore},
+		{"Attachments", testAttachments},
+		{"ExpiryReminders", testExpiryReminders},
+		{"RotationReminders", testRotationReminders},
+		{"Search", testSearch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+// TestPolicyStore runs the PolicyStore conformance tests. newStore must
+// return an empty store on every call.
+func TestPolicyStore(t *testing.T, newStore func(t *testing.T) repository.PolicyStore) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store repository.PolicyStore)
+	}{
+		{"CRUD", testPolicyCRUD},
+		{"NameConflict", testPolicyNameConflict},
+		{"ListForSubjects", testPolicyListForSubjects},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+// TestVaultStore runs the VaultStore conformance tests. newStore must
+// return an uninitialized store on every call.
+func TestVaultStore(t *testing.T, newStore func(t *testing.T) repository.VaultStore) {
+	ctx := context.Background()
+	store := newStore(t)
+
+	if _, err := store.GetHeader(ctx); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("GetHeader on new store: got %v, want ErrNotFound", err)
+	}
+
+	header := &models.VaultHeader{
+		Salt:      []byte("0123456789abcdef0123456789abcdef"),
+		Verifier:  []byte{0, 1, 2, 3, 254, 255},
+		CreatedAt: time.Now(),
+	}
+	if err := store.CreateHeader(ctx, header); err != nil {
+		t.Fatalf("CreateHeader: %v", err)
+	}
+
+	got, err := store.GetHeader(ctx)
+	if err != nil {
+		t.Fatalf("GetHeader: %v", err)
+	}
+	if !slices.Equal(got.Salt, header.Salt) || !slices.Equal(got.Verifier, header.Verifier) {
+		t.Errorf("GetHeader = %+v, want %+v", got, header)
+	}
+	assertTime(t, "CreatedAt", got.CreatedAt, header.CreatedAt)
+
+	other := &models.VaultHeader{Salt: []byte("other"), Verifier: []byte("other"), CreatedAt: time.Now()}
+	if err := store.CreateHeader(ctx, other); !errors.Is(err, repository.ErrConflict) {
+		t.Fatalf("second CreateHeader: got %v, want ErrConflict", err)
+	}
+	if got, err := store.GetHeader(ctx); err != nil || !slices.Equal(got.Salt, header.Salt) {
+		t.Errorf("header replaced by second CreateHeader: %+v, %v", got, err)
+	}
+}
+
+func testCreateGet(t *testing.T, store repository.SecretStore) {
+	expires := time.Now().Add(48 * time.Hour)
+
+	secret := &models.Secret{
+		Title:          "Stripe",
+		Type:           "api_token",
+		EncryptedValue: []byte{0, 1, 2, 255},
+		Fields: []models.SecretField{
+			{Name: "region", Value: "eu-west-1"},
+			{Name: "pin", Concealed: true, EncryptedValue: []byte{9, 8, 7}},
+		},
+		Folder:      "prod/payments",
+		Tags:        []string{"payments", "prod"},
+		UpdatedBy:   "user:alice",
+		ExpiresAt:   &expires,
+		RotateEvery: 90 * 24 * time.Hour,
+	}
+	before := time.Now()
+	create(t, store, secret)
+
+	if secret.ID == "" {
+		t.Fatal("Create did not assign an ID")
+	}
+	if secret.Version != 1 {
+		t.Errorf("Version = %d, want 1", secret.Version)
+	}
+	if secret.CreatedAt.Before(before.Add(-time.Second)) || !secret.UpdatedAt.Equal(secret.CreatedAt) || !secret.RotatedAt.Equal(secret.CreatedAt) {
+		t.Errorf("timestamps not set to now: created %v, updated %v, rotated %v", secret.CreatedAt, secret.UpdatedAt, secret.RotatedAt)
+	}
+
+	got := get(t, store, secret.ID)
+	assertSecret(t, got, secret)
+	if got.DeletedAt != nil {
+		t.Errorf("DeletedAt = %v, want nil", got.DeletedAt)
+	}
+
+	// IDs are unique
+	other := newSecret("Other", "")
+	create(t, store, other)
+	if other.ID == secret.ID {
+		t.Errorf("Create reused ID %s", secret.ID)
+	}
+}
+
+func testGetMissing(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+	id := "00000000-0000-4000-8000-000000000000"
+
+	if _, err := store.Get(ctx, id); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Get: got %v, want ErrNotFound", err)
+	}
+	if err := store.Update(ctx, &models.Secret{ID: id, Title: "x", Type: "secure_note", EncryptedValue: []byte{1}}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Update: got %v, want ErrNotFound", err)
+	}
+	if err := store.UpdateWithVersion(ctx, &models.Secret{ID: id, Title: "x", Type: "secure_note", EncryptedValue: []byte{1}}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("UpdateWithVersion: got %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, id, 1); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Delete: got %v, want ErrNotFound", err)
+	}
+	if _, err := store.GetVersion(ctx, id, 1); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetVersion: got %v, want ErrNotFound", err)
+	}
+}
+
+func testList(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+	soon := time.Now().Add(24 * time.Hour)
+	later := time.Now().Add(30 * 24 * time.Hour)
+
+	root := newSecret("root", "")
+	prod := newSecret("prod", "prod")
+	prod.Tags = []string{"prod"}
+	prod.ExpiresAt = &soon
+	payments := newSecret("payments", "prod/payments")
+	payments.Tags = []string{"payments", "prod"}
+	payments.Fields = []models.SecretField{{Name: "region", Value: "eu-west-1"}, {Name: "team", Value: "billing"}}
+	payments.ExpiresAt = &later
+	sibling := newSecret("sibling", "production")
+	sibling.Fields = []models.SecretField{{Name: "region", Value: "us-east-1"}}
+	concealed := newSecret("concealed", "")
+	concealed.Fields = []models.SecretField{{Name: "region", Concealed: true, EncryptedValue: []byte("eu-west-1")}}
+	trashed := newSecret("trashed", "prod")
+	trashed.Tags = []string{"prod"}
+
+	for _, secret := range []*models.Secret{root, prod, payments, sibling, concealed, trashed} {
+		create(t, store, secret)
+	}
+	if err := store.Delete(ctx, trashed.ID, trashed.Revision); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter models.SecretFilter
+		want   []*models.Secret
+	}{
+		{"all", models.SecretFilter{}, []*models.Secret{root, prod, payments, sibling, concealed}},
+		{"tag", models.SecretFilter{Tag: "prod"}, []*models.Secret{prod, payments}},
+		{"unknown tag", models.SecretFilter{Tag: "none"}, nil},
+		{"folder prefix", models.SecretFilter{FolderPrefix: "prod"}, []*models.Secret{prod, payments}},
+		{"subfolder", models.SecretFilter{FolderPrefix: "prod/payments"}, []*models.Secret{payments}},
+		{"field", models.SecretFilter{Fields: map[string]string{"region": "eu-west-1"}}, []*models.Secret{payments}},
+		{"fields", models.SecretFilter{Fields: map[string]string{"region": "eu-west-1", "team": "ops"}}, nil},
+		{"expiring", models.SecretFilter{ExpiringBefore: ptr(time.Now().Add(7 * 24 * time.Hour))}, []*models.Secret{prod}},
+		{"combined", models.SecretFilter{Tag: "prod", FolderPrefix: "prod/payments", ExpiringBefore: &later}, []*models.Secret{payments}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			assertIDs(t, got, tt.want)
+		})
+	}
+}
+
+func testListPages(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+
+	var all []*models.Secret
+	for _, title := range []string{"delta", "alpha", "echo", "bravo", "charlie"} {
+		secret := newSecret(title, "")
+		if title == "bravo" || title == "echo" {
+			secret.Type = "login"
+		}
+		create(t, store, secret)
+		all = append(all, secret)
+	}
+	// Keep clear of the timestamp precision of the backends
+	time.Sleep(time.Millisecond)
+	between := time.Now()
+	time.Sleep(time.Millisecond)
+	alphabet := newSecret("alphabet", "")
+	create(t, store, alphabet)
+	all = append(all, alphabet)
+
+	// Touching a secret moves it to the front when sorting by update time
+	delta := all[0]
+	delta.Folder = "touched"
+	if err := store.Update(ctx, delta); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	filters := []struct {
+		name   string
+		filter models.SecretFilter
+		want   []*models.Secret
+	}{
+		{"type", models.SecretFilter{Type: "login"}, []*models.Secret{all[2], all[3]}},
+		{"title prefix", models.SecretFilter{TitlePrefix: "alpha"}, []*models.Secret{all[1], alphabet}},
+		{"title prefix is literal", models.SecretFilter{TitlePrefix: "al%"}, nil},
+		{"title prefix is case-sensitive", models.SecretFilter{TitlePrefix: "Alpha"}, nil},
+		{"created after", models.SecretFilter{CreatedAfter: &between}, []*models.Secret{alphabet}},
+		{"created before", models.SecretFilter{CreatedBefore: &between, Type: "login"}, []*models.Secret{all[2], all[3]}},
+		{"updated after", models.SecretFilter{UpdatedAfter: &between}, []*models.Secret{alphabet, delta}},
+		{"updated before", models.SecretFilter{UpdatedBefore: &between, TitlePrefix: "d"}, nil},
+	}
+	for _, tt := range filters {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			assertIDs(t, got, tt.want)
+		})
+	}
+
+	byTitle, err := store.List(ctx, models.SecretFilter{Sort: models.SortByTitle, Ascending: true})
+	if err != nil {
+		t.Fatalf("List by title: %v", err)
+	}
+	if got, want := titles(byTitle), []string{"alpha", "alphabet", "bravo", "charlie", "delta", "echo"}; !slices.Equal(got, want) {
+		t.Errorf("List by title = %v, want %v", got, want)
+	}
+
+	if latest, err := store.List(ctx, models.SecretFilter{Sort: models.SortByUpdatedAt, Limit: 1}); err != nil {
+		t.Fatalf("List by update time: %v", err)
+	} else if len(latest) != 1 || latest[0].ID != delta.ID {
+		t.Errorf("most recently updated = %v, want delta", titles(latest))
+	}
+
+	// Paging through any order yields the full listing exactly once
+	for _, sort := range []models.SecretSort{models.SortByCreatedAt, models.SortByUpdatedAt, models.SortByTitle} {
+		for _, ascending := range []bool{false, true} {
+			filter := models.SecretFilter{Sort: sort, Ascending: ascending}
+			full, err := store.List(ctx, filter)
+			if err != nil {
+				t.Fatalf("List(%s): %v", sort, err)
+			}
+
+			var paged []*models.Secret
+			filter.Limit = 2
+			for range len(full) {
+				page, err := store.List(ctx, filter)
+				if err != nil {
+					t.Fatalf("List(%s) page: %v", sort, err)
+				}
+				if len(page) > filter.Limit {
+					t.Fatalf("List(%s) page has %d secrets, limit %d", sort, len(page), filter.Limit)
+				}
+				if len(page) == 0 {
+					break
+				}
+				paged = append(paged, page...)
+				filter.After = models.NewSecretCursor(page[len(page)-1], sort)
+			}
+
+			if got, want := ids(paged), ids(full); !slices.Equal(got, want) {
+				t.Errorf("pages of List(%s, ascending=%v) = %v, want %v", sort, ascending, titles(paged), titles(full))
+			}
+		}
+	}
+}
+
+func testSearch(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+
+	search := func(t *testing.T, words ...string) []*models.Secret {
+		t.Helper()
+		list, err := store.List(ctx, models.SecretFilter{SearchWords: words})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		return list
+	}
+
+	stripe := newSecret("Stripe Live", "")
+	stripe.Fields = []models.SecretField{{Name: "Region", Value: "EU-West"}}
+	paypal := newSecret("PayPal", "")
+	paypal.Fields = []models.SecretField{{Name: "pin", Concealed: true, EncryptedValue: []byte("ciphertext of west")}}
+	umlaut := newSecret("Übersicht", "")
+	like := newSecret("100% off", "")
+	for _, secret := range []*models.Secret{stripe, paypal, umlaut, like} {
+		create(t, store, secret)
+	}
+
+	// Words match anywhere in the title, field names and plain field values, ignoring case
+	assertIDs(t, search(t, "ripe"), []*models.Secret{stripe})
+	assertIDs(t, search(t, "pay"), []*models.Secret{paypal})
+	assertIDs(t, search(t, "region", "west"), []*models.Secret{stripe})
+	assertIDs(t, search(t, "pin"), []*models.Secret{paypal})
+	assertIDs(t, search(t, "übersicht"), []*models.Secret{umlaut})
+	assertIDs(t, search(t, "stripe", "none"), nil)
+
+	// Concealed values and secret values are never matched
+	assertIDs(t, search(t, "ciphertext"), nil)
+
+	// Wildcards in the words match literally
+	assertIDs(t, search(t, "0%"), []*models.Secret{like})
+	assertIDs(t, search(t, "_"), nil)
+
+	// Trashed secrets are not found
+	if err := store.Delete(ctx, stripe.ID, stripe.Revision); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	assertIDs(t, search(t, "ripe"), nil)
+}
+
+func testUpdate(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+
+	secret := newSecret("old", "a")
+	secret.Tags = []string{"one", "two"}
+	create(t, store, secret)
+
+	secret.Title = "new"
+	secret.Folder = "b/c"
+	secret.Tags = []string{"three"}
+	secret.EncryptedValue = []byte("changed")
+	if err := store.Update(ctx, secret); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got := get(t, store, secret.ID)
+	assertSecret(t, got, secret)
+	if got.Version != 1 {
+		t.Errorf("Version = %d, want 1 after Update", got.Version)
+	}
+	if versions, err := store.ListVersions(ctx, secret.ID); err != nil || len(versions) != 0 {
+		t.Errorf("ListVersions after Update = %d versions, %v; want none", len(versions), err)
+	}
+
+	// Tags no longer used by any secret do not match
+	if list, err := store.List(ctx, models.SecretFilter{Tag: "one"}); err != nil || len(list) != 0 {
+		t.Errorf("List by removed tag = %d secrets, %v; want none", len(list), err)
+	}
+}
+
+func testUpdateWithVersion(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+
+	secret := newSecret("v1", "")
+	secret.EncryptedValue = []byte("value 1")
+	secret.Fields = []models.SecretField{{Name: "env", Value: "dev"}}
+	secret.UpdatedBy = "user:alice"
+	create(t, store, secret)
+	created := get(t, store, secret.ID)
+
+	for i, author := range []string{"user:bob", "token:ci"} {
+		secret.Title = "v" + string(rune('2'+i))
+		secret.EncryptedValue = []byte("value " + string(rune('2'+i)))
+		secret.Fields = nil
+		secret.UpdatedBy = author
+		if err := store.UpdateWithVersion(ctx, secret); err != nil {
+			t.Fatalf("UpdateWithVersion: %v", err)
+		}
+		if secret.Version != i+2 {
+			t.Errorf("Version = %d, want %d", secret.Version, i+2)
+		}
+	}
+
+	got := get(t, store, secret.ID)
+	assertSecret(t, got, secret)
+
+	versions, err := store.ListVersions(ctx, secret.ID)
+	if err != nil {
+		t.Fatalf("ListVersions: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 2 || versions[1].Version != 1 {
+		t.Fatalf("ListVersions = %v, want versions 2 and 1, newest first", versionNumbers(versions))
+	}
+
+	first := versions[1]
+	if first.SecretID != secret.ID || first.Title != "v1" || string(first.EncryptedValue) != "value 1" || first.Author != "user:alice" {
+		t.Errorf("version 1 = %+v, want the created content", first)
+	}
+	if len(first.Fields) != 1 || first.Fields[0].Name != "env" {
+		t.Errorf("version 1 fields = %+v, want the created fields", first.Fields)
+	}
+	assertTime(t, "version 1 CreatedAt", first.CreatedAt, created.UpdatedAt)
+
+	v2, err := store.GetVersion(ctx, secret.ID, 2)
+	if err != nil {
+		t.Fatalf("GetVersion: %v", err)
+	}
+	if v2.Title != "v2" || string(v2.EncryptedValue) != "value 2" || v2.Author != "user:bob" {
+		t.Errorf("GetVersion(2) = %+v", v2)
+	}
+
+	// The current version is not part of the history
+	if _, err := store.GetVersion(ctx, secret.ID, 3); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetVersion(current): got %v, want ErrNotFound", err)
+	}
+}
+
+func testRevisions(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+
+	secret := newSecret("stripe", "prod")
+	create(t, store, secret)
+	if secret.Revision != 1 {
+		t.Fatalf("Create set revision %d, want 1", secret.Revision)
+	}
+
+	// A writer holding an older revision is refused and changes nothing
+	stale := get(t, store, secret.ID)
+	secret.Title = "stripe live"
+	if err := store.Update(ctx, secret); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if secret.Revision != 2 {
+		t.Errorf("Update set revision %d, want 2", secret.Revision)
+	}
+
+	stale.Title = "overwritten"
+	if err := store.Update(ctx, stale); !errors.Is(err, repository.ErrRevisionMismatch) {
+		t.Errorf("stale Update: got %v, want ErrRevisionMismatch", err)
+	}
+	if err := store.UpdateWithVersion(ctx, stale); !errors.Is(err, repository.ErrRevisionMismatch) {
+		t.Errorf("stale UpdateWithVersion: got %v, want ErrRevisionMismatch", err)
+	}
+	if err := store.Delete(ctx, stale.ID, stale.Revision); !errors.Is(err, repository.ErrRevisionMismatch) {
+		t.Errorf("stale Delete: got %v, want ErrRevisionMismatch", err)
+	}
+	if got := get(t, store, secret.ID); got.Title != "stripe live" || got.Revision != 2 || got.Version != 1 {
+		t.Errorf("after stale writes got %q revision %d version %d, want the first update at revision 2", got.Title, got.Revision, got.Version)
+	}
+	if versions, err := store.ListVersions(ctx, secret.ID); err != nil || len(versions) != 0 {
+		t.Errorf("ListVersions = %d, %v; want no versions archived by a refused write", len(versions), err)
+	}
+
+	if err := store.UpdateWithVersion(ctx, secret); err != nil {
+		t.Fatalf("UpdateWithVersion: %v", err)
+	}
+	if secret.Revision != 3 {
+		t.Errorf("UpdateWithVersion set revision %d, want 3", secret.Revision)
+	}
+
+	// Folder renames count as writes too
+	if _, err := store.RenameFolder(ctx, "prod", "live", map[string]int{secret.ID: 3}); err != nil {
+		t.Fatalf("RenameFolder: %v", err)
+	}
+	if got := get(t, store, secret.ID); got.Revision != 4 {
+		t.Errorf("revision after RenameFolder = %d, want 4", got.Revision)
+	}
+	if err := store.Delete(ctx, secret.ID, secret.Revision); !errors.Is(err, repository.ErrRevisionMismatch) {
+		t.Errorf("Delete before RenameFolder: got %v, want ErrRevisionMismatch", err)
+	}
+	if err := store.Delete(ctx, secret.ID, 4); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	// Restoring from the trash is a write, so a copy read before the delete stays stale
+	trashed, err := store.GetTrashed(ctx, secret.ID)
+	if err != nil {
+		t.Fatalf("GetTrashed: %v", err)
+	}
+	if err := store.Restore(ctx, secret.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	restored := get(t, store, secret.ID)
+	if restored.Revision != trashed.Revision+1 {
+		t.Errorf("revision after Restore = %d, want %d", restored.Revision, trashed.Revision+1)
+	}
+	if err := store.Delete(ctx, secret.ID, 4); !errors.Is(err, repository.ErrRevisionMismatch) {
+		t.Errorf("Delete before Restore: got %v, want ErrRevisionMismatch", err)
+	}
+	trashed.Title = "overwritten"
+	if err := store.Update(ctx, trashed); !errors.Is(err, repository.ErrRevisionMismatch) {
+		t.Errorf("Update before Restore: got %v, want ErrRevisionMismatch", err)
+	}
+	if err := store.Delete(ctx, secret.ID, restored.Revision); err != nil {
+		t.Fatalf("Delete after Restore: %v", err)
+	}
+}
+
+func testPruneVersions(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+
+	secret := newSecret("pruned", "")
+	create(t, store, secret)
+	for i := 0; i < 4; i++ {
+		if err := store.UpdateWithVersion(ctx, secret); err != nil {
+			t.Fatalf("UpdateWithVersion: %v", err)
+		}
+	}
+
+	if err := store.PruneVersions(ctx, secret.ID, 2, time.Time{}); err != nil {
+		t.Fatalf("PruneVersions: %v", err)
+	}
+	versions, err := store.ListVersions(ctx, secret.ID)
+	if err != nil {
+		t.Fatalf("ListVersions: %v", err)
+	}
+	if got := versionNumbers(versions); !slices.Equal(got, []int{4, 3}) {
+		t.Errorf("versions after keeping 2 = %v, want [4 3]", got)
+	}
+
+	if err := store.PruneVersions(ctx, secret.ID, 0, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PruneVersions: %v", err)
+	}
+	if versions, err := store.ListVersions(ctx, secret.ID); err != nil || len(versions) != 0 {
+		t.Errorf("versions after age limit = %v, %v; want none", versionNumbers(versions), err)
+	}
+}
+
+func testPruneVersionsBefore(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+
+	// A version dates from when its content was written, not from when it was archived
+	old := newSecret("old history", "")
+	create(t, store, old)
+	if err := store.UpdateWithVersion(ctx, old); err != nil {
+		t.Fatalf("UpdateWithVersion: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	cutoff := time.Now()
+	time.Sleep(20 * time.Millisecond)
+
+	recent := newSecret("recent history", "")
+	create(t, store, recent)
+	for _, secret := range []*models.Secret{old, old, recent} {
+		if err := store.UpdateWithVersion(ctx, secret); err != nil {
+			t.Fatalf("UpdateWithVersion: %v", err)
+		}
+	}
+
+	pruned, err := store.PruneVersionsBefore(ctx, cutoff)
+	if err != nil {
+		t.Fatalf("PruneVersionsBefore: %v", err)
+	}
+	if pruned != 2 {
+		t.Errorf("PruneVersionsBefore = %d, want 2", pruned)
+	}
+
+	versions, err := store.ListVersions(ctx, old.ID)
+	if err != nil {
+		t.Fatalf("ListVersions: %v", err)
+	}
+	if got := versionNumbers(versions); !slices.Equal(got, []int{3}) {
+		t.Errorf("versions after pruning = %v, want [3]", got)
+	}
+	if versions, err := store.ListVersions(ctx, recent.ID); err != nil || len(versions) != 1 {
+		t.Errorf("versions of a recently changed secret = %v, %v; want one", versionNumbers(versions), err)
+	}
+}
+
+func testRenameFolder(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+
+	folder := newSecret("folder", "prod")
+	nested := newSecret("nested", "prod/db")
+	sibling := newSecret("sibling", "production")
+	like := newSecret("like", "pr_d")
+	for _, secret := range []*models.Secret{folder, nested, sibling, like} {
+		create(t, store, secret)
+	}
+
+	// The rename is refused unless the caller checked exactly the folder's secrets, as they are now
+	checked := map[string]int{folder.ID: folder.Revision, nested.ID: nested.Revision}
+	for name, revisions := range map[string]map[string]int{
+		"stale revision": {folder.ID: folder.Revision, nested.ID: nested.Revision + 1},
+		"missing secret": {folder.ID: folder.Revision},
+		"extra secret":   {folder.ID: folder.Revision, nested.ID: nested.Revision, sibling.ID: sibling.Revision},
+	} {
+		if _, err := store.RenameFolder(ctx, "prod", "live/prod", revisions); !errors.Is(err, repository.ErrRevisionMismatch) {
+			t.Errorf("RenameFolder with a %s: got %v, want ErrRevisionMismatch", name, err)
+		}
+	}
+	if got := get(t, store, nested.ID); got.Folder != "prod/db" || got.Revision != nested.Revision {
+		t.Errorf("after refused renames got %q at revision %d, want it unchanged", got.Folder, got.Revision)
+	}
+
+	count, err := store.RenameFolder(ctx, "prod", "live/prod", checked)
+	if err != nil {
+		t.Fatalf("RenameFolder: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("RenameFolder moved %d secrets, want 2", count)
+	}
+
+	for secret, want := range map[*models.Secret]string{folder: "live/prod", nested: "live/prod/db", sibling: "production", like: "pr_d"} {
+		if got := get(t, store, secret.ID); got.Folder != want {
+			t.Errorf("%s folder = %q, want %q", secret.Title, got.Folder, want)
+		}
+	}
+	if got := get(t, store, nested.ID); got.Revision != nested.Revision+1 {
+		t.Errorf("revision after RenameFolder = %d, want %d", got.Revision, nested.Revision+1)
+	}
+
+	// Wildcards in the source folder match literally
+	if count, err := store.RenameFolder(ctx, "pr_d", "x", map[string]int{like.ID: like.Revision}); err != nil || count != 1 {
+		t.Errorf("RenameFolder(pr_d) = %d, %v; want 1", count, err)
+	}
+}
+
+func testTrash(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+
+	kept := newSecret("kept", "")
+	first := newSecret("first", "")
+	second := newSecret("second", "")
+	for _, secret := range []*models.Secret{kept, first, second} {
+		create(t, store, secret)
+	}
+	if err := store.UpdateWithVersion(ctx, second); err != nil {
+		t.Fatalf("UpdateWithVersion: %v", err)
+	}
+
+	before := time.Now()
+	for _, secret := range []*models.Secret{first, second} {
+		if err := store.Delete(ctx, secret.ID, secret.Revision); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := store.Get(ctx, first.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Get of trashed secret: got %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, first.ID, first.Revision); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Delete of trashed secret: got %v, want ErrNotFound", err)
+	}
+	if _, err := store.GetTrashed(ctx, kept.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetTrashed of live secret: got %v, want ErrNotFound", err)
+	}
+
+	trash, err := store.ListTrash(ctx)
+	if err != nil {
+		t.Fatalf("ListTrash: %v", err)
+	}
+	if len(trash) != 2 || trash[0].ID != second.ID || trash[1].ID != first.ID {
+		t.Errorf("ListTrash = %v, want [second first]", titles(trash))
+	}
+
+	trashed, err := store.GetTrashed(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("GetTrashed: %v", err)
+	}
+	if trashed.DeletedAt == nil || trashed.DeletedAt.Before(before.Add(-time.Second)) {
+		t.Errorf("DeletedAt = %v, want about %v", trashed.DeletedAt, before)
+	}
+
+	if err := store.Restore(ctx, first.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got := get(t, store, first.ID); got.DeletedAt != nil {
+		t.Errorf("DeletedAt after Restore = %v, want nil", got.DeletedAt)
+	}
+	if err := store.Restore(ctx, first.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Restore of live secret: got %v, want ErrNotFound", err)
+	}
+
+	if err := store.Purge(ctx, kept.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Purge of live secret: got %v, want ErrNotFound", err)
+	}
+	if err := store.UpdateWithVersion(ctx, second); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("UpdateWithVersion of trashed secret: got %v, want ErrNotFound", err)
+	}
+	if err := store.Purge(ctx, second.ID); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if _, err := store.GetTrashed(ctx, second.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetTrashed after Purge: got %v, want ErrNotFound", err)
+	}
+	if err := store.Purge(ctx, second.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("second Purge: got %v, want ErrNotFound", err)
+	}
+	if versions, err := store.ListVersions(ctx, second.ID); err != nil || len(versions) != 0 {
+		t.Errorf("ListVersions after Purge = %v, %v; want none", versionNumbers(versions), err)
+	}
+}
+
+func testPurgeDeletedBefore(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+
+	old := newSecret("old", "")
+	recent := newSecret("recent", "")
+	live := newSecret("live", "")
+	for _, secret := range []*models.Secret{old, recent, live} {
+		create(t, store, secret)
+	}
+
+	if err := store.Delete(ctx, old.ID, old.Revision); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	cutoff := time.Now()
+	time.Sleep(20 * time.Millisecond)
+	if err := store.Delete(ctx, recent.ID, recent.Revision); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	purged, err := store.PurgeDeletedBefore(ctx, cutoff)
+	if err != nil {
+		t.Fatalf("PurgeDeletedBefore: %v", err)
+	}
+	if !slices.Equal(purged, []string{old.ID}) {
+		t.Errorf("PurgeDeletedBefore = %v, want [%s]", purged, old.ID)
+	}
+
+	trash, err := store.ListTrash(ctx)
+	if err != nil {
+		t.Fatalf("ListTrash: %v", err)
+	}
+	assertIDs(t, trash, []*models.Secret{recent})
+	get(t, store, live.ID)
+}
+
+func testAttachments(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+
+	secret := newSecret("with attachments", "")
+	other := newSecret("other", "")
+	create(t, store, secret)
+	create(t, store, other)
+
+	now := time.Now()
+	first := &models.Attachment{ID: "7f6e4c1a-0b1e-4d5c-9a3b-000000000001", SecretID: secret.ID, EncryptedName: []byte("a"), Size: 100, CreatedBy: "user:alice", CreatedAt: now}
+	second := &models.Attachment{ID: "7f6e4c1a-0b1e-4d5c-9a3b-000000000002", SecretID: secret.ID, EncryptedName: []byte("b"), Size: 23, CreatedBy: "user:bob", CreatedAt: now.Add(time.Second)}
+	foreign := &models.Attachment{ID: "7f6e4c1a-0b1e-4d5c-9a3b-000000000003", SecretID: other.ID, EncryptedName: []byte("c"), Size: 7, CreatedBy: "user:bob", CreatedAt: now}
+	// The total size limit applies per secret and may be met exactly
+	for _, attachment := range []*models.Attachment{second, first, foreign} {
+		if err := store.CreateAttachment(ctx, attachment, 123); err != nil {
+			t.Fatalf("CreateAttachment: %v", err)
+		}
+	}
+
+	list, err := store.ListAttachments(ctx, secret.ID)
+	if err != nil {
+		t.Fatalf("ListAttachments: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != first.ID || list[1].ID != second.ID {
+		t.Fatalf("ListAttachments = %d attachments, want first and second, oldest first", len(list))
+	}
+
+	got, err := store.GetAttachment(ctx, secret.ID, first.ID)
+	if err != nil {
+		t.Fatalf("GetAttachment: %v", err)
+	}
+	if got.SecretID != secret.ID || string(got.EncryptedName) != "a" || got.Size != 100 || got.CreatedBy != "user:alice" {
+		t.Errorf("GetAttachment = %+v, want %+v", got, first)
+	}
+	assertTime(t, "attachment CreatedAt", got.CreatedAt, first.CreatedAt)
+
+	// Attachments are scoped to their secret
+	if _, err := store.GetAttachment(ctx, secret.ID, foreign.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetAttachment of another secret's attachment: got %v, want ErrNotFound", err)
+	}
+	if err := store.DeleteAttachment(ctx, secret.ID, foreign.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("DeleteAttachment of another secret's attachment: got %v, want ErrNotFound", err)
+	}
+
+	if size, err := store.AttachmentsSize(ctx, secret.ID); err != nil || size != 123 {
+		t.Errorf("AttachmentsSize = %d, %v; want 123", size, err)
+	}
+
+	over := &models.Attachment{ID: "7f6e4c1a-0b1e-4d5c-9a3b-000000000004", SecretID: secret.ID, EncryptedName: []byte("d"), Size: 1, CreatedBy: "user:bob", CreatedAt: now}
+	if err := store.CreateAttachment(ctx, over, 123); !errors.Is(err, repository.ErrTooLarge) {
+		t.Errorf("CreateAttachment over the total size: got %v, want ErrTooLarge", err)
+	}
+	if _, err := store.GetAttachment(ctx, secret.ID, over.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetAttachment of a refused attachment: got %v, want ErrNotFound", err)
+	}
+
+	if err := store.DeleteAttachment(ctx, secret.ID, first.ID); err != nil {
+		t.Fatalf("DeleteAttachment: %v", err)
+	}
+	if _, err := store.GetAttachment(ctx, secret.ID, first.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetAttachment after delete: got %v, want ErrNotFound", err)
+	}
+	if size, err := store.AttachmentsSize(ctx, secret.ID); err != nil || size != 23 {
+		t.Errorf("AttachmentsSize after delete = %d, %v; want 23", size, err)
+	}
+
+	// Purging a secret removes its attachment metadata
+	if err := store.Delete(ctx, secret.ID, secret.Revision); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.Purge(ctx, secret.ID); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if list, err := store.ListAttachments(ctx, secret.ID); err != nil || len(list) != 0 {
+		t.Errorf("ListAttachments after Purge = %d, %v; want none", len(list), err)
+	}
+	if size, err := store.AttachmentsSize(ctx, "00000000-0000-4000-8000-000000000000"); err != nil || size != 0 {
+		t.Errorf("AttachmentsSize of unknown secret = %d, %v; want 0", size, err)
+	}
+}
+
+func testExpiryReminders(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+	now := time.Now()
+
+	expired := newSecret("expired", "")
+	expired.ExpiresAt = ptr(now.Add(-time.Hour))
+	soon := newSecret("soon", "")
+	soon.ExpiresAt = ptr(now.Add(24 * time.Hour))
+	later := newSecret("later", "")
+	later.ExpiresAt = ptr(now.Add(30 * 24 * time.Hour))
+	never := newSecret("never", "")
+	trashed := newSecret("trashed", "")
+	trashed.ExpiresAt = ptr(now.Add(-time.Hour))
+	for _, secret := range []*models.Secret{expired, soon, later, never, trashed} {
+		create(t, store, secret)
+	}
+	if err := store.Delete(ctx, trashed.ID, trashed.Revision); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	cutoff := now.Add(7 * 24 * time.Hour)
+	due, err := store.ListExpiryReminders(ctx, cutoff)
+	if err != nil {
+		t.Fatalf("ListExpiryReminders: %v", err)
+	}
+	if len(due) != 2 || due[0].ID != expired.ID || due[1].ID != soon.ID {
+		t.Fatalf("ListExpiryReminders = %v, want [expired soon], soonest first", titles(due))
+	}
+
+	if err := store.MarkExpiryNotified(ctx, soon.ID, now); err != nil {
+		t.Fatalf("MarkExpiryNotified: %v", err)
+	}
+	due, err = store.ListExpiryReminders(ctx, cutoff)
+	if err != nil {
+		t.Fatalf("ListExpiryReminders: %v", err)
+	}
+	assertIDs(t, due, []*models.Secret{expired})
+
+	// Keeping the expiry date keeps the reminder sent; changing it re-arms it
+	soon.Title = "renamed"
+	if err := store.UpdateWithVersion(ctx, soon); err != nil {
+		t.Fatalf("UpdateWithVersion: %v", err)
+	}
+	if due, err := store.ListExpiryReminders(ctx, cutoff); err != nil || len(due) != 1 {
+		t.Errorf("ListExpiryReminders after unrelated update = %v, %v; want [expired]", titles(due), err)
+	}
+
+	soon.ExpiresAt = ptr(now.Add(48 * time.Hour))
+	if err := store.UpdateWithVersion(ctx, soon); err != nil {
+		t.Fatalf("UpdateWithVersion: %v", err)
+	}
+	due, err = store.ListExpiryReminders(ctx, cutoff)
+	if err != nil {
+		t.Fatalf("ListExpiryReminders: %v", err)
+	}
+	assertIDs(t, due, []*models.Secret{expired, soon})
+}
+
+func testRotationReminders(t *testing.T, store repository.SecretStore) {
+	ctx := context.Background()
+
+	hourly := newSecret("hourly", "")
+	hourly.RotateEvery = time.Hour
+	daily := newSecret("daily", "")
+	daily.RotateEvery = 24 * time.Hour
+	never := newSecret("never", "")
+	for _, secret := range []*models.Secret{hourly, daily, never} {
+		create(t, store, secret)
+	}
+
+	if due, err := store.ListRotationReminders(ctx, time.Now()); err != nil || len(due) != 0 {
+		t.Errorf("ListRotationReminders now = %v, %v; want none", titles(due), err)
+	}
+
+	at := time.Now().Add(2 * time.Hour)
+	due, err := store.ListRotationReminders(ctx, at)
+	if err != nil {
+		t.Fatalf("ListRotationReminders: %v", err)
+	}
+	assertIDs(t, due, []*models.Secret{hourly})
+	if due[0].RotateEvery != time.Hour {
+		t.Errorf("RotateEvery = %v, want 1h", due[0].RotateEvery)
+	}
+
+	if err := store.MarkRotationNotified(ctx, hourly.ID, at); err != nil {
+		t.Fatalf("MarkRotationNotified: %v", err)
+	}
+	if due, err := store.ListRotationReminders(ctx, at); err != nil || len(due) != 0 {
+		t.Errorf("ListRotationReminders after notifying = %v, %v; want none", titles(due), err)
+	}
+
+	// Rotating re-arms the reminder for the next interval
+	hourly.RotatedAt = time.Now()
+	if err := store.UpdateWithVersion(ctx, hourly); err != nil {
+		t.Fatalf("UpdateWithVersion: %v", err)
+	}
+	if due, err := store.ListRotationReminders(ctx, at); err != nil || len(due) != 1 {
+		t.Errorf("ListRotationReminders after rotation = %v, %v; want [hourly]", titles(due), err)
+	}
+
+	got := get(t, store, hourly.ID)
+	assertTime(t, "RotatedAt", got.RotatedAt, hourly.RotatedAt)
+}
+
+func testPolicyCRUD(t *testing.T, store repository.PolicyStore) {
+	ctx := context.Background()
+
+	readers := &models.Policy{
+		Name:        "readers",
+		Description: "Read production secrets",
+		Document: models.PolicyDocument{Statements: []models.PolicyStatement{{
+			Effect:     models.EffectAllow,
+			Actions:    []string{models.ActionRead},
+			Resource:   "secrets",
+			Conditions: map[string][]string{"tag": {"prod"}},
+		}}},
+		Subjects: []models.PolicySubject{{Type: models.SubjectGroup, ID: "ops"}, {Type: models.SubjectUser, ID: "alice"}},
+	}
+	admins := &models.Policy{
+		Name:     "admins",
+		Document: models.PolicyDocument{Statements: []models.PolicyStatement{{Effect: models.EffectAllow, Actions: []string{"*"}, Resource: "secrets"}}},
+	}
+	for _, policy := range []*models.Policy{readers, admins} {
+		if err := store.Create(ctx, policy); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	if readers.ID == "" || readers.ID == admins.ID {
+		t.Fatalf("Create assigned IDs %q and %q", readers.ID, admins.ID)
+	}
+	if readers.CreatedAt.IsZero() || !readers.UpdatedAt.Equal(readers.CreatedAt) {
+		t.Errorf("timestamps not set: created %v, updated %v", readers.CreatedAt, readers.UpdatedAt)
+	}
+
+	got, err := store.Get(ctx, readers.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	assertPolicy(t, got, readers)
+
+	list, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "admins" || list[1].Name != "readers" {
+		t.Errorf("List = %d policies, want admins and readers ordered by name", len(list))
+	}
+
+	readers.Name = "prod-readers"
+	readers.Subjects = []models.PolicySubject{{Type: models.SubjectToken, ID: "ci"}}
+	if err := store.Update(ctx, readers); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err = store.Get(ctx, readers.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	assertPolicy(t, got, readers)
+
+	if err := store.Delete(ctx, readers.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, readers.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Get after Delete: got %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, readers.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("second Delete: got %v, want ErrNotFound", err)
+	}
+	if err := store.Update(ctx, readers); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Update after Delete: got %v, want ErrNotFound", err)
+	}
+}
+
+func testPolicyNameConflict(t *testing.T, store repository.PolicyStore) {
+	ctx := context.Background()
+
+	first := &models.Policy{Name: "taken"}
+	second := &models.Policy{Name: "free"}
+	for _, policy := range []*models.Policy{first, second} {
+		if err := store.Create(ctx, policy); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	if err := store.Create(ctx, &models.Policy{Name: "taken"}); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("Create with taken name: got %v, want ErrConflict", err)
+	}
+
+	second.Name = "taken"
+	if err := store.Update(ctx, second); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("Update to taken name: got %v, want ErrConflict", err)
+	}
+	if got, err := store.Get(ctx, second.ID); err != nil || got.Name != "free" {
+		t.Errorf("policy after failed rename = %+v, %v; want name free", got, err)
+	}
+}
+
+func testPolicyListForSubjects(t *testing.T, store repository.PolicyStore) {
+	ctx := context.Background()
+
+	ops := &models.Policy{Name: "ops", Subjects: []models.PolicySubject{{Type: models.SubjectGroup, ID: "ops"}}}
+	alice := &models.Policy{Name: "alice", Subjects: []models.PolicySubject{{Type: models.SubjectUser, ID: "alice"}, {Type: models.SubjectGroup, ID: "ops"}}}
+	token := &models.Policy{Name: "token", Subjects: []models.PolicySubject{{Type: models.SubjectToken, ID: "alice"}}}
+	for _, policy := range []*models.Policy{ops, alice, token} {
+		if err := store.Create(ctx, policy); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		subjects []models.PolicySubject
+		want     []string
+	}{
+		{"none", nil, nil},
+		{"group", []models.PolicySubject{{Type: models.SubjectGroup, ID: "ops"}}, []string{"alice", "ops"}},
+		{"user and group", []models.PolicySubject{{Type: models.SubjectUser, ID: "alice"}, {Type: models.SubjectGroup, ID: "ops"}}, []string{"alice", "ops"}},
+		{"type matters", []models.PolicySubject{{Type: models.SubjectUser, ID: "ops"}}, nil},
+		{"token", []models.PolicySubject{{Type: models.SubjectToken, ID: "alice"}}, []string{"token"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policies, err := store.ListForSubjects(ctx, tt.subjects)
+			if err != nil {
+				t.Fatalf("ListForSubjects: %v", err)
+			}
+			var names []string
+			for _, policy := range policies {
+				names = append(names, policy.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("ListForSubjects = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+// newSecret returns a minimal secret in folder
+func newSecret(title, folder string) *models.Secret {
+	return &models.Secret{
+		Title:          title,
+		Type:           "secure_note",
+		EncryptedValue: []byte("ciphertext of " + title),
+		Folder:         folder,
+		UpdatedBy:      "owner",
+	}
+}
+
+func create(t *testing.T, store repository.SecretStore, secret *models.Secret) {
+	t.Helper()
+	if err := store.Create(context.Background(), secret); err != nil {
+		t.Fatalf("Create(%s): %v", secret.Title, err)
+	}
+}
+
+func get(t *testing.T, store repository.SecretStore, id string) *models.Secret {
+	t.Helper()
+	secret, err := store.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", id, err)
+	}
+	return secret
+}
+
+// assertSecret compares the stored fields of two secrets. Timestamps only need
+// to match to the microsecond, the resolution of the least precise backend.
+func assertSecret(t *testing.T, got, want *models.Secret) {
+	t.Helper()
+
+	if got.ID != want.ID || got.Title != want.Title || got.Type != want.Type || got.Folder != want.Folder ||
+		got.Version != want.Version || got.UpdatedBy != want.UpdatedBy || got.RotateEvery != want.RotateEvery {
+		t.Errorf("secret = %+v, want %+v", got, want)
+	}
+	if !slices.Equal(got.EncryptedValue, want.EncryptedValue) {
+		t.Errorf("EncryptedValue = %v, want %v", got.EncryptedValue, want.EncryptedValue)
+	}
+	if !slices.Equal(got.Tags, want.Tags) && (len(got.Tags) != 0 || len(want.Tags) != 0) {
+		t.Errorf("Tags = %v, want %v", got.Tags, want.Tags)
+	}
+	if len(got.Fields) != len(want.Fields) {
+		t.Errorf("Fields = %+v, want %+v", got.Fields, want.Fields)
+	} else {
+		for i := range got.Fields {
+			g, w := got.Fields[i], want.Fields[i]
+			if g.Name != w.Name || g.Value != w.Value || g.Concealed != w.Concealed || !slices.Equal(g.EncryptedValue, w.EncryptedValue) {
+				t.Errorf("Fields[%d] = %+v, want %+v", i, g, w)
+			}
+		}
+	}
+	assertTime(t, "CreatedAt", got.CreatedAt, want.CreatedAt)
+	assertTime(t, "UpdatedAt", got.UpdatedAt, want.UpdatedAt)
+	assertTime(t, "RotatedAt", got.RotatedAt, want.RotatedAt)
+	if (got.ExpiresAt == nil) != (want.ExpiresAt == nil) {
+		t.Errorf("ExpiresAt = %v, want %v", got.ExpiresAt, want.ExpiresAt)
+	} else if got.ExpiresAt != nil {
+		assertTime(t, "ExpiresAt", *got.ExpiresAt, *want.ExpiresAt)
+	}
+}
+
+func assertPolicy(t *testing.T, got, want *models.Policy) {
+	t.Helper()
+
+	if got.ID != want.ID || got.Name != want.Name || got.Description != want.Description {
+		t.Errorf("policy = %+v, want %+v", got, want)
+	}
+	if len(got.Document.Statements) != len(want.Document.Statements) {
+		t.Errorf("Document = %+v, want %+v", got.Document, want.Document)
+	} else {
+		for i := range got.Document.Statements {
+			g, w := got.Document.Statements[i], want.Document.Statements[i]
+			if g.Effect != w.Effect || g.Resource != w.Resource || !slices.Equal(g.Actions, w.Actions) || len(g.Conditions) != len(w.Conditions) {
+				t.Errorf("Statements[%d] = %+v, want %+v", i, g, w)
+			}
+		}
+	}
+
+	gotSubjects := slices.Clone(got.Subjects)
+	wantSubjects := slices.Clone(want.Subjects)
+	for _, subjects := range [][]models.PolicySubject{gotSubjects, wantSubjects} {
+		slices.SortFunc(subjects, func(a, b models.PolicySubject) int {
+			if a.Type != b.Type {
+				return compare(a.Type, b.Type)
+			}
+			return compare(a.ID, b.ID)
+		})
+	}
+	if !slices.Equal(gotSubjects, wantSubjects) {
+		t.Errorf("Subjects = %v, want %v", got.Subjects, want.Subjects)
+	}
+	assertTime(t, "CreatedAt", got.CreatedAt, want.CreatedAt)
+	assertTime(t, "UpdatedAt", got.UpdatedAt, want.UpdatedAt)
+}
+
+// assertTime compares timestamps to the microsecond
+func assertTime(t *testing.T, name string, got, want time.Time) {
+	t.Helper()
+	if d := got.Sub(want); d > time.Microsecond || d < -time.Microsecond {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+// assertIDs checks that secrets holds exactly want, in any order
+func assertIDs(t *testing.T, secrets []*models.Secret, want []*models.Secret) {
+	t.Helper()
+
+	got := make([]string, len(secrets))
+	for i, secret := range secrets {
+		got[i] = secret.ID
+	}
+	wantIDs := make([]string, len(want))
+	for i, secret := range want {
+		wantIDs[i] = secret.ID
+	}
+	slices.Sort(got)
+	slices.Sort(wantIDs)
+
+	if !slices.Equal(got, wantIDs) {
+		t.Errorf("got secrets %v, want %v", titles(secrets), titles(want))
+	}
+}
+
+func titles(secrets []*models.Secret) []string {
+	names := make([]string, len(secrets))
+	for i, secret := range secrets {
+		names[i] = secret.Title
+	}
+	return names
+}
+
+func ids(secrets []*models.Secret) []string {
+	list := make([]string, len(secrets))
+	for i, secret := range secrets {
+		list[i] = secret.ID
+	}
+	return list
+}
+
+func versionNumbers(versions []*models.SecretVersion) []int {
+	numbers := make([]int, len(versions))
+	for i, version := range versions {
+		numbers[i] = version.Version
+	}
+	return numbers
+}
+
+func compare(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
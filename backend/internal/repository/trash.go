@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"my-vault/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ListTrash retrieves all soft-deleted secrets, most recently deleted first
+func (r *SecretRepository) ListTrash(ctx context.Context) ([]*models.Secret, error) {
+	query := `
+		SELECT ` + secretColumns + `
+		FROM secrets s
+		WHERE s.deleted_at IS NOT NULL
+		ORDER BY s.deleted_at DESC
+	`
+
+	return r.query(ctx, query)
+}
+
+// GetTrashed retrieves a soft-deleted secret by ID
+func (r *SecretRepository) GetTrashed(ctx context.Context, id string) (*models.Secret, error) {
+	query := `SELECT ` + secretColumns + ` FROM secrets s WHERE s.id = $1 AND s.deleted_at IS NOT NULL`
+
+	secret, err := scanSecret(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("trashed secret %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trashed secret: %w", err)
+	}
+
+	return secret, nil
+}
+
+// Restore moves a secret out of the trash
+func (r *SecretRepository) Restore(ctx context.Context, id string) error {
+	query := `UPDATE secrets SET deleted_at = NULL, revision = revision + 1 WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	result, err := r.pool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore secret: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("trashed secret %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// Purge permanently removes a trashed secret together with its history
+func (r *SecretRepository) Purge(ctx context.Context, id string) error {
+	query := `DELETE FROM secrets WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	result, err := r.pool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to purge secret: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("trashed secret %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// PurgeDeletedBefore permanently removes secrets trashed before cutoff.
+// It returns the IDs of the secrets removed.
+func (r *SecretRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
+	query := `DELETE FROM secrets WHERE deleted_at IS NOT NULL AND deleted_at < $1 RETURNING id`
+
+	rows, err := r.pool.Query(ctx, query, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge trash: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge trash: %w", err)
+	}
+
+	return ids, nil
+}
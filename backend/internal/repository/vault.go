@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"my-vault/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// VaultRepository handles database operations for the vault header
+type VaultRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewVaultRepository creates a new vault repository
+func NewVaultRepository(db *PostgresDB) *VaultRepository {
+	return &VaultRepository{
+		pool: db.GetPool(),
+	}
+}
+
+// GetHeader retrieves the vault header
+func (r *VaultRepository) GetHeader(ctx context.Context) (*models.VaultHeader, error) {
+	query := `SELECT salt, verifier, created_at FROM vault_header WHERE id = 1`
+
+	var header models.VaultHeader
+	err := r.pool.QueryRow(ctx, query).Scan(&header.Salt, &header.Verifier, &header.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("vault header %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vault header: %w", err)
+	}
+
+	return &header, nil
+}
+
+// CreateHeader stores the vault header unless the vault is already initialized
+func (r *VaultRepository) CreateHeader(ctx context.Context, header *models.VaultHeader) error {
+	query := `
+		INSERT INTO vault_header (id, salt, verifier, created_at)
+		VALUES (1, $1, $2, $3)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	result, err := r.pool.Exec(ctx, query, header.Salt, header.Verifier, header.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create vault header: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("vault header %w", ErrConflict)
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"my-vault/internal/models"
+	"my-vault/internal/utils"
+
+	"github.com/gofrs/flock"
+)
+
+const (
+	// vaultFileFormat identifies a vault file; vaultFileVersion is bumped on incompatible changes
+	vaultFileFormat  = "vaultbox"
+	vaultFileVersion = 1
+
+	// vaultFileKeyPurpose derives the file key from the vault key
+	vaultFileKeyPurpose = "vault-file"
+)
+
+// vaultFileContents is the on-disk layout of a vault file. The header stays
+// readable so the master password can be checked before anything is decrypted;
+// everything else is encrypted as one blob under a key derived from the vault key.
+type vaultFileContents struct {
+	Format  string              `json:"format"`
+	Version int                 `json:"version"`
+	Header  *models.VaultHeader `json:"header,omitempty"`
+	Data    []byte              `json:"data,omitempty"`
+}
+
+// vaultFile persists a MemoryDB to a single encrypted file. Attachment contents
+// are not part of it: they live in a BlobStore directory, and a vault on this
+// backend is the file together with that directory.
+type vaultFile struct {
+	path   string
+	lock   *flock.Flock
+	key    []byte // file key while the vault is unlocked
+	sealed []byte // encrypted content as last read or written
+}
+
+// OpenVaultFile opens the vault file at path, creating its directory when
+// missing; the file itself is written on the first change. Only the header is
+// read here: the content is decrypted into memory when the vault is unlocked.
+// An exclusive lock on path.lock keeps other processes from opening the same
+// vault file until Close.
+func OpenVaultFile(path string) (*MemoryDB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create vault file directory: %w", err)
+	}
+
+	lock := flock.New(path + ".lock")
+	locked, err := lock.TryLock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock vault file: %w", err)
+	}
+	if !locked {
+		return nil, fmt.Errorf("vault file %s is in use by another process", path)
+	}
+
+	file := &vaultFile{path: path, lock: lock}
+	contents, err := file.read()
+	if err != nil {
+		lock.Unlock()
+		return nil, err
+	}
+	file.sealed = contents.Data
+
+	log.Printf("Successfully opened vault file %s", path)
+
+	return &MemoryDB{header: contents.Header, file: file}, nil
+}
+
+// read parses the vault file; a missing file reads as an uninitialized vault
+func (f *vaultFile) read() (*vaultFileContents, error) {
+	raw, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &vaultFileContents{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault file: %w", err)
+	}
+
+	var contents vaultFileContents
+	if err := json.Unmarshal(raw, &contents); err != nil {
+		return nil, fmt.Errorf("failed to parse vault file: %w", err)
+	}
+	if contents.Format != vaultFileFormat {
+		return nil, fmt.Errorf("%s is not a vault file", f.path)
+	}
+	if contents.Version != vaultFileVersion {
+		return nil, fmt.Errorf("unsupported vault file version %d", contents.Version)
+	}
+
+	return &contents, nil
+}
+
+// load reads the vault file again, so a copy synced in while the vault was
+// locked is picked up, and decrypts its content with the vault key
+func (f *vaultFile) load(vaultKey []byte) (*models.VaultHeader, *memoryData, error) {
+	contents, err := f.read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := utils.DeriveSubkey(vaultKey, vaultFileKeyPurpose)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := newMemoryData()
+	if len(contents.Data) > 0 {
+		plaintext, err := utils.Decrypt(contents.Data, key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decrypt vault file: %w", err)
+		}
+		if err := json.Unmarshal(plaintext, data); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode vault file: %w", err)
+		}
+
+		// Secrets written before revisions were counted start at 1, as in the SQL backends
+		for _, secret := range data.Secrets {
+			secret.Revision = max(secret.Revision, 1)
+		}
+	}
+
+	f.key = key
+	f.sealed = contents.Data
+	return contents.Header, data, nil
+}
+
+// save encrypts data and replaces the vault file. While the vault is locked
+// data is nil and the previously encrypted content is kept.
+func (f *vaultFile) save(header *models.VaultHeader, data *memoryData) error {
+	contents := vaultFileContents{
+		Format:  vaultFileFormat,
+		Version: vaultFileVersion,
+		Header:  header,
+		Data:    f.sealed,
+	}
+
+	if data != nil {
+		plaintext, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to encode vault file: %w", err)
+		}
+
+		contents.Data, err = utils.Encrypt(plaintext, f.key)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt vault file: %w", err)
+		}
+	}
+
+	raw, err := json.Marshal(contents)
+	if err != nil {
+		return fmt.Errorf("failed to encode vault file: %w", err)
+	}
+
+	if err := writeFileAtomic(f.path, raw); err != nil {
+		return fmt.Errorf("failed to write vault file: %w", err)
+	}
+
+	f.sealed = contents.Data
+	return nil
+}
+
+// forget clears the file key from memory
+func (f *vaultFile) forget() {
+	clear(f.key)
+	f.key = nil
+}
+
+// writeFileAtomic replaces path with content. The content is written to a
+// temporary file in the same directory, synced and renamed over path, and the
+// directory is synced, so a crash leaves either the old or the new file.
+func writeFileAtomic(path string, content []byte) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
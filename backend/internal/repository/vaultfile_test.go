@@ -0,0 +1,154 @@
+package repository_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"my-vault/internal/models"
+	"my-vault/internal/repository"
+	"my-vault/internal/repository/storetest"
+)
+
+// testVaultKey stands in for a key derived from the master password
+var testVaultKey = []byte("0123456789abcdef0123456789abcdef")
+
+// openTestVaultFile opens a vault file in a temporary directory
+func openTestVaultFile(t *testing.T, path string) *repository.MemoryDB {
+	t.Helper()
+
+	db, err := repository.OpenVaultFile(path)
+	if err != nil {
+		t.Fatalf("OpenVaultFile: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+// newUnlockedVaultFile opens an empty vault file and unlocks it
+func newUnlockedVaultFile(t *testing.T) *repository.MemoryDB {
+	t.Helper()
+
+	db := openTestVaultFile(t, filepath.Join(t.TempDir(), "vault.vbx"))
+	if err := repository.NewMemoryVaultRepository(db).Unlock(context.Background(), testVaultKey); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+
+	return db
+}
+
+func TestVaultFileSecretStore(t *testing.T) {
+	storetest.TestSecretStore(t, func(t *testing.T) repository.SecretStore {
+		return repository.NewMemorySecretRepository(newUnlockedVaultFile(t))
+	})
+}
+
+func TestVaultFilePolicyStore(t *testing.T) {
+	storetest.TestPolicyStore(t, func(t *testing.T) repository.PolicyStore {
+		return repository.NewMemoryPolicyRepository(newUnlockedVaultFile(t))
+	})
+}
+
+func TestVaultFileVaultStore(t *testing.T) {
+	storetest.TestVaultStore(t, func(t *testing.T) repository.VaultStore {
+		return repository.NewMemoryVaultRepository(openTestVaultFile(t, filepath.Join(t.TempDir(), "vault.vbx")))
+	})
+}
+
+func TestVaultFilePersistence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "vault.vbx")
+
+	db, err := repository.OpenVaultFile(path)
+	if err != nil {
+		t.Fatalf("OpenVaultFile: %v", err)
+	}
+	vault := repository.NewMemoryVaultRepository(db)
+	header := &models.VaultHeader{Salt: []byte("salt"), Verifier: []byte("verifier"), CreatedAt: time.Now()}
+	if err := vault.CreateHeader(ctx, header); err != nil {
+		t.Fatalf("CreateHeader: %v", err)
+	}
+
+	// Nothing but the header is readable before unlock
+	secrets := repository.NewMemorySecretRepository(db)
+	if _, err := secrets.List(ctx, models.SecretFilter{}); !errors.Is(err, repository.ErrLocked) {
+		t.Fatalf("List before unlock: got %v, want ErrLocked", err)
+	}
+
+	if err := vault.Unlock(ctx, testVaultKey); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	secret := &models.Secret{Title: "persisted-title", Type: "password", EncryptedValue: []byte("ciphertext")}
+	if err := secrets.Create(ctx, secret); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// The file holds no plaintext metadata and no temporary files are left behind
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if bytes.Contains(raw, []byte("persisted-title")) {
+		t.Error("vault file contains a secret title in plaintext")
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Errorf("Stat: %v", err)
+	} else if info.Mode().Perm() != 0o600 {
+		t.Errorf("vault file mode = %v, want 0600", info.Mode().Perm())
+	}
+	if matches, _ := filepath.Glob(path + ".*.tmp"); len(matches) > 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+
+	vault.Lock()
+	if _, err := secrets.Get(ctx, secret.ID); !errors.Is(err, repository.ErrLocked) {
+		t.Errorf("Get after lock: got %v, want ErrLocked", err)
+	}
+	db.Close()
+
+	// Reopened, the vault keeps its header and content
+	reopened := openTestVaultFile(t, path)
+	vault = repository.NewMemoryVaultRepository(reopened)
+	if got, err := vault.GetHeader(ctx); err != nil || string(got.Salt) != "salt" {
+		t.Fatalf("GetHeader after reopen = %+v, %v", got, err)
+	}
+
+	if err := vault.Unlock(ctx, []byte("fedcba9876543210fedcba9876543210")); err == nil {
+		t.Error("Unlock with a wrong key succeeded")
+	}
+
+	if err := vault.Unlock(ctx, testVaultKey); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	got, err := repository.NewMemorySecretRepository(reopened).Get(ctx, secret.ID)
+	if err != nil {
+		t.Fatalf("Get after reopen: %v", err)
+	}
+	if got.Title != secret.Title || string(got.EncryptedValue) != "ciphertext" {
+		t.Errorf("secret after reopen = %+v", got)
+	}
+}
+
+func TestVaultFileLocking(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.vbx")
+
+	db, err := repository.OpenVaultFile(path)
+	if err != nil {
+		t.Fatalf("OpenVaultFile: %v", err)
+	}
+	if _, err := repository.OpenVaultFile(path); err == nil {
+		t.Fatal("second OpenVaultFile succeeded while the file is open")
+	}
+
+	db.Close()
+	if second, err := repository.OpenVaultFile(path); err != nil {
+		t.Fatalf("OpenVaultFile after Close: %v", err)
+	} else {
+		second.Close()
+	}
+}
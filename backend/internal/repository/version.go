@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"my-vault/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UpdateWithVersion updates a secret's content, archiving the replaced content
+// in secret_versions and bumping the version number in one transaction
+func (r *SecretRepository) UpdateWithVersion(ctx context.Context, secret *models.Secret) error {
+	secret.UpdatedAt = time.Now()
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		// Lock the row so concurrent updates archive distinct versions
+		if err := lockSecretRevision(ctx, tx, secret.ID, secret.Revision); err != nil {
+			return err
+		}
+
+		archive := `
+			INSERT INTO secret_versions (secret_id, version, title, type, encrypted_value, fields, author, created_at)
+			SELECT id, version, title, type, encrypted_value, fields, updated_by, updated_at
+			FROM secrets
+			WHERE id = $1
+		`
+		if _, err := tx.Exec(ctx, archive, secret.ID); err != nil {
+			return fmt.Errorf("failed to archive version: %w", err)
+		}
+
+		// Reminders already sent are reset when the dates they were sent for change
+		update := `
+			UPDATE secrets
+			SET title = $1, type = $2, encrypted_value = $3, fields = $4, folder = $5,
+				version = version + 1, revision = revision + 1, updated_by = $6, updated_at = $7,
+				expires_at = $9, rotate_every = $10, rotated_at = $11,
+				expiry_notified_at = CASE WHEN expires_at IS DISTINCT FROM $9 THEN NULL ELSE expiry_notified_at END,
+				rotation_notified_at = CASE
+					WHEN rotate_every IS DISTINCT FROM $10 OR rotated_at IS DISTINCT FROM $11 THEN NULL
+					ELSE rotation_notified_at
+				END
+			WHERE id = $8
+			RETURNING version, revision
+		`
+		err := tx.QueryRow(ctx, update,
+			secret.Title,
+			secret.Type,
+			secret.EncryptedValue,
+			fieldsOrEmpty(secret.Fields),
+			secret.Folder,
+			secret.UpdatedBy,
+			secret.UpdatedAt,
+			secret.ID,
+			secret.ExpiresAt,
+			rotateEverySeconds(secret.RotateEvery),
+			secret.RotatedAt,
+		).Scan(&secret.Version, &secret.Revision)
+		if err != nil {
+			return err
+		}
+
+		return replaceTags(ctx, tx, secret.ID, secret.Tags)
+	})
+
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrRevisionMismatch) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	return nil
+}
+
+// ListVersions retrieves the archived versions of a secret, newest first
+func (r *SecretRepository) ListVersions(ctx context.Context, secretID string) ([]*models.SecretVersion, error) {
+	query := `
+		SELECT secret_id, version, title, type, encrypted_value, fields, author, created_at
+		FROM secret_versions
+		WHERE secret_id = $1
+		ORDER BY version DESC
+	`
+
+	rows, err := r.pool.Query(ctx, query, secretID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*models.SecretVersion
+	for rows.Next() {
+		version, err := scanVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan version: %w", err)
+		}
+		versions = append(versions, version)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// GetVersion retrieves one archived version of a secret
+func (r *SecretRepository) GetVersion(ctx context.Context, secretID string, version int) (*models.SecretVersion, error) {
+	query := `
+		SELECT secret_id, version, title, type, encrypted_value, fields, author, created_at
+		FROM secret_versions
+		WHERE secret_id = $1 AND version = $2
+	`
+
+	v, err := scanVersion(r.pool.QueryRow(ctx, query, secretID, version))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("version %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version: %w", err)
+	}
+
+	return v, nil
+}
+
+// PruneVersions deletes archived versions beyond the newest keep versions
+// or created before olderThan. Zero values disable the respective limit.
+func (r *SecretRepository) PruneVersions(ctx context.Context, secretID string, keep int, olderThan time.Time) error {
+	if keep > 0 {
+		query := `
+			DELETE FROM secret_versions
+			WHERE secret_id = $1 AND version NOT IN (
+				SELECT version FROM secret_versions
+				WHERE secret_id = $1
+				ORDER BY version DESC
+				LIMIT $2
+			)
+		`
+		if _, err := r.pool.Exec(ctx, query, secretID, keep); err != nil {
+			return fmt.Errorf("failed to prune versions: %w", err)
+		}
+	}
+
+	if !olderThan.IsZero() {
+		query := `DELETE FROM secret_versions WHERE secret_id = $1 AND created_at < $2`
+		if _, err := r.pool.Exec(ctx, query, secretID, olderThan); err != nil {
+			return fmt.Errorf("failed to prune versions: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// PruneVersionsBefore deletes the archived versions of every secret created before cutoff.
+// It returns the number of versions removed.
+func (r *SecretRepository) PruneVersionsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `DELETE FROM secret_versions WHERE created_at < $1`
+
+	result, err := r.pool.Exec(ctx, query, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune versions: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// scanVersion scans a secret_versions row
+func scanVersion(row pgx.Row) (*models.SecretVersion, error) {
+	var version models.SecretVersion
+	err := row.Scan(
+		&version.SecretID,
+		&version.Version,
+		&version.Title,
+		&version.Type,
+		&version.EncryptedValue,
+		&version.Fields,
+		&version.Author,
+		&version.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
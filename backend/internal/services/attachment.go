@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"my-vault/internal/models"
+	"my-vault/internal/repository"
+	"my-vault/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// maxAttachmentNameLength caps the length of an attachment filename
+const maxAttachmentNameLength = 255
+
+// AttachmentLimits caps the size of attachments in bytes.
+// Zero values disable the respective limit.
+type AttachmentLimits struct {
+	MaxFileSize  int64
+	MaxTotalSize int64
+}
+
+// SetAttachments sets where attachment contents are stored and how large they may be
+func (s *SecretService) SetAttachments(blobs *repository.BlobStore, limits AttachmentLimits) {
+	s.blobs = blobs
+	s.attachmentLimits = limits
+}
+
+// ListAttachments returns the attachments of a secret with decrypted filenames
+func (s *SecretService) ListAttachments(ctx context.Context, secretID string) ([]*models.AttachmentInfo, error) {
+	key, err := s.vaultService.GetKey()
+	if err != nil {
+		return nil, fmt.Errorf("vault is locked: %w", err)
+	}
+
+	if _, err := s.authorizedSecret(ctx, models.ActionRead, secretID); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.repo.ListAttachments(ctx, secretID)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := []*models.AttachmentInfo{}
+	for _, attachment := range attachments {
+		info, err := decryptAttachment(attachment, key)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// AddAttachment encrypts content into the blob store and attaches it to a secret.
+// Content is streamed in chunks and never held in memory as a whole.
+func (s *SecretService) AddAttachment(ctx context.Context, secretID, name string, content io.Reader) (*models.AttachmentInfo, error) {
+	key, err := s.vaultService.GetKey()
+	if err != nil {
+		return nil, fmt.Errorf("vault is locked: %w", err)
+	}
+	if s.blobs == nil {
+		return nil, errors.New("attachments are not configured")
+	}
+
+	name, err = normalizeAttachmentName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.authorizedSecret(ctx, models.ActionUpdate, secretID); err != nil {
+		return nil, err
+	}
+
+	// The file may use whatever the per-file and per-secret limits leave
+	limit := s.attachmentLimits.MaxFileSize
+	if s.attachmentLimits.MaxTotalSize > 0 {
+		used, err := s.repo.AttachmentsSize(ctx, secretID)
+		if err != nil {
+			return nil, err
+		}
+		remaining := s.attachmentLimits.MaxTotalSize - used
+		if remaining <= 0 {
+			return nil, fmt.Errorf("%w: secret attachments exceed %d bytes", ErrTooLarge, s.attachmentLimits.MaxTotalSize)
+		}
+		if limit <= 0 || remaining < limit {
+			limit = remaining
+		}
+	}
+
+	encryptedName, err := utils.Encrypt([]byte(name), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt filename: %w", err)
+	}
+
+	attachment := &models.Attachment{
+		ID:            uuid.New().String(),
+		SecretID:      secretID,
+		EncryptedName: encryptedName,
+		CreatedBy:     PrincipalFromContext(ctx).String(),
+		CreatedAt:     time.Now(),
+	}
+
+	err = s.blobs.Write(secretID, attachment.ID, func(w io.Writer) error {
+		// The content is bound to the attachment ID so blobs cannot be swapped between attachments
+		encrypter, err := utils.NewEncryptWriter(w, key, []byte(attachment.ID))
+		if err != nil {
+			return err
+		}
+
+		// Read one byte past the limit to tell an exact fit from an oversized file
+		src := content
+		if limit > 0 {
+			src = io.LimitReader(content, limit+1)
+		}
+		size, err := io.Copy(encrypter, src)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt attachment: %w", err)
+		}
+		if limit > 0 && size > limit {
+			return fmt.Errorf("%w: attachment exceeds %d bytes", ErrTooLarge, limit)
+		}
+		attachment.Size = size
+
+		return encrypter.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Uploads running side by side all passed the check above; the store checks the total again
+	if err := s.repo.CreateAttachment(ctx, attachment, s.attachmentLimits.MaxTotalSize); err != nil {
+		s.deleteBlob(secretID, attachment.ID)
+		return nil, err
+	}
+
+	return decryptAttachment(attachment, key)
+}
+
+// OpenAttachment returns an attachment's metadata and a reader streaming its
+// decrypted content. The caller must close the reader.
+func (s *SecretService) OpenAttachment(ctx context.Context, secretID, id string) (*models.AttachmentInfo, io.ReadCloser, error) {
+	key, err := s.vaultService.GetKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("vault is locked: %w", err)
+	}
+	if s.blobs == nil {
+		return nil, nil, errors.New("attachments are not configured")
+	}
+
+	secret, err := s.authorizedSecret(ctx, models.ActionRead, secretID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attachment, err := s.repo.GetAttachment(ctx, secretID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := decryptAttachment(attachment, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := s.blobs.Open(secretID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := utils.NewDecryptReader(file, key, []byte(id))
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to decrypt attachment: %w", err)
+	}
+
+	if err := s.auditAttachment(ctx, secret, id); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return info, struct {
+		io.Reader
+		io.Closer
+	}{content, file}, nil
+}
+
+// DeleteAttachment removes an attachment from a secret
+func (s *SecretService) DeleteAttachment(ctx context.Context, secretID, id string) error {
+	if !s.vaultService.IsUnlocked() {
+		return fmt.Errorf("vault is locked")
+	}
+
+	if _, err := s.authorizedSecret(ctx, models.ActionUpdate, secretID); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteAttachment(ctx, secretID, id); err != nil {
+		return err
+	}
+	s.deleteBlob(secretID, id)
+
+	return nil
+}
+
+// authorizedSecret loads a secret and checks the caller may perform action on it
+func (s *SecretService) authorizedSecret(ctx context.Context, action, secretID string) (*models.Secret, error) {
+	secret, err := s.repo.Get(ctx, secretID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	if err := s.policyService.Authorize(ctx, action, secretAttributes(secret)); err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// deleteBlob removes an attachment's content. Failures are logged since the
+// metadata is already gone and the blob is unreachable.
+func (s *SecretService) deleteBlob(secretID, id string) {
+	if s.blobs == nil {
+		return
+	}
+	if err := s.blobs.Delete(secretID, id); err != nil {
+		log.Printf("Failed to delete attachment %s of secret %s: %v", id, secretID, err)
+	}
+}
+
+// deleteBlobs removes the contents of every attachment of a purged secret
+func (s *SecretService) deleteBlobs(secretID string) {
+	if s.blobs == nil {
+		return
+	}
+	if err := s.blobs.DeleteAll(secretID); err != nil {
+		log.Printf("Failed to delete attachments of secret %s: %v", secretID, err)
+	}
+}
+
+// decryptAttachment converts stored attachment metadata into its API form
+func decryptAttachment(attachment *models.Attachment, key []byte) (*models.AttachmentInfo, error) {
+	name, err := utils.Decrypt(attachment.EncryptedName, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt filename of attachment %s: %w", attachment.ID, err)
+	}
+
+	return &models.AttachmentInfo{
+		ID:        attachment.ID,
+		SecretID:  attachment.SecretID,
+		Name:      string(name),
+		Size:      attachment.Size,
+		CreatedBy: attachment.CreatedBy,
+		CreatedAt: attachment.CreatedAt,
+	}, nil
+}
+
+// normalizeAttachmentName reduces an uploaded filename to its base name
+func normalizeAttachmentName(name string) (string, error) {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "", fmt.Errorf("%w: attachment filename is required", ErrValidation)
+	}
+	if len(name) > maxAttachmentNameLength {
+		return "", fmt.Errorf("%w: attachment filename is too long", ErrValidation)
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return "", fmt.Errorf("%w: attachment filename contains control characters", ErrValidation)
+	}
+
+	return name, nil
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+// Auditor records access to secret values. A secret is only revealed once
+// its event has been recorded, so an auditor failing refuses the access.
+type Auditor interface {
+	Audit(ctx context.Context, event *models.AuditEvent) error
+}
+
+// LogAuditor writes audit events to the server log
+type LogAuditor struct{}
+
+// NewLogAuditor creates an auditor writing to the server log
+func NewLogAuditor() *LogAuditor {
+	return &LogAuditor{}
+}
+
+// Audit logs the event
+func (a *LogAuditor) Audit(ctx context.Context, event *models.AuditEvent) error {
+	if event.AttachmentID != "" {
+		log.Printf("Audit: %s by %s: secret %s attachment %s", event.Action, event.Principal, event.SecretID, event.AttachmentID)
+		return nil
+	}
+	log.Printf("Audit: %s by %s: secret %s version %d", event.Action, event.Principal, event.SecretID, event.Version)
+	return nil
+}
+
+// FileAuditor appends audit events to a file as JSON lines
+type FileAuditor struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileAuditor opens path for appending, creating it when missing
+func NewFileAuditor(path string) (*FileAuditor, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	return &FileAuditor{file: file}, nil
+}
+
+// Audit appends the event and syncs the file
+func (a *FileAuditor) Audit(ctx context.Context, event *models.AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit event: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := a.file.Sync(); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the audit log
+func (a *FileAuditor) Close() error {
+	return a.file.Close()
+}
+
+// SetAuditor sets where reveals of secret values are recorded, replacing the server log
+func (s *SecretService) SetAuditor(auditor Auditor) {
+	s.auditor = auditor
+}
+
+// auditReveal records that the caller was handed the current values of secrets
+func (s *SecretService) auditReveal(ctx context.Context, secrets ...*models.Secret) error {
+	principal := PrincipalFromContext(ctx).String()
+	now := time.Now()
+
+	for _, secret := range secrets {
+		event := &models.AuditEvent{
+			Time:      now,
+			Action:    models.AuditSecretRevealed,
+			Principal: principal,
+			SecretID:  secret.ID,
+			Version:   secret.Version,
+		}
+		if err := s.auditor.Audit(ctx, event); err != nil {
+			return fmt.Errorf("failed to audit access: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// auditAttachment records that the caller was handed the decrypted content of an attachment
+func (s *SecretService) auditAttachment(ctx context.Context, secret *models.Secret, attachmentID string) error {
+	event := &models.AuditEvent{
+		Time:         time.Now(),
+		Action:       models.AuditAttachmentRevealed,
+		Principal:    PrincipalFromContext(ctx).String(),
+		SecretID:     secret.ID,
+		Version:      secret.Version,
+		AttachmentID: attachmentID,
+	}
+	if err := s.auditor.Audit(ctx, event); err != nil {
+		return fmt.Errorf("failed to audit access: %w", err)
+	}
+
+	return nil
+}
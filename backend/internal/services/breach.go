@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"my-vault/internal/models"
+	"my-vault/internal/repository"
+)
+
+// SetBreachIndex sets the local breached passwords dataset checked by reports
+func (s *SecretService) SetBreachIndex(breaches *repository.PwnedPasswords) {
+	s.breaches = breaches
+}
+
+// BreachReport decrypts every secret the caller may read and lists the
+// credentials whose SHA-1 hash appears in the local breached passwords dataset
+func (s *SecretService) BreachReport(ctx context.Context) (*models.BreachReport, error) {
+	if s.breaches == nil {
+		return nil, fmt.Errorf("%w: no breached passwords dataset is configured", ErrUnavailable)
+	}
+
+	key, err := s.vaultService.GetKey()
+	if err != nil {
+		return nil, fmt.Errorf("vault is locked: %w", err)
+	}
+
+	report := &models.BreachReport{
+		GeneratedAt: time.Now(),
+		Breached:    []models.BreachedSecret{},
+	}
+
+	report.SecretsScanned, err = s.forEachReadable(ctx, key, func(secret *models.Secret, data map[string]any) error {
+		for _, name := range credentialFields[secret.Type] {
+			value, _ := data[name].(string)
+			if value == "" {
+				continue
+			}
+
+			ref := models.SecretFieldRef{ID: secret.ID, Title: secret.Title, Field: name}
+			breached, ok, err := s.breachedCredential(ref, value)
+			if err != nil {
+				return err
+			}
+			if ok {
+				report.Breached = append(report.Breached, breached)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// breachedCredential looks up the SHA-1 hash of a credential in the dataset
+func (s *SecretService) breachedCredential(ref models.SecretFieldRef, value string) (models.BreachedSecret, bool, error) {
+	sum := sha1.Sum([]byte(value))
+
+	count, err := s.breaches.Lookup(hex.EncodeToString(sum[:]))
+	if err != nil {
+		return models.BreachedSecret{}, false, err
+	}
+	if count == 0 {
+		return models.BreachedSecret{}, false, nil
+	}
+
+	return models.BreachedSecret{SecretFieldRef: ref, Occurrences: count}, true, nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+// secretCursor is the opaque form of a listing position handed to clients.
+// It records the sort order, so a cursor is only accepted for the listing
+// order it was issued for.
+type secretCursor struct {
+	Sort      models.SecretSort `json:"sort"`
+	Ascending bool              `json:"asc,omitempty"`
+	ID        string            `json:"id"`
+	Title     string            `json:"title,omitempty"`
+	At        *time.Time        `json:"at,omitempty"`
+}
+
+// encodeSecretCursor returns the cursor continuing a listing with filter behind secret
+func encodeSecretCursor(secret *models.Secret, filter models.SecretFilter) string {
+	position := models.NewSecretCursor(secret, filter.Sort)
+	cursor := secretCursor{
+		Sort:      sortOrDefault(filter.Sort),
+		Ascending: filter.Ascending,
+		ID:        position.ID,
+		Title:     position.Title,
+	}
+	if filter.Sort != models.SortByTitle {
+		cursor.At = &position.At
+	}
+
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeSecretCursor parses a cursor returned as next_cursor by an earlier
+// listing, checking that it was issued for the sort order of filter
+func DecodeSecretCursor(value string, filter models.SecretFilter) (*models.SecretCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrValidation)
+	}
+
+	var cursor secretCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrValidation)
+	}
+
+	if cursor.Sort != sortOrDefault(filter.Sort) || cursor.Ascending != filter.Ascending {
+		return nil, fmt.Errorf("%w: cursor was issued for another sort order", ErrValidation)
+	}
+	if (cursor.Sort == models.SortByTitle) != (cursor.At == nil) {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrValidation)
+	}
+
+	position := &models.SecretCursor{ID: cursor.ID, Title: cursor.Title}
+	if cursor.At != nil {
+		position.At = *cursor.At
+	}
+	return position, nil
+}
+
+// sortOrDefault returns the sort order a listing uses when none is set
+func sortOrDefault(sort models.SecretSort) models.SecretSort {
+	if sort == "" {
+		return models.SortByCreatedAt
+	}
+	return sort
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"errors"
+
+	"my-vault/internal/repository"
+)
+
+var (
+	// ErrNotFound is returned when a requested record does not exist
+	ErrNotFound = repository.ErrNotFound
+
+	// ErrConflict is returned when a record clashes with an existing one
+	ErrConflict = repository.ErrConflict
+
+	// ErrRevisionMismatch is returned when a secret changed since the caller read it
+	ErrRevisionMismatch = repository.ErrRevisionMismatch
+
+	// ErrLocked is returned when the storage backend cannot be read while the vault is locked
+	ErrLocked = repository.ErrLocked
+
+	// ErrAccessDenied is returned when policies do not allow an action
+	ErrAccessDenied = errors.New("access denied")
+
+	// ErrValidation is returned when a request fails validation
+	ErrValidation = errors.New("validation failed")
+
+	// ErrTooLarge is returned when uploaded content exceeds a size limit
+	ErrTooLarge = repository.ErrTooLarge
+
+	// ErrInvalidPassword is returned when the master password does not match the vault
+	ErrInvalidPassword = errors.New("invalid master password")
+
+	// ErrNotInitialized is returned when unlocking a vault whose master password was never set
+	ErrNotInitialized = errors.New("vault is not initialized")
+
+	// ErrUnavailable is returned when an optional feature is not configured or is busy
+	ErrUnavailable = errors.New("unavailable")
+)
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"my-vault/internal/models"
+)
+
+const (
+	// maxFolderLength caps the length of a normalized folder path
+	maxFolderLength = 1024
+
+	// maxTagLength caps the length of a single tag
+	maxTagLength = 100
+)
+
+// ListFolders returns every folder holding secrets the caller may read,
+// including intermediate folders, with direct and total secret counts
+func (s *SecretService) ListFolders(ctx context.Context) ([]*models.Folder, error) {
+	if !s.vaultService.IsUnlocked() {
+		return nil, fmt.Errorf("vault is locked: %w", ErrLocked)
+	}
+
+	secrets, err := s.repo.List(ctx, models.SecretFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	evaluator, err := s.policyService.Evaluator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	folders := make(map[string]*models.Folder)
+	for _, secret := range secrets {
+		if secret.Folder == "" || !evaluator.Allows(models.ActionRead, secretAttributes(secret)) {
+			continue
+		}
+
+		segments := strings.Split(secret.Folder, "/")
+		for i := range segments {
+			path := strings.Join(segments[:i+1], "/")
+			folder, ok := folders[path]
+			if !ok {
+				folder = &models.Folder{Path: path}
+				folders[path] = folder
+			}
+			folder.TotalCount++
+			if path == secret.Folder {
+				folder.SecretCount++
+			}
+		}
+	}
+
+	result := make([]*models.Folder, 0, len(folders))
+	for _, folder := range folders {
+		result = append(result, folder)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+
+	return result, nil
+}
+
+// Move places a secret in another folder without touching its value
+func (s *SecretService) Move(ctx context.Context, id string, revision int, req *models.MoveSecretRequest) (*models.SecretSummary, error) {
+	if !s.vaultService.IsUnlocked() {
+		return nil, fmt.Errorf("vault is locked: %w", ErrLocked)
+	}
+
+	folder, err := normalizeFolder(req.Folder)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	// The caller must be allowed to update the secret in both its old and new folder
+	evaluator, err := s.policyService.Evaluator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !evaluator.Allows(models.ActionUpdate, secretAttributes(secret)) {
+		return nil, fmt.Errorf("%w: %s not permitted", ErrAccessDenied, models.ActionUpdate)
+	}
+
+	secret.Folder = folder
+	if !evaluator.Allows(models.ActionUpdate, secretAttributes(secret)) {
+		return nil, fmt.Errorf("%w: %s not permitted", ErrAccessDenied, models.ActionUpdate)
+	}
+
+	// The store refuses the move unless the secret is still at the revision the caller read
+	secret.Revision = revision
+	if err := s.repo.Update(ctx, secret); err != nil {
+		return nil, fmt.Errorf("failed to move secret: %w", err)
+	}
+
+	return newSecretSummary(secret), nil
+}
+
+// RenameFolder renames a folder and all of its subfolders in bulk
+func (s *SecretService) RenameFolder(ctx context.Context, req *models.RenameFolderRequest) (*models.RenameFolderResponse, error) {
+	if !s.vaultService.IsUnlocked() {
+		return nil, fmt.Errorf("vault is locked: %w", ErrLocked)
+	}
+
+	from, err := normalizeFolder(req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := normalizeFolder(req.To)
+	if err != nil {
+		return nil, err
+	}
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("%w: source and target folders are required", ErrValidation)
+	}
+	if to == from || strings.HasPrefix(to, from+"/") {
+		return nil, fmt.Errorf("%w: cannot move a folder into itself", ErrValidation)
+	}
+
+	// Every affected secret must be updatable by the caller, before and after the rename
+	secrets, err := s.repo.List(ctx, models.SecretFilter{FolderPrefix: from})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	evaluator, err := s.policyService.Evaluator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make(map[string]int, len(secrets))
+	for _, secret := range secrets {
+		renamed := *secret
+		renamed.Folder = to + strings.TrimPrefix(secret.Folder, from)
+		if !evaluator.Allows(models.ActionUpdate, secretAttributes(secret)) || !evaluator.Allows(models.ActionUpdate, secretAttributes(&renamed)) {
+			return nil, fmt.Errorf("%w: %s not permitted on secret %s", ErrAccessDenied, models.ActionUpdate, secret.ID)
+		}
+		revisions[secret.ID] = secret.Revision
+	}
+
+	// The store renames only the secrets checked above, refusing if any changed or joined the folder since
+	count, err := s.repo.RenameFolder(ctx, from, to, revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RenameFolderResponse{Renamed: count}, nil
+}
+
+// normalizeFolder trims a folder path to clean slash-separated segments; "" is the root
+func normalizeFolder(folder string) (string, error) {
+	var segments []string
+	for _, segment := range strings.Split(folder, "/") {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+		if segment == "." || segment == ".." {
+			return "", fmt.Errorf("%w: folder %q contains a relative segment", ErrValidation, folder)
+		}
+		segments = append(segments, segment)
+	}
+
+	normalized := strings.Join(segments, "/")
+	if len(normalized) > maxFolderLength {
+		return "", fmt.Errorf("%w: folder path is too long", ErrValidation)
+	}
+
+	return normalized, nil
+}
+
+// normalizeTags trims, de-duplicates and sorts tags
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("%w: tag %q is too long", ErrValidation, tag)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	sort.Strings(normalized)
+	return normalized, nil
+}
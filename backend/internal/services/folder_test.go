@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"my-vault/internal/models"
+)
+
+func TestRenameFolder(t *testing.T) {
+	ctx := ownerContext()
+	service, vault := newTestSecretService(t)
+
+	_, err := service.policyService.Create(ctx, &models.CreatePolicyRequest{
+		Name: "ops-writers",
+		Document: models.PolicyDocument{Statements: []models.PolicyStatement{
+			{Effect: models.EffectAllow, Actions: []string{models.ActionUpdate}, Resource: "secrets", Conditions: map[string][]string{"tag": {"ops"}}},
+		}},
+		Subjects: []models.PolicySubject{{Type: models.SubjectUser, ID: "bob"}},
+	})
+	if err != nil {
+		t.Fatalf("Create policy: %v", err)
+	}
+	bob := WithPrincipal(context.Background(), Principal{User: "bob"})
+
+	pager, err := service.Create(ctx, &models.CreateSecretRequest{Title: "Pager", Type: "api_token", Value: "pd_123", Folder: "ops", Tags: []string{"ops"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	stripe, err := service.Create(ctx, &models.CreateSecretRequest{Title: "Stripe", Type: "api_token", Value: "sk_live_123", Folder: "ops/billing"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// One secret the caller may not update stops the whole rename
+	if _, err := service.RenameFolder(bob, &models.RenameFolderRequest{From: "ops", To: "oncall"}); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("RenameFolder over a secret without update: got %v, want ErrAccessDenied", err)
+	}
+	if got, err := service.Get(ctx, pager.ID); err != nil || got.Folder != "ops" {
+		t.Errorf("Get after a refused rename = %v, %v; want it left in ops", got, err)
+	}
+
+	result, err := service.RenameFolder(ctx, &models.RenameFolderRequest{From: "ops", To: "oncall"})
+	if err != nil {
+		t.Fatalf("RenameFolder: %v", err)
+	}
+	if result.Renamed != 2 {
+		t.Errorf("RenameFolder renamed %d secrets, want 2", result.Renamed)
+	}
+	if got, err := service.Get(ctx, stripe.ID); err != nil || got.Folder != "oncall/billing" || got.Revision != stripe.Revision+1 {
+		t.Errorf("Get after rename = %v, %v; want oncall/billing at revision %d", got, err, stripe.Revision+1)
+	}
+
+	vault.Lock()
+	if _, err := service.RenameFolder(ctx, &models.RenameFolderRequest{From: "oncall", To: "ops"}); !errors.Is(err, ErrLocked) {
+		t.Errorf("RenameFolder while locked: got %v, want ErrLocked", err)
+	}
+	if _, err := service.ListFolders(ctx); !errors.Is(err, ErrLocked) {
+		t.Errorf("ListFolders while locked: got %v, want ErrLocked", err)
+	}
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"my-vault/internal/models"
+	"my-vault/internal/utils"
+)
+
+const (
+	// Password length bounds and default
+	minPasswordLength     = 4
+	maxPasswordLength     = 256
+	defaultPasswordLength = 20
+
+	// Passphrase word count bounds and default
+	minPassphraseWords     = 3
+	maxPassphraseWords     = 20
+	defaultPassphraseWords = 6
+
+	// ambiguousCharacters are easily confused when read or typed by hand
+	ambiguousCharacters = "0Oo1lI|`'\""
+)
+
+// characterClasses are the sets a generated password draws from
+var characterClasses = []struct {
+	name  string
+	chars string
+}{
+	{"lowercase", "abcdefghijklmnopqrstuvwxyz"},
+	{"uppercase", "ABCDEFGHIJKLMNOPQRSTUVWXYZ"},
+	{"digits", "0123456789"},
+	{"symbols", "!@#$%^&*()-_=+[]{};:,.<>?/~"},
+}
+
+// Generate creates a random password, diceware passphrase or SSH private key
+func Generate(req *models.GenerateRequest) (*models.GenerateResponse, error) {
+	switch req.Mode {
+	case "", models.GenerateModePassword:
+		return generatePassword(req)
+	case models.GenerateModePassphrase:
+		return generatePassphrase(req)
+	case models.GenerateModeSSHKey:
+		return generateSSHKey(req)
+	default:
+		return nil, fmt.Errorf("%w: unknown generator mode %q", ErrValidation, req.Mode)
+	}
+}
+
+// generatePassword draws characters uniformly from the enabled classes,
+// retrying until every enabled class is present
+func generatePassword(req *models.GenerateRequest) (*models.GenerateResponse, error) {
+	length := req.Length
+	if length == 0 {
+		length = defaultPasswordLength
+	}
+	if length < minPasswordLength || length > maxPasswordLength {
+		return nil, fmt.Errorf("%w: length must be between %d and %d", ErrValidation, minPasswordLength, maxPasswordLength)
+	}
+
+	enabled := []*bool{req.Lowercase, req.Uppercase, req.Digits, req.Symbols}
+	var classes []string
+	for i, class := range characterClasses {
+		if enabled[i] != nil && !*enabled[i] {
+			continue
+		}
+
+		chars := class.chars
+		if req.ExcludeAmbiguous {
+			chars = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(ambiguousCharacters, r) {
+					return -1
+				}
+				return r
+			}, chars)
+		}
+		classes = append(classes, chars)
+	}
+	if len(classes) == 0 {
+		return nil, fmt.Errorf("%w: at least one character class must be enabled", ErrValidation)
+	}
+	if length < len(classes) {
+		return nil, fmt.Errorf("%w: length must be at least %d to include every character class", ErrValidation, len(classes))
+	}
+
+	pool := strings.Join(classes, "")
+	password := make([]byte, length)
+	for {
+		for i := range password {
+			n, err := utils.RandomIndex(len(pool))
+			if err != nil {
+				return nil, err
+			}
+			password[i] = pool[n]
+		}
+
+		if containsEveryClass(string(password), classes) {
+			break
+		}
+	}
+
+	return &models.GenerateResponse{
+		Mode:    models.GenerateModePassword,
+		Value:   string(password),
+		Entropy: passwordEntropy(length, classes),
+	}, nil
+}
+
+// generatePassphrase picks words uniformly from the bundled diceware wordlist
+func generatePassphrase(req *models.GenerateRequest) (*models.GenerateResponse, error) {
+	count := req.Words
+	if count == 0 {
+		count = defaultPassphraseWords
+	}
+	if count < minPassphraseWords || count > maxPassphraseWords {
+		return nil, fmt.Errorf("%w: words must be between %d and %d", ErrValidation, minPassphraseWords, maxPassphraseWords)
+	}
+
+	separator := "-"
+	if req.Separator != nil {
+		separator = *req.Separator
+	}
+
+	wordlist := utils.Wordlist()
+	words := make([]string, count)
+	for i := range words {
+		n, err := utils.RandomIndex(len(wordlist))
+		if err != nil {
+			return nil, err
+		}
+		words[i] = wordlist[n]
+		if req.Capitalize {
+			words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+		}
+	}
+
+	entropy := float64(count) * math.Log2(float64(len(wordlist)))
+
+	// Append a random digit to a random word
+	if req.IncludeNumber {
+		word, err := utils.RandomIndex(count)
+		if err != nil {
+			return nil, err
+		}
+		digit, err := utils.RandomIndex(10)
+		if err != nil {
+			return nil, err
+		}
+		words[word] += strconv.Itoa(digit)
+		entropy += math.Log2(float64(10 * count))
+	}
+
+	return &models.GenerateResponse{
+		Mode:    models.GenerateModePassphrase,
+		Value:   strings.Join(words, separator),
+		Entropy: roundEntropy(entropy),
+	}, nil
+}
+
+// containsEveryClass reports whether password has a character from each class
+func containsEveryClass(password string, classes []string) bool {
+	for _, class := range classes {
+		if !strings.ContainsAny(password, class) {
+			return false
+		}
+	}
+	return true
+}
+
+// passwordEntropy computes log2 of the number of passwords of the given length
+// that contain every class, counted by inclusion-exclusion over missing classes
+func passwordEntropy(length int, classes []string) float64 {
+	count := new(big.Int)
+	for mask := 0; mask < 1<<len(classes); mask++ {
+		size, missing := 0, 0
+		for i, class := range classes {
+			if mask&(1<<i) != 0 {
+				missing++
+				continue
+			}
+			size += len(class)
+		}
+
+		term := new(big.Int).Exp(big.NewInt(int64(size)), big.NewInt(int64(length)), nil)
+		if missing%2 == 1 {
+			count.Sub(count, term)
+		} else {
+			count.Add(count, term)
+		}
+	}
+
+	mantissa := new(big.Float)
+	exponent := new(big.Float).SetInt(count).MantExp(mantissa)
+	f, _ := mantissa.Float64()
+	return roundEntropy(math.Log2(f) + float64(exponent))
+}
+
+// roundEntropy rounds an entropy estimate to one decimal place
+func roundEntropy(bits float64) float64 {
+	return math.Round(bits*10) / 10
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"unicode"
+
+	"my-vault/internal/models"
+	"my-vault/internal/utils"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestGeneratePassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.GenerateRequest
+		length  int
+		classes []string
+	}{
+		{
+			name:    "defaults",
+			req:     models.GenerateRequest{},
+			length:  defaultPasswordLength,
+			classes: []string{"lowercase", "uppercase", "digits", "symbols"},
+		},
+		{
+			name:    "minimum length with every class",
+			req:     models.GenerateRequest{Mode: models.GenerateModePassword, Length: minPasswordLength},
+			length:  minPasswordLength,
+			classes: []string{"lowercase", "uppercase", "digits", "symbols"},
+		},
+		{
+			name:    "maximum length",
+			req:     models.GenerateRequest{Length: maxPasswordLength},
+			length:  maxPasswordLength,
+			classes: []string{"lowercase", "uppercase", "digits", "symbols"},
+		},
+		{
+			name:    "digits only",
+			req:     models.GenerateRequest{Length: 8, Lowercase: boolPtr(false), Uppercase: boolPtr(false), Symbols: boolPtr(false)},
+			length:  8,
+			classes: []string{"digits"},
+		},
+		{
+			name:    "letters without ambiguous characters",
+			req:     models.GenerateRequest{Length: 64, Digits: boolPtr(false), Symbols: boolPtr(false), ExcludeAmbiguous: true},
+			length:  64,
+			classes: []string{"lowercase", "uppercase"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Repeat so a class missing by chance would show up
+			for range 50 {
+				resp, err := Generate(&tt.req)
+				if err != nil {
+					t.Fatalf("Generate: %v", err)
+				}
+				if resp.Mode != models.GenerateModePassword || len(resp.Value) != tt.length {
+					t.Fatalf("Generate = %s password of length %d, want password of length %d", resp.Mode, len(resp.Value), tt.length)
+				}
+
+				for _, class := range characterClasses {
+					present := strings.ContainsAny(resp.Value, class.chars)
+					if want := slices.Contains(tt.classes, class.name); present != want {
+						t.Fatalf("%q: %s present = %v, want %v", resp.Value, class.name, present, want)
+					}
+				}
+				if tt.req.ExcludeAmbiguous && strings.ContainsAny(resp.Value, ambiguousCharacters) {
+					t.Fatalf("%q contains ambiguous characters", resp.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratePasswordEntropy(t *testing.T) {
+	// A single class has no missing-class exclusions: length * log2(size)
+	resp, err := Generate(&models.GenerateRequest{Length: 10, Lowercase: boolPtr(false), Uppercase: boolPtr(false), Symbols: boolPtr(false)})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if want := roundEntropy(10 * math.Log2(10)); resp.Entropy != want {
+		t.Errorf("Entropy = %v, want %v", resp.Entropy, want)
+	}
+
+	// Requiring every class removes some passwords, so the estimate falls below the full pool
+	resp, err = Generate(&models.GenerateRequest{Length: 8})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	pool := 0
+	for _, class := range characterClasses {
+		pool += len(class.chars)
+	}
+	if full := 8 * math.Log2(float64(pool)); resp.Entropy >= full || resp.Entropy < full-2 {
+		t.Errorf("Entropy = %v, want less than 2 bits below %v", resp.Entropy, full)
+	}
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	wordlist := utils.Wordlist()
+
+	// A few wordlist entries contain a hyphen, so words are split on other separators
+	tests := []struct {
+		name      string
+		req       models.GenerateRequest
+		words     int
+		separator string
+	}{
+		{
+			name:      "default word count",
+			req:       models.GenerateRequest{Mode: models.GenerateModePassphrase, Separator: strPtr(" ")},
+			words:     defaultPassphraseWords,
+			separator: " ",
+		},
+		{
+			name:      "minimum words",
+			req:       models.GenerateRequest{Mode: models.GenerateModePassphrase, Words: minPassphraseWords, Separator: strPtr(".")},
+			words:     minPassphraseWords,
+			separator: ".",
+		},
+		{
+			name:      "maximum words",
+			req:       models.GenerateRequest{Mode: models.GenerateModePassphrase, Words: maxPassphraseWords, Separator: strPtr(" ")},
+			words:     maxPassphraseWords,
+			separator: " ",
+		},
+		{
+			name:      "capitalized with a number",
+			req:       models.GenerateRequest{Mode: models.GenerateModePassphrase, Words: 4, Separator: strPtr("_"), Capitalize: true, IncludeNumber: true},
+			words:     4,
+			separator: "_",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := Generate(&tt.req)
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if resp.Mode != models.GenerateModePassphrase {
+				t.Errorf("Mode = %s, want passphrase", resp.Mode)
+			}
+
+			words := strings.Split(resp.Value, tt.separator)
+			if len(words) != tt.words {
+				t.Fatalf("%q has %d words, want %d", resp.Value, len(words), tt.words)
+			}
+
+			digits := 0
+			for _, word := range words {
+				if tt.req.IncludeNumber && unicode.IsDigit(rune(word[len(word)-1])) {
+					word = word[:len(word)-1]
+					digits++
+				}
+				if tt.req.Capitalize {
+					if !unicode.IsUpper(rune(word[0])) {
+						t.Errorf("word %q is not capitalized", word)
+					}
+					word = strings.ToLower(word[:1]) + word[1:]
+				}
+				if !slices.Contains(wordlist, word) {
+					t.Errorf("word %q is not from the wordlist", word)
+				}
+			}
+			if tt.req.IncludeNumber && digits != 1 {
+				t.Errorf("%q has %d words ending in a digit, want 1", resp.Value, digits)
+			}
+
+			entropy := float64(tt.words) * math.Log2(float64(len(wordlist)))
+			if tt.req.IncludeNumber {
+				entropy += math.Log2(float64(10 * tt.words))
+			}
+			if want := roundEntropy(entropy); resp.Entropy != want {
+				t.Errorf("Entropy = %v, want %v", resp.Entropy, want)
+			}
+		})
+	}
+
+	// Words are joined with a hyphen unless asked otherwise, and directly with an empty separator
+	resp, err := Generate(&models.GenerateRequest{Mode: models.GenerateModePassphrase})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if hyphens := strings.Count(resp.Value, "-"); hyphens < defaultPassphraseWords-1 {
+		t.Errorf("%q has %d hyphens, want at least %d", resp.Value, hyphens, defaultPassphraseWords-1)
+	}
+
+	resp, err = Generate(&models.GenerateRequest{Mode: models.GenerateModePassphrase, Words: minPassphraseWords, Separator: strPtr("")})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if strings.ContainsAny(resp.Value, " _.") {
+		t.Errorf("%q contains a separator, want none", resp.Value)
+	}
+}
+
+func TestGenerateRejectsInvalidRequests(t *testing.T) {
+	none := boolPtr(false)
+
+	tests := []struct {
+		name string
+		req  models.GenerateRequest
+	}{
+		{name: "unknown mode", req: models.GenerateRequest{Mode: "pin"}},
+		{name: "password too short", req: models.GenerateRequest{Length: minPasswordLength - 1}},
+		{name: "password too long", req: models.GenerateRequest{Length: maxPasswordLength + 1}},
+		{name: "negative length", req: models.GenerateRequest{Length: -1}},
+		{name: "no character class", req: models.GenerateRequest{Lowercase: none, Uppercase: none, Digits: none, Symbols: none}},
+		{name: "too few words", req: models.GenerateRequest{Mode: models.GenerateModePassphrase, Words: minPassphraseWords - 1}},
+		{name: "too many words", req: models.GenerateRequest{Mode: models.GenerateModePassphrase, Words: maxPassphraseWords + 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Generate(&tt.req); !errors.Is(err, ErrValidation) {
+				t.Errorf("Generate: got %v, want ErrValidation", err)
+			}
+		})
+	}
+}
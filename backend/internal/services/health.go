@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"my-vault/internal/models"
+	"my-vault/internal/utils"
+
+	"github.com/nbutton23/zxcvbn-go"
+)
+
+const (
+	// DefaultHealthMinScore is the lowest zxcvbn score not reported as weak
+	DefaultHealthMinScore = 3
+
+	// DefaultHealthMaxAge reports secrets not updated within 90 days
+	DefaultHealthMaxAge = 90 * 24 * time.Hour
+
+	// maxStrengthInput bounds the characters fed to the strength estimator,
+	// which slows down sharply on long inputs
+	maxStrengthInput = 100
+
+	// reuseHashPurpose separates the reuse-detection key from other subkeys
+	reuseHashPurpose = "my-vault health reuse v1"
+)
+
+// credentialFields lists, per secret type, the typed fields holding credentials
+// checked for strength and reuse
+var credentialFields = map[string][]string{
+	"login":               {"password"},
+	"database_credential": {"password"},
+	"api_token":           {"token"},
+	"ssh_key":             {"passphrase"},
+}
+
+// HealthReport decrypts every secret the caller may read and reports weak,
+// reused and breached credentials, secrets not updated within opts.MaxAge and
+// secrets missing fields their type expects. Reuse is detected by comparing
+// keyed hashes, never plaintext values.
+func (s *SecretService) HealthReport(ctx context.Context, opts models.HealthReportOptions) (*models.HealthReport, error) {
+	key, err := s.vaultService.GetKey()
+	if err != nil {
+		return nil, fmt.Errorf("vault is locked: %w", err)
+	}
+
+	hashKey, err := utils.DeriveSubkey(key, reuseHashPurpose)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &models.HealthReport{
+		GeneratedAt:        now,
+		BreachCheckEnabled: s.breaches != nil,
+		Weak:               []models.WeakSecret{},
+		Reused:             []models.ReusedValue{},
+		Breached:           []models.BreachedSecret{},
+		Old:                []models.OldSecret{},
+		Incomplete:         []models.IncompleteSecret{},
+	}
+	byHash := make(map[string][]models.SecretFieldRef)
+
+	report.SecretsScanned, err = s.forEachReadable(ctx, key, func(secret *models.Secret, data map[string]any) error {
+		for _, name := range credentialFields[secret.Type] {
+			value, _ := data[name].(string)
+			if value == "" {
+				continue
+			}
+			ref := models.SecretFieldRef{ID: secret.ID, Title: secret.Title, Field: name}
+
+			if weak, ok := weakCredential(ref, value, credentialContext(secret, data), opts.MinScore); ok {
+				report.Weak = append(report.Weak, weak)
+			}
+
+			hash := utils.KeyedHash(hashKey, value)
+			byHash[hash] = append(byHash[hash], ref)
+
+			if s.breaches != nil {
+				breached, ok, err := s.breachedCredential(ref, value)
+				if err != nil {
+					return err
+				}
+				if ok {
+					report.Breached = append(report.Breached, breached)
+				}
+			}
+		}
+
+		if opts.MaxAge > 0 && now.Sub(secret.UpdatedAt) > opts.MaxAge {
+			report.Old = append(report.Old, models.OldSecret{
+				ID:        secret.ID,
+				Title:     secret.Title,
+				UpdatedAt: secret.UpdatedAt,
+				AgeDays:   int(now.Sub(secret.UpdatedAt).Hours() / 24),
+			})
+		}
+
+		if missing := missingExpectedFields(secret.Type, data); len(missing) > 0 {
+			report.Incomplete = append(report.Incomplete, models.IncompleteSecret{
+				ID:            secret.ID,
+				Title:         secret.Title,
+				Type:          secret.Type,
+				MissingFields: missing,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, refs := range byHash {
+		if len(refs) > 1 {
+			report.Reused = append(report.Reused, models.ReusedValue{Count: len(refs), Secrets: refs})
+		}
+	}
+	sort.Slice(report.Reused, func(i, j int) bool {
+		if report.Reused[i].Count != report.Reused[j].Count {
+			return report.Reused[i].Count > report.Reused[j].Count
+		}
+		return report.Reused[i].Secrets[0].ID < report.Reused[j].Secrets[0].ID
+	})
+	sort.Slice(report.Old, func(i, j int) bool { return report.Old[i].UpdatedAt.Before(report.Old[j].UpdatedAt) })
+
+	return report, nil
+}
+
+// forEachReadable decrypts every secret the caller may read and passes it with
+// its typed fields to fn, returning how many secrets were visited
+func (s *SecretService) forEachReadable(ctx context.Context, key []byte, fn func(secret *models.Secret, data map[string]any) error) (int, error) {
+	secrets, err := s.repo.List(ctx, models.SecretFilter{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	evaluator, err := s.policyService.Evaluator(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	visited := 0
+	for _, secret := range secrets {
+		if !evaluator.Allows(models.ActionRead, secretAttributes(secret)) {
+			continue
+		}
+		visited++
+
+		plaintext, err := utils.Decrypt(secret.EncryptedValue, key)
+		if err != nil {
+			return 0, fmt.Errorf("secret %s: failed to decrypt secret: %w", secret.ID, err)
+		}
+
+		_, data := decodePayload(secret.Type, plaintext)
+		if err := fn(secret, data); err != nil {
+			return 0, err
+		}
+	}
+
+	return visited, nil
+}
+
+// weakCredential estimates the strength of a credential, penalizing values
+// derived from the secret's own metadata
+func weakCredential(ref models.SecretFieldRef, value string, userInputs []string, minScore int) (models.WeakSecret, bool) {
+	if len(value) > maxStrengthInput {
+		value = value[:maxStrengthInput]
+	}
+
+	result := zxcvbn.PasswordStrength(value, userInputs)
+	if result.Score >= minScore {
+		return models.WeakSecret{}, false
+	}
+
+	return models.WeakSecret{
+		SecretFieldRef: ref,
+		Score:          result.Score,
+		CrackTime:      result.CrackTimeDisplay,
+	}, true
+}
+
+// credentialContext collects plain values a credential should not be derived from
+func credentialContext(secret *models.Secret, data map[string]any) []string {
+	inputs := []string{secret.Title}
+	for _, name := range []string{"username", "host", "database"} {
+		if value, ok := data[name].(string); ok && value != "" {
+			inputs = append(inputs, value)
+		}
+	}
+	return inputs
+}
+
+// missingExpectedFields lists the expected fields of a type absent from data
+func missingExpectedFields(typeName string, data map[string]any) []string {
+	t, ok := secretTypes[typeName]
+	if !ok {
+		return nil
+	}
+
+	var missing []string
+	for _, spec := range t.fields {
+		if !spec.expected {
+			continue
+		}
+		if isEmptyValue(data[spec.name]) {
+			missing = append(missing, spec.name)
+		}
+	}
+	return missing
+}
+
+// isEmptyValue reports whether a decoded field value is missing, blank or an empty list
+func isEmptyValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	case []string:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
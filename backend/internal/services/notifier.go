@@ -0,0 +1,74 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+// Notifier delivers reminders about secrets. Implementations must not block
+// for long; the reminder job calls them sequentially.
+type Notifier interface {
+	Notify(ctx context.Context, notification *models.Notification) error
+}
+
+// LogNotifier writes reminders to the server log
+type LogNotifier struct{}
+
+// NewLogNotifier creates a notifier writing to the server log
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Notify logs the reminder
+func (n *LogNotifier) Notify(ctx context.Context, notification *models.Notification) error {
+	log.Printf("Reminder: secret %q (%s) is %s at %s",
+		notification.Title, notification.SecretID, notification.Kind, notification.DueAt.Format(time.RFC3339))
+	return nil
+}
+
+// WebhookNotifier posts reminders as JSON to an HTTP endpoint
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify posts the reminder, treating any non-2xx response as a failure
+func (n *WebhookNotifier) Notify(ctx context.Context, notification *models.Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
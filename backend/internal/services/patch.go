@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"my-vault/internal/models"
+	"my-vault/internal/utils"
+)
+
+// patchableFields are the members of models.UpdateSecretRequest a merge patch may set
+var patchableFields = []string{"title", "type", "value", "data", "fields", "folder", "tags", "expires_at", "rotate_every"}
+
+// Patch applies a JSON merge patch (RFC 7396) to a secret, provided it is still
+// at the revision the caller read. The patch is merged into the secret in its
+// update request form and the result is validated as a whole, as by Update.
+// Members left out keep their value; null removes them.
+func (s *SecretService) Patch(ctx context.Context, id string, revision int, patch []byte) (*models.SecretResponse, error) {
+	var changes map[string]any
+	if err := json.Unmarshal(patch, &changes); err != nil || changes == nil {
+		return nil, fmt.Errorf("%w: a merge patch must be a JSON object", ErrValidation)
+	}
+	for name := range changes {
+		if !slices.Contains(patchableFields, name) {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrValidation, name)
+		}
+	}
+
+	// Get encryption key from vault
+	key, err := s.vaultService.GetKey()
+	if err != nil {
+		return nil, fmt.Errorf("vault is locked: %w", err)
+	}
+
+	secret, evaluator, err := s.getForUpdate(ctx, id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := updateDocument(secret, key)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := decodeUpdateDocument(mergePatch(current, changes))
+	if err != nil {
+		return nil, err
+	}
+
+	// The primary field is carried as value; a patch setting it through data alone wins
+	if _, ok := changes["value"]; !ok {
+		if t, known := secretTypes[req.Type]; known && req.Data[t.primary] != nil {
+			req.Value = ""
+		}
+	}
+
+	if req.Title == "" || req.Type == "" {
+		return nil, fmt.Errorf("%w: title and type are required", ErrValidation)
+	}
+
+	return s.update(ctx, key, secret, evaluator, req)
+}
+
+// updateDocument returns a secret as the JSON object of the update request that
+// would recreate it, its primary field given as value only
+func updateDocument(secret *models.Secret, key []byte) (map[string]any, error) {
+	plaintext, err := utils.Decrypt(secret.EncryptedValue, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	value, data := decodePayload(secret.Type, plaintext)
+	if t, ok := secretTypes[secret.Type]; ok {
+		data = maps.Clone(data)
+		delete(data, t.primary)
+	}
+
+	fields, err := decodeFields(secret.Fields, key)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(&models.UpdateSecretRequest{
+		Title:       secret.Title,
+		Type:        secret.Type,
+		Value:       value,
+		Data:        data,
+		Fields:      fields,
+		Folder:      secret.Folder,
+		Tags:        secret.Tags,
+		ExpiresAt:   secret.ExpiresAt,
+		RotateEvery: formatRotateEvery(secret.RotateEvery),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode secret: %w", err)
+	}
+
+	var document map[string]any
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, fmt.Errorf("failed to encode secret: %w", err)
+	}
+
+	return document, nil
+}
+
+// decodeUpdateDocument reads a merged document back as an update request
+func decodeUpdateDocument(document any) (*models.UpdateSecretRequest, error) {
+	raw, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode patched secret: %w", err)
+	}
+
+	var req models.UpdateSecretRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return nil, fmt.Errorf("%w: patched secret is invalid: %v", ErrValidation, err)
+	}
+
+	return &req, nil
+}
+
+// mergePatch applies a JSON merge patch to a decoded JSON document, as RFC 7396
+// describes: objects are merged member by member, null removes a member and
+// anything else replaces the target
+func mergePatch(target, patch any) any {
+	changes, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	merged, ok := target.(map[string]any)
+	if ok {
+		merged = maps.Clone(merged)
+	} else {
+		merged = make(map[string]any, len(changes))
+	}
+
+	for name, change := range changes {
+		if change == nil {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = mergePatch(merged[name], change)
+	}
+
+	return merged
+}
@@ -0,0 +1,381 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"my-vault/internal/models"
+	"my-vault/internal/repository"
+)
+
+// policyResource is the only resource policies currently govern
+const policyResource = "secrets"
+
+// conditionKeys lists the secret attributes policy conditions may match on
+var conditionKeys = map[string]bool{
+	"id":     true,
+	"title":  true,
+	"type":   true,
+	"folder": true,
+	"tag":    true,
+}
+
+// policyActions lists the actions policy statements may grant or deny
+var policyActions = map[string]bool{
+	models.ActionRead:   true,
+	models.ActionCreate: true,
+	models.ActionUpdate: true,
+	models.ActionDelete: true,
+	"*":                 true,
+}
+
+// Principal identifies the caller of a request. Owner is only set once the
+// caller has presented the owner credential; a principal with no identity at
+// all is anonymous and denied everything.
+type Principal struct {
+	Owner  bool
+	User   string
+	Token  string
+	Groups []string
+}
+
+// IsOwner reports whether the principal is the vault owner, who bypasses policies
+func (p Principal) IsOwner() bool {
+	return p.Owner
+}
+
+// IsAnonymous reports whether the caller was not identified at all
+func (p Principal) IsAnonymous() bool {
+	return !p.Owner && len(p.Subjects()) == 0
+}
+
+// Subjects returns the policy subjects the principal matches
+func (p Principal) Subjects() []models.PolicySubject {
+	var subjects []models.PolicySubject
+	if p.User != "" {
+		subjects = append(subjects, models.PolicySubject{Type: models.SubjectUser, ID: p.User})
+	}
+	if p.Token != "" {
+		subjects = append(subjects, models.PolicySubject{Type: models.SubjectToken, ID: p.Token})
+	}
+	for _, group := range p.Groups {
+		subjects = append(subjects, models.PolicySubject{Type: models.SubjectGroup, ID: group})
+	}
+	return subjects
+}
+
+// String returns a short label for the principal, used for authorship
+func (p Principal) String() string {
+	switch {
+	case p.Owner:
+		return "owner"
+	case p.User != "":
+		return models.SubjectUser + ":" + p.User
+	case p.Token != "":
+		return models.SubjectToken + ":" + p.Token
+	case len(p.Groups) > 0:
+		return models.SubjectGroup + ":" + strings.Join(p.Groups, ",")
+	default:
+		return "anonymous"
+	}
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the request principal
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the request principal, or an anonymous one if none is set
+func PrincipalFromContext(ctx context.Context) Principal {
+	principal, _ := ctx.Value(principalKey{}).(Principal)
+	return principal
+}
+
+type evaluatorKey struct{}
+
+// WithEvaluator returns a context carrying the caller's loaded policies for the rest of the request
+func WithEvaluator(ctx context.Context, evaluator *PolicyEvaluator) context.Context {
+	return context.WithValue(ctx, evaluatorKey{}, evaluator)
+}
+
+// PolicyService handles business logic for authorization policies
+type PolicyService struct {
+	repo repository.PolicyStore
+}
+
+// NewPolicyService creates a new policy service
+func NewPolicyService(repo repository.PolicyStore) *PolicyService {
+	return &PolicyService{
+		repo: repo,
+	}
+}
+
+// Create validates and stores a new policy
+func (s *PolicyService) Create(ctx context.Context, req *models.CreatePolicyRequest) (*models.Policy, error) {
+	policy := &models.Policy{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Document:    req.Document,
+		Subjects:    req.Subjects,
+	}
+
+	if err := validatePolicy(policy); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, policy); err != nil {
+		return nil, fmt.Errorf("failed to save policy: %w", err)
+	}
+
+	return normalizePolicy(policy), nil
+}
+
+// Get retrieves a policy by ID
+func (s *PolicyService) Get(ctx context.Context, id string) (*models.Policy, error) {
+	policy, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return normalizePolicy(policy), nil
+}
+
+// List retrieves all policies
+func (s *PolicyService) List(ctx context.Context) ([]*models.Policy, error) {
+	policies, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, policy := range policies {
+		normalizePolicy(policy)
+	}
+
+	return policies, nil
+}
+
+// Update validates and replaces an existing policy
+func (s *PolicyService) Update(ctx context.Context, id string, req *models.UpdatePolicyRequest) (*models.Policy, error) {
+	policy, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.Name = strings.TrimSpace(req.Name)
+	policy.Description = req.Description
+	policy.Document = req.Document
+	policy.Subjects = req.Subjects
+
+	if err := validatePolicy(policy); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, policy); err != nil {
+		return nil, fmt.Errorf("failed to update policy: %w", err)
+	}
+
+	return normalizePolicy(policy), nil
+}
+
+// Delete removes a policy
+func (s *PolicyService) Delete(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Evaluator loads the policies that apply to the caller in ctx,
+// reusing an evaluator already attached with WithEvaluator
+func (s *PolicyService) Evaluator(ctx context.Context) (*PolicyEvaluator, error) {
+	if evaluator, ok := ctx.Value(evaluatorKey{}).(*PolicyEvaluator); ok {
+		return evaluator, nil
+	}
+
+	principal := PrincipalFromContext(ctx)
+	if principal.IsOwner() {
+		return &PolicyEvaluator{owner: true}, nil
+	}
+	if principal.IsAnonymous() {
+		return &PolicyEvaluator{}, nil
+	}
+
+	policies, err := s.repo.ListForSubjects(ctx, principal.Subjects())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policies: %w", err)
+	}
+
+	evaluator := &PolicyEvaluator{}
+	for _, policy := range policies {
+		evaluator.statements = append(evaluator.statements, policy.Document.Statements...)
+	}
+
+	return evaluator, nil
+}
+
+// Authorize checks whether the caller in ctx may perform action on a secret with attrs
+func (s *PolicyService) Authorize(ctx context.Context, action string, attrs map[string][]string) error {
+	evaluator, err := s.Evaluator(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !evaluator.Allows(action, attrs) {
+		return fmt.Errorf("%w: %s not permitted", ErrAccessDenied, action)
+	}
+
+	return nil
+}
+
+// PolicyEvaluator decides access for one caller against its attached policies
+type PolicyEvaluator struct {
+	owner      bool
+	statements []models.PolicyStatement
+}
+
+// Allows reports whether action is permitted on a secret with attrs.
+// An explicit deny always wins; without a matching allow the action is denied.
+func (e *PolicyEvaluator) Allows(action string, attrs map[string][]string) bool {
+	if e.owner {
+		return true
+	}
+
+	allowed := false
+	for _, statement := range e.statements {
+		if !statementApplies(statement, action) || !conditionsMatch(statement.Conditions, attrs) {
+			continue
+		}
+		if statement.Effect == models.EffectDeny {
+			return false
+		}
+		allowed = true
+	}
+
+	return allowed
+}
+
+// MayPerform reports whether action could be allowed on at least some secrets.
+// It is used to reject requests early, before any secret is loaded.
+func (e *PolicyEvaluator) MayPerform(action string) bool {
+	if e.owner {
+		return true
+	}
+
+	allowed := false
+	for _, statement := range e.statements {
+		if !statementApplies(statement, action) {
+			continue
+		}
+		if statement.Effect == models.EffectDeny && len(statement.Conditions) == 0 {
+			return false
+		}
+		if statement.Effect == models.EffectAllow {
+			allowed = true
+		}
+	}
+
+	return allowed
+}
+
+// statementApplies reports whether a statement covers the action on secrets
+func statementApplies(statement models.PolicyStatement, action string) bool {
+	if statement.Resource != policyResource && statement.Resource != "*" {
+		return false
+	}
+
+	for _, a := range statement.Actions {
+		if a == action || a == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// conditionsMatch reports whether every condition matches at least one attribute value
+func conditionsMatch(conditions map[string][]string, attrs map[string][]string) bool {
+	for key, patterns := range conditions {
+		if !anyMatch(patterns, attrs[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// anyMatch reports whether any value matches any of the glob patterns
+func anyMatch(patterns, values []string) bool {
+	for _, pattern := range patterns {
+		for _, value := range values {
+			if ok, _ := path.Match(pattern, value); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validatePolicy checks a policy document and its subjects
+func validatePolicy(policy *models.Policy) error {
+	if policy.Name == "" {
+		return fmt.Errorf("%w: policy name is required", ErrValidation)
+	}
+
+	if len(policy.Document.Statements) == 0 {
+		return fmt.Errorf("%w: policy must contain at least one statement", ErrValidation)
+	}
+
+	for i, statement := range policy.Document.Statements {
+		if statement.Effect != models.EffectAllow && statement.Effect != models.EffectDeny {
+			return fmt.Errorf("%w: statement %d: effect must be %q or %q", ErrValidation, i, models.EffectAllow, models.EffectDeny)
+		}
+
+		if statement.Resource != policyResource && statement.Resource != "*" {
+			return fmt.Errorf("%w: statement %d: unknown resource %q", ErrValidation, i, statement.Resource)
+		}
+
+		if len(statement.Actions) == 0 {
+			return fmt.Errorf("%w: statement %d: at least one action is required", ErrValidation, i)
+		}
+		for _, action := range statement.Actions {
+			if !policyActions[action] {
+				return fmt.Errorf("%w: statement %d: unknown action %q", ErrValidation, i, action)
+			}
+		}
+
+		for key, patterns := range statement.Conditions {
+			if !conditionKeys[key] {
+				return fmt.Errorf("%w: statement %d: unknown condition %q", ErrValidation, i, key)
+			}
+			if len(patterns) == 0 {
+				return fmt.Errorf("%w: statement %d: condition %q has no values", ErrValidation, i, key)
+			}
+			for _, pattern := range patterns {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("%w: statement %d: invalid pattern %q", ErrValidation, i, pattern)
+				}
+			}
+		}
+	}
+
+	for _, subject := range policy.Subjects {
+		switch subject.Type {
+		case models.SubjectUser, models.SubjectGroup, models.SubjectToken:
+		default:
+			return fmt.Errorf("%w: unknown subject type %q", ErrValidation, subject.Type)
+		}
+		if subject.ID == "" {
+			return fmt.Errorf("%w: subject id is required", ErrValidation)
+		}
+	}
+
+	return nil
+}
+
+// normalizePolicy ensures empty collections serialize as arrays
+func normalizePolicy(policy *models.Policy) *models.Policy {
+	if policy.Subjects == nil {
+		policy.Subjects = []models.PolicySubject{}
+	}
+	return policy
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"my-vault/internal/models"
+	"my-vault/internal/repository"
+)
+
+func allow(actions []string, conditions map[string][]string) models.PolicyStatement {
+	return models.PolicyStatement{Effect: models.EffectAllow, Actions: actions, Resource: "secrets", Conditions: conditions}
+}
+
+func deny(actions []string, conditions map[string][]string) models.PolicyStatement {
+	return models.PolicyStatement{Effect: models.EffectDeny, Actions: actions, Resource: "secrets", Conditions: conditions}
+}
+
+func TestPolicyEvaluatorAllows(t *testing.T) {
+	prodToken := map[string][]string{"type": {"api_token"}, "folder": {"prod/payments"}, "tag": {"prod", "billing"}, "title": {"Stripe"}}
+	stagingToken := map[string][]string{"type": {"api_token"}, "folder": {"staging"}, "tag": {"staging"}, "title": {"Stripe test"}}
+
+	tests := []struct {
+		name       string
+		statements []models.PolicyStatement
+		action     string
+		attrs      map[string][]string
+		want       bool
+	}{
+		{
+			name:   "no statements deny",
+			action: models.ActionRead,
+			attrs:  prodToken,
+			want:   false,
+		},
+		{
+			name:       "unconditional allow",
+			statements: []models.PolicyStatement{allow([]string{models.ActionRead}, nil)},
+			action:     models.ActionRead,
+			attrs:      prodToken,
+			want:       true,
+		},
+		{
+			name:       "allow covers listed actions only",
+			statements: []models.PolicyStatement{allow([]string{models.ActionRead}, nil)},
+			action:     models.ActionDelete,
+			attrs:      prodToken,
+			want:       false,
+		},
+		{
+			name:       "wildcard action",
+			statements: []models.PolicyStatement{allow([]string{"*"}, nil)},
+			action:     models.ActionUpdate,
+			attrs:      prodToken,
+			want:       true,
+		},
+		{
+			name:       "other resource ignored",
+			statements: []models.PolicyStatement{{Effect: models.EffectAllow, Actions: []string{"*"}, Resource: "policies"}},
+			action:     models.ActionRead,
+			attrs:      prodToken,
+			want:       false,
+		},
+		{
+			name: "deny wins over allow",
+			statements: []models.PolicyStatement{
+				allow([]string{"*"}, nil),
+				deny([]string{models.ActionRead}, map[string][]string{"tag": {"prod"}}),
+			},
+			action: models.ActionRead,
+			attrs:  prodToken,
+			want:   false,
+		},
+		{
+			name: "deny wins whatever the order",
+			statements: []models.PolicyStatement{
+				deny([]string{"*"}, nil),
+				allow([]string{models.ActionRead}, map[string][]string{"tag": {"prod"}}),
+			},
+			action: models.ActionRead,
+			attrs:  prodToken,
+			want:   false,
+		},
+		{
+			name: "unmatched deny leaves allow",
+			statements: []models.PolicyStatement{
+				allow([]string{"*"}, nil),
+				deny([]string{models.ActionRead}, map[string][]string{"tag": {"prod"}}),
+			},
+			action: models.ActionRead,
+			attrs:  stagingToken,
+			want:   true,
+		},
+		{
+			name:       "all conditions must match",
+			statements: []models.PolicyStatement{allow([]string{models.ActionRead}, map[string][]string{"type": {"api_token"}, "tag": {"prod"}})},
+			action:     models.ActionRead,
+			attrs:      stagingToken,
+			want:       false,
+		},
+		{
+			name:       "any value of a condition may match",
+			statements: []models.PolicyStatement{allow([]string{models.ActionRead}, map[string][]string{"tag": {"ops", "billing"}})},
+			action:     models.ActionRead,
+			attrs:      prodToken,
+			want:       true,
+		},
+		{
+			name:       "condition on missing attribute",
+			statements: []models.PolicyStatement{allow([]string{models.ActionRead}, map[string][]string{"id": {"*"}})},
+			action:     models.ActionRead,
+			attrs:      prodToken,
+			want:       false,
+		},
+		{
+			name:       "glob on folder",
+			statements: []models.PolicyStatement{allow([]string{models.ActionRead}, map[string][]string{"folder": {"prod/*"}})},
+			action:     models.ActionRead,
+			attrs:      prodToken,
+			want:       true,
+		},
+		{
+			name:       "glob star stops at slash",
+			statements: []models.PolicyStatement{allow([]string{models.ActionRead}, map[string][]string{"folder": {"*"}})},
+			action:     models.ActionRead,
+			attrs:      prodToken,
+			want:       false,
+		},
+		{
+			name:       "glob character class and question mark",
+			statements: []models.PolicyStatement{allow([]string{models.ActionRead}, map[string][]string{"title": {"[A-Z]trip?"}})},
+			action:     models.ActionRead,
+			attrs:      prodToken,
+			want:       true,
+		},
+		{
+			name:       "glob is case sensitive",
+			statements: []models.PolicyStatement{allow([]string{models.ActionRead}, map[string][]string{"title": {"stripe"}})},
+			action:     models.ActionRead,
+			attrs:      prodToken,
+			want:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluator := &PolicyEvaluator{statements: tt.statements}
+			if got := evaluator.Allows(tt.action, tt.attrs); got != tt.want {
+				t.Errorf("Allows(%q) = %v, want %v", tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyEvaluatorMayPerform(t *testing.T) {
+	tests := []struct {
+		name       string
+		statements []models.PolicyStatement
+		action     string
+		want       bool
+	}{
+		{
+			name:   "no statements",
+			action: models.ActionRead,
+			want:   false,
+		},
+		{
+			name:       "conditional allow may apply to some secrets",
+			statements: []models.PolicyStatement{allow([]string{models.ActionRead}, map[string][]string{"tag": {"prod"}})},
+			action:     models.ActionRead,
+			want:       true,
+		},
+		{
+			name:       "allow for another action",
+			statements: []models.PolicyStatement{allow([]string{models.ActionRead}, nil)},
+			action:     models.ActionCreate,
+			want:       false,
+		},
+		{
+			name: "unconditional deny rules out every secret",
+			statements: []models.PolicyStatement{
+				allow([]string{"*"}, nil),
+				deny([]string{models.ActionDelete}, nil),
+			},
+			action: models.ActionDelete,
+			want:   false,
+		},
+		{
+			name: "conditional deny leaves other secrets",
+			statements: []models.PolicyStatement{
+				allow([]string{"*"}, nil),
+				deny([]string{models.ActionDelete}, map[string][]string{"folder": {"prod/*"}}),
+			},
+			action: models.ActionDelete,
+			want:   true,
+		},
+		{
+			name:       "deny alone",
+			statements: []models.PolicyStatement{deny([]string{"*"}, map[string][]string{"tag": {"prod"}})},
+			action:     models.ActionRead,
+			want:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluator := &PolicyEvaluator{statements: tt.statements}
+			if got := evaluator.MayPerform(tt.action); got != tt.want {
+				t.Errorf("MayPerform(%q) = %v, want %v", tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyServiceEvaluator(t *testing.T) {
+	ctx := context.Background()
+	db := repository.NewMemoryDB()
+	t.Cleanup(db.Close)
+	service := NewPolicyService(repository.NewMemoryPolicyRepository(db))
+
+	_, err := service.Create(ctx, &models.CreatePolicyRequest{
+		Name:     "ops-readers",
+		Document: models.PolicyDocument{Statements: []models.PolicyStatement{allow([]string{models.ActionRead}, nil)}},
+		Subjects: []models.PolicySubject{{Type: models.SubjectGroup, ID: "ops"}},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	attrs := map[string][]string{"type": {"login"}}
+	tests := []struct {
+		name      string
+		principal Principal
+		action    string
+		want      bool
+	}{
+		{name: "anonymous", principal: Principal{}, action: models.ActionRead, want: false},
+		{name: "owner", principal: Principal{Owner: true}, action: models.ActionDelete, want: true},
+		{name: "group member", principal: Principal{User: "alice", Groups: []string{"ops"}}, action: models.ActionRead, want: true},
+		{name: "group member other action", principal: Principal{User: "alice", Groups: []string{"ops"}}, action: models.ActionUpdate, want: false},
+		{name: "user without policies", principal: Principal{User: "bob"}, action: models.ActionRead, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.Authorize(WithPrincipal(ctx, tt.principal), tt.action, attrs)
+			if tt.want && err != nil {
+				t.Errorf("Authorize: %v, want allowed", err)
+			}
+			if !tt.want && !errors.Is(err, ErrAccessDenied) {
+				t.Errorf("Authorize: %v, want ErrAccessDenied", err)
+			}
+		})
+	}
+
+	if PrincipalFromContext(ctx).IsOwner() {
+		t.Error("a context without a principal must not act as the owner")
+	}
+}
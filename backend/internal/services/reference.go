@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"my-vault/internal/models"
+	"my-vault/internal/utils"
+)
+
+// maxReferenceDepth bounds how deeply references may be nested
+const maxReferenceDepth = 16
+
+// maxRenderedSize bounds the bytes one render may produce. Without it a few
+// secrets each referencing the next twice would expand exponentially.
+const maxRenderedSize = 1 << 20
+
+// referencePattern matches {{ref "secret"}} and {{ref "secret" "field"}}.
+// Arguments are Go string literals; any other {{...}} text is left as is.
+var referencePattern = regexp.MustCompile(`\{\{\s*ref\s+("(?:[^"\\]|\\.)*")(?:\s+("(?:[^"\\]|\\.)*"))?\s*\}\}`)
+
+// Render resolves the references a secret's value, typed fields and custom
+// fields make to other secrets. A reference names a secret by ID, by
+// folder/title path or by title, and optionally one of its fields; without a
+// field it resolves to the primary value. The caller must be allowed to read
+// every secret involved.
+func (s *SecretService) Render(ctx context.Context, id string) (*models.RenderedSecret, error) {
+	key, err := s.vaultService.GetKey()
+	if err != nil {
+		return nil, fmt.Errorf("vault is locked: %w", err)
+	}
+
+	secret, err := s.authorizedSecret(ctx, models.ActionRead, id)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluator, err := s.policyService.Evaluator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &referenceResolver{
+		ctx:        ctx,
+		service:    s,
+		key:        key,
+		evaluator:  evaluator,
+		decoded:    make(map[string]*decodedSecret),
+		rendered:   make(map[string]string),
+		active:     make(map[string]bool),
+		referenced: make(map[string]bool),
+	}
+
+	d, err := r.decode(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := &models.RenderedSecret{ID: secret.ID, Fields: make([]models.CustomField, len(d.fields))}
+	if rendered.Value, err = r.render(d, ""); err != nil {
+		return nil, err
+	}
+
+	if d.data != nil {
+		rendered.Data = make(map[string]any, len(d.data))
+		for name, value := range d.data {
+			if _, ok := value.(string); ok {
+				if value, err = r.render(d, name); err != nil {
+					return nil, err
+				}
+			}
+			rendered.Data[name] = value
+		}
+	}
+
+	for i, field := range d.fields {
+		value, err := r.render(d, field.Name)
+		if err != nil {
+			return nil, err
+		}
+		rendered.Fields[i] = models.CustomField{Name: field.Name, Value: value, Concealed: field.Concealed}
+	}
+
+	rendered.References = make([]string, 0, len(r.referenced))
+	for id := range r.referenced {
+		rendered.References = append(rendered.References, id)
+	}
+	sort.Strings(rendered.References)
+
+	// Values of referenced secrets end up in the output, so they are revealed too
+	revealed := []*models.Secret{secret}
+	for _, id := range rendered.References {
+		if id != secret.ID {
+			revealed = append(revealed, r.decoded[id].secret)
+		}
+	}
+	if err := s.auditReveal(ctx, revealed...); err != nil {
+		return nil, err
+	}
+
+	return rendered, nil
+}
+
+// decodedSecret is a secret decrypted once for reference resolution
+type decodedSecret struct {
+	secret *models.Secret
+	value  string
+	data   map[string]any
+	fields []models.CustomField
+}
+
+// referenceResolver resolves references for one render, caching decrypted
+// secrets and rendered fields, tracking the fields being rendered to detect
+// cycles and counting the bytes produced so far
+type referenceResolver struct {
+	ctx       context.Context
+	service   *SecretService
+	key       []byte
+	evaluator *PolicyEvaluator
+
+	secrets    []*models.Secret // loaded on the first reference by name
+	decoded    map[string]*decodedSecret
+	rendered   map[string]string
+	active     map[string]bool
+	path       []string
+	referenced map[string]bool
+	size       int
+}
+
+// render returns one field of a secret with its references resolved; "" is the primary value
+func (r *referenceResolver) render(d *decodedSecret, field string) (string, error) {
+	raw, err := d.field(field)
+	if err != nil {
+		return "", err
+	}
+
+	label := d.secret.Title
+	if field != "" {
+		label += "." + field
+	}
+	node := d.secret.ID + "\x00" + field
+
+	if value, ok := r.rendered[node]; ok {
+		return value, nil
+	}
+	if r.active[node] {
+		return "", fmt.Errorf("%w: reference cycle %s -> %s", ErrValidation, strings.Join(r.path, " -> "), label)
+	}
+	if len(r.path) >= maxReferenceDepth {
+		return "", fmt.Errorf("%w: references nested deeper than %d", ErrValidation, maxReferenceDepth)
+	}
+
+	r.active[node] = true
+	r.path = append(r.path, label)
+	defer func() {
+		delete(r.active, node)
+		r.path = r.path[:len(r.path)-1]
+	}()
+
+	value, err := r.expand(raw)
+	if err != nil {
+		return "", err
+	}
+
+	r.rendered[node] = value
+	return value, nil
+}
+
+// expand substitutes every reference in text
+func (r *referenceResolver) expand(text string) (string, error) {
+	matches := referencePattern.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text, nil
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m[0]])
+		last = m[1]
+
+		name, err := strconv.Unquote(text[m[2]:m[3]])
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid reference %s", ErrValidation, text[m[0]:m[1]])
+		}
+		var field string
+		if m[4] >= 0 {
+			if field, err = strconv.Unquote(text[m[4]:m[5]]); err != nil {
+				return "", fmt.Errorf("%w: invalid reference %s", ErrValidation, text[m[0]:m[1]])
+			}
+		}
+
+		target, err := r.lookup(name)
+		if err != nil {
+			return "", err
+		}
+		value, err := r.render(target, field)
+		if err != nil {
+			return "", err
+		}
+		if r.size+b.Len()+len(value) > maxRenderedSize {
+			return "", fmt.Errorf("%w: references expand to more than %d bytes", ErrValidation, maxRenderedSize)
+		}
+		b.WriteString(value)
+	}
+	b.WriteString(text[last:])
+	r.size += b.Len()
+
+	return b.String(), nil
+}
+
+// lookup finds the secret a reference names by ID, folder/title path or title.
+// Only secrets the caller may read are candidates, so a reference to anything
+// else fails exactly like one to a secret that does not exist.
+func (r *referenceResolver) lookup(name string) (*decodedSecret, error) {
+	if r.secrets == nil {
+		secrets, err := r.service.repo.List(r.ctx, models.SecretFilter{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secrets: %w", err)
+		}
+		r.secrets = []*models.Secret{}
+		for _, secret := range secrets {
+			if r.evaluator.Allows(models.ActionRead, secretAttributes(secret)) {
+				r.secrets = append(r.secrets, secret)
+			}
+		}
+	}
+
+	var matches []*models.Secret
+	for _, secret := range r.secrets {
+		if secret.ID == name {
+			matches = []*models.Secret{secret}
+			break
+		}
+		if secret.Title == name || (secret.Folder != "" && secret.Folder+"/"+secret.Title == name) {
+			matches = append(matches, secret)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: referenced secret %q does not exist", ErrValidation, name)
+	case 1:
+	default:
+		return nil, fmt.Errorf("%w: reference %q matches %d secrets, use a folder path or ID", ErrValidation, name, len(matches))
+	}
+
+	secret := matches[0]
+	r.referenced[secret.ID] = true
+
+	return r.decode(secret)
+}
+
+// decode decrypts a secret once per render
+func (r *referenceResolver) decode(secret *models.Secret) (*decodedSecret, error) {
+	if d, ok := r.decoded[secret.ID]; ok {
+		return d, nil
+	}
+
+	plaintext, err := utils.Decrypt(secret.EncryptedValue, r.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	fields, err := decodeFields(secret.Fields, r.key)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &decodedSecret{secret: secret, fields: fields}
+	d.value, d.data = decodePayload(secret.Type, plaintext)
+	r.decoded[secret.ID] = d
+
+	return d, nil
+}
+
+// field returns the raw text of a typed or custom field; "" is the primary value
+func (d *decodedSecret) field(name string) (string, error) {
+	if name == "" {
+		return d.value, nil
+	}
+
+	if value, ok := d.data[name]; ok {
+		switch value := value.(type) {
+		case string:
+			return value, nil
+		case float64:
+			return strconv.FormatFloat(value, 'f', -1, 64), nil
+		default:
+			return "", fmt.Errorf("%w: field %q of secret %q is not a single value", ErrValidation, name, d.secret.Title)
+		}
+	}
+
+	for _, field := range d.fields {
+		if field.Name == name {
+			return field.Value, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: secret %q has no field %q", ErrValidation, d.secret.Title, name)
+}
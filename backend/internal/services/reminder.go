@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"my-vault/internal/models"
+	"my-vault/internal/utils"
+)
+
+// reminderInterval is how often the background job looks for due reminders
+const reminderInterval = time.Hour
+
+// StartReminders starts a background job that notifies about secrets expiring
+// within window, or already expired, and secrets overdue for rotation. Each
+// reminder is sent once and marked on the secret; changing the expiry date,
+// rotation interval or value re-arms it. It runs until the returned function is called.
+func (s *SecretService) StartReminders(notifier Notifier, window time.Duration) (stop func()) {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(reminderInterval)
+		defer ticker.Stop()
+
+		for {
+			s.sendReminders(notifier, window)
+
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// sendReminders runs one pass of the reminder job
+func (s *SecretService) sendReminders(notifier Notifier, window time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	now := time.Now()
+
+	expiring, err := s.repo.ListExpiryReminders(ctx, now.Add(window))
+	if errors.Is(err, ErrLocked) {
+		// Sent on the next pass once the vault is unlocked
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to list expiring secrets: %v", err)
+	}
+	for _, secret := range expiring {
+		kind := models.NotificationExpiring
+		if !secret.ExpiresAt.After(now) {
+			kind = models.NotificationExpired
+		}
+
+		if err := notifier.Notify(ctx, newNotification(kind, secret, *secret.ExpiresAt, now)); err != nil {
+			log.Printf("Failed to send expiry reminder for secret %s: %v", secret.ID, err)
+			continue
+		}
+		if err := s.repo.MarkExpiryNotified(ctx, secret.ID, now); err != nil {
+			log.Printf("Failed to mark expiry reminder for secret %s: %v", secret.ID, err)
+		}
+	}
+
+	due, err := s.repo.ListRotationReminders(ctx, now)
+	if err != nil {
+		log.Printf("Failed to list secrets due for rotation: %v", err)
+	}
+	for _, secret := range due {
+		notification := newNotification(models.NotificationRotationDue, secret, secret.RotatedAt.Add(secret.RotateEvery), now)
+		if err := notifier.Notify(ctx, notification); err != nil {
+			log.Printf("Failed to send rotation reminder for secret %s: %v", secret.ID, err)
+			continue
+		}
+		if err := s.repo.MarkRotationNotified(ctx, secret.ID, now); err != nil {
+			log.Printf("Failed to mark rotation reminder for secret %s: %v", secret.ID, err)
+		}
+	}
+}
+
+// newNotification builds a reminder about a secret without its value
+func newNotification(kind string, secret *models.Secret, dueAt, now time.Time) *models.Notification {
+	return &models.Notification{
+		Kind:     kind,
+		SecretID: secret.ID,
+		Title:    secret.Title,
+		Type:     secret.Type,
+		Folder:   secret.Folder,
+		DueAt:    dueAt,
+		SentAt:   now,
+	}
+}
+
+// parseRotateEvery parses a rotation interval such as "90d"; "" means no rotation
+func parseRotateEvery(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	d, err := utils.ParseDuration(value)
+	if err != nil || d < time.Hour {
+		return 0, fmt.Errorf("%w: rotate_every must be a duration of at least 1h, e.g. 90d", ErrValidation)
+	}
+	return d, nil
+}
+
+// formatRotateEvery formats a rotation interval in whole days when possible
+func formatRotateEvery(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
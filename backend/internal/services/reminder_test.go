@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// fakeNotifier records the reminders it is given, failing for the titles in fail
+type fakeNotifier struct {
+	sent []*models.Notification
+	fail map[string]bool
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, notification *models.Notification) error {
+	if n.fail[notification.Title] {
+		return errors.New("delivery failed")
+	}
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+// take returns the reminders sent so far as "kind title", sorted, and forgets them
+func (n *fakeNotifier) take() []string {
+	var sent []string
+	for _, notification := range n.sent {
+		sent = append(sent, notification.Kind+" "+notification.Title)
+	}
+	slices.Sort(sent)
+	n.sent = nil
+	return sent
+}
+
+func TestSendReminders(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+	now := time.Now()
+
+	for _, req := range []*models.CreateSecretRequest{
+		{Title: "Expiring", Type: "api_token", Value: "v", ExpiresAt: timePtr(now.Add(48 * time.Hour))},
+		{Title: "Expired", Type: "api_token", Value: "v", ExpiresAt: timePtr(now.Add(-time.Hour))},
+		{Title: "Later", Type: "api_token", Value: "v", ExpiresAt: timePtr(now.Add(60 * 24 * time.Hour))},
+		{Title: "Fresh", Type: "api_token", Value: "v", RotateEvery: "1h"},
+	} {
+		if _, err := service.Create(ctx, req); err != nil {
+			t.Fatalf("Create(%s): %v", req.Title, err)
+		}
+	}
+
+	// Only the store can date a rotation back far enough to be due
+	created, err := service.Create(ctx, &models.CreateSecretRequest{Title: "Rotating", Type: "api_token", Value: "v", RotateEvery: "1h"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	rotating, err := service.repo.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	rotating.RotatedAt = now.Add(-2 * time.Hour)
+	if err := service.repo.UpdateWithVersion(ctx, rotating); err != nil {
+		t.Fatalf("UpdateWithVersion: %v", err)
+	}
+
+	notifier := &fakeNotifier{fail: map[string]bool{"Expiring": true}}
+	service.sendReminders(notifier, 7*24*time.Hour)
+	if got, want := notifier.take(), []string{"expired Expired", "rotation_due Rotating"}; !slices.Equal(got, want) {
+		t.Errorf("first pass sent %v, want %v", got, want)
+	}
+
+	// Sent reminders are marked and not repeated; failed ones are tried again
+	notifier.fail = nil
+	service.sendReminders(notifier, 7*24*time.Hour)
+	sent := notifier.sent
+	if got, want := notifier.take(), []string{"expiring Expiring"}; !slices.Equal(got, want) {
+		t.Fatalf("second pass sent %v, want %v", got, want)
+	}
+	if sent[0].SecretID == "" || !sent[0].DueAt.Equal(now.Add(48*time.Hour)) || sent[0].SentAt.Before(now) {
+		t.Errorf("reminder = %+v, want the secret ID, its expiry as due date and the send time", sent[0])
+	}
+
+	service.sendReminders(notifier, 7*24*time.Hour)
+	if got := notifier.take(); len(got) != 0 {
+		t.Errorf("third pass sent %v, want nothing", got)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received models.Notification
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("webhook got %s with Content-Type %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decode notification: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL)
+	notification := &models.Notification{
+		Kind:     models.NotificationExpiring,
+		SecretID: "550e8400-e29b-41d4-a716-446655440000",
+		Title:    "GitHub API Token",
+		DueAt:    time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+	}
+
+	if err := notifier.Notify(context.Background(), notification); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if received.SecretID != notification.SecretID || received.Kind != notification.Kind || !received.DueAt.Equal(notification.DueAt) {
+		t.Errorf("webhook received %+v, want %+v", received, notification)
+	}
+
+	// Anything but a 2xx response is a failed delivery
+	status = http.StatusInternalServerError
+	if err := notifier.Notify(context.Background(), notification); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Notify against a failing webhook: got %v, want the status in the error", err)
+	}
+
+	server.Close()
+	if err := notifier.Notify(context.Background(), notification); err == nil {
+		t.Error("Notify against an unreachable webhook succeeded")
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"my-vault/internal/models"
+)
+
+// minSubwordSearch is the shortest query word that also matches inside longer
+// words; shorter ones only match whole words
+const minSubwordSearch = 3
+
+// Search covers the title, custom field names and plain custom field values,
+// all stored in cleartext. The store narrows the candidates to secrets
+// containing every query word, case-insensitively; matchesSearch then drops
+// those where a short word only occurs inside a longer one.
+
+// searchWords splits text into lowercase words of letters and digits
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchableText returns the parts of a secret search covers: the title,
+// custom field names and plain custom field values
+func searchableText(secret *models.Secret) []string {
+	text := []string{secret.Title}
+	for _, field := range secret.Fields {
+		text = append(text, field.Name)
+		if !field.Concealed {
+			text = append(text, field.Value)
+		}
+	}
+	return text
+}
+
+// matchesSearch reports whether every query word is part of a word of the
+// secret's searchable text, or for short words, a whole word
+func matchesSearch(secret *models.Secret, words []string) bool {
+	var secretWords []string
+	for _, text := range searchableText(secret) {
+		secretWords = append(secretWords, searchWords(text)...)
+	}
+
+	for _, word := range words {
+		short := utf8.RuneCountInString(word) < minSubwordSearch
+		if !slices.ContainsFunc(secretWords, func(w string) bool {
+			if short {
+				return w == word
+			}
+			return strings.Contains(w, word)
+		}) {
+			return false
+		}
+	}
+	return true
+}
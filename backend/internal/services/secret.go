@@ -1,8 +1,10 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"time"
 
 	"my-vault/internal/models"
 	"my-vault/internal/repository"
@@ -11,15 +13,26 @@ import (
 
 // SecretService handles business logic for secrets
 type SecretService struct {
-	repo         *repository.SecretRepository
-	vaultService *VaultService
+	repo          repository.SecretStore
+	vaultService  *VaultService
+	policyService *PolicyService
+	retention     VersionRetention
+
+	blobs            *repository.BlobStore
+	attachmentLimits AttachmentLimits
+
+	breaches *repository.PwnedPasswords
+
+	auditor Auditor
 }
 
 // NewSecretService creates a new secret service
-func NewSecretService(repo *repository.SecretRepository, vaultService *VaultService) *SecretService {
+func NewSecretService(repo repository.SecretStore, vaultService *VaultService, policyService *PolicyService) *SecretService {
 	return &SecretService{
-		repo:         repo,
-		vaultService: vaultService,
+		repo:          repo,
+		vaultService:  vaultService,
+		policyService: policyService,
+		auditor:       NewLogAuditor(),
 	}
 }
 
@@ -31,17 +44,67 @@ func (s *SecretService) Create(ctx context.Context, req *models.CreateSecretRequ
 		return nil, fmt.Errorf("vault is locked: %w", err)
 	}
 
-	// Encrypt the secret value
-	encryptedValue, err := utils.Encrypt([]byte(req.Value), key)
+	// Normalize the secret's place in the folder hierarchy and its tags
+	folder, err := normalizeFolder(req.Folder)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+		return nil, err
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	rotateEvery, err := parseRotateEvery(req.RotateEvery)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create secret model
 	secret := &models.Secret{
-		Title:          req.Title,
-		Type:           req.Type,
-		EncryptedValue: encryptedValue,
+		Title:       req.Title,
+		Type:        req.Type,
+		Folder:      folder,
+		Tags:        tags,
+		ExpiresAt:   req.ExpiresAt,
+		RotateEvery: rotateEvery,
+		UpdatedBy:   PrincipalFromContext(ctx).String(),
+	}
+
+	// Check the caller may create a secret with these attributes
+	if err := s.policyService.Authorize(ctx, models.ActionCreate, secretAttributes(secret)); err != nil {
+		return nil, err
+	}
+
+	// Generate the primary value when requested
+	value, reqData := req.Value, req.Data
+	if req.Generate != nil {
+		if value != "" {
+			return nil, fmt.Errorf("%w: value and generate are mutually exclusive", ErrValidation)
+		}
+		if value, reqData, err = generateValue(req.Type, req.Generate, req.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	// Publish SSH public keys as metadata, then validate the value against the type's field set
+	reqData, fields, err := sshKeyMetadata(req.Type, value, reqData, req.Fields)
+	if err != nil {
+		return nil, err
+	}
+	payload, data, err := buildPayload(req.Type, value, reqData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Encrypt the secret payload
+	encryptedValue, err := utils.Encrypt(payload, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	secret.EncryptedValue = encryptedValue
+
+	// Validate custom fields and encrypt the concealed ones
+	if secret.Fields, err = encodeFields(fields, key, nil); err != nil {
+		return nil, err
 	}
 
 	// Save to database
@@ -49,15 +112,11 @@ func (s *SecretService) Create(ctx context.Context, req *models.CreateSecretRequ
 		return nil, fmt.Errorf("failed to save secret: %w", err)
 	}
 
-	// Return response
-	return &models.SecretResponse{
-		ID:        secret.ID,
-		Title:     secret.Title,
-		Type:      secret.Type,
-		Value:     req.Value, // Return decrypted value
-		CreatedAt: secret.CreatedAt,
-		UpdatedAt: secret.UpdatedAt,
-	}, nil
+	// Return response with the decrypted value
+	if err := s.auditReveal(ctx, secret); err != nil {
+		return nil, err
+	}
+	return newSecretResponse(secret, data, fields), nil
 }
 
 // Get retrieves a secret by ID
@@ -74,105 +133,384 @@ func (s *SecretService) Get(ctx context.Context, id string) (*models.SecretRespo
 		return nil, fmt.Errorf("failed to get secret: %w", err)
 	}
 
+	// Check the caller may read this secret
+	if err := s.policyService.Authorize(ctx, models.ActionRead, secretAttributes(secret)); err != nil {
+		return nil, err
+	}
+
 	// Decrypt the secret value
-	decryptedValue, err := utils.Decrypt(secret.EncryptedValue, key)
+	return s.reveal(ctx, secret, key)
+}
+
+// List retrieves the metadata of the secrets matching the filter, one page at
+// a time when the filter sets a limit. Nothing is decrypted.
+func (s *SecretService) List(ctx context.Context, filter models.SecretFilter) (*models.SecretListResponse, error) {
+	secrets, next, err := s.listReadable(ctx, filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+		return nil, err
 	}
 
-	// Return response
-	return &models.SecretResponse{
-		ID:        secret.ID,
-		Title:     secret.Title,
-		Type:      secret.Type,
-		Value:     string(decryptedValue),
-		CreatedAt: secret.CreatedAt,
-		UpdatedAt: secret.UpdatedAt,
-	}, nil
+	list := &models.SecretListResponse{Secrets: []*models.SecretSummary{}, NextCursor: next}
+	for _, secret := range secrets {
+		list.Secrets = append(list.Secrets, newSecretSummary(secret))
+	}
+
+	return list, nil
 }
 
-// List retrieves all secrets
-func (s *SecretService) List(ctx context.Context) ([]*models.SecretResponse, error) {
+// ListWithValues retrieves the secrets matching the filter like List, but
+// decrypted. Only the secrets of the page are decrypted, and each one is
+// audited as revealed.
+func (s *SecretService) ListWithValues(ctx context.Context, filter models.SecretFilter) (*models.SecretValueListResponse, error) {
 	// Get encryption key from vault
 	key, err := s.vaultService.GetKey()
 	if err != nil {
 		return nil, fmt.Errorf("vault is locked: %w", err)
 	}
 
-	// Get secrets from database
-	secrets, err := s.repo.List(ctx)
+	secrets, next, err := s.listReadable(ctx, filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list secrets: %w", err)
+		return nil, err
 	}
 
 	// Decrypt and convert to responses
-	var responses []*models.SecretResponse
+	list := &models.SecretValueListResponse{Secrets: []*models.SecretResponse{}, NextCursor: next}
 	for _, secret := range secrets {
-		decryptedValue, err := utils.Decrypt(secret.EncryptedValue, key)
+		response, err := decryptSecret(secret, key)
 		if err != nil {
-			return nil, fmt.Errorf("failed to decrypt secret %s: %w", secret.ID, err)
+			return nil, fmt.Errorf("secret %s: %w", secret.ID, err)
 		}
 
-		responses = append(responses, &models.SecretResponse{
-			ID:        secret.ID,
-			Title:     secret.Title,
-			Type:      secret.Type,
-			Value:     string(decryptedValue),
-			CreatedAt: secret.CreatedAt,
-			UpdatedAt: secret.UpdatedAt,
-		})
+		list.Secrets = append(list.Secrets, response)
+	}
+
+	if err := s.auditReveal(ctx, secrets...); err != nil {
+		return nil, err
 	}
 
-	return responses, nil
+	return list, nil
 }
 
-// Update updates an existing secret
-func (s *SecretService) Update(ctx context.Context, id string, req *models.UpdateSecretRequest) (*models.SecretResponse, error) {
+// listReadable returns the page of secrets matching the filter that the caller
+// may read, with the cursor of the next page. Secrets the caller may not read
+// are skipped, so the page may hold fewer secrets than the limit.
+func (s *SecretService) listReadable(ctx context.Context, filter models.SecretFilter) ([]*models.Secret, string, error) {
+	if !s.vaultService.IsUnlocked() {
+		return nil, "", fmt.Errorf("vault is locked: %w", ErrLocked)
+	}
+
+	// Get secrets from database, one more than requested to learn whether another page follows
+	query := filter
+	if filter.Search != "" {
+		if query.SearchWords = searchWords(filter.Search); len(query.SearchWords) == 0 {
+			return nil, "", fmt.Errorf("%w: search needs at least one letter or digit", ErrValidation)
+		}
+	}
+	if filter.Limit > 0 {
+		query.Limit = filter.Limit + 1
+	}
+
+	secrets, err := s.repo.List(ctx, query)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	var next string
+	if filter.Limit > 0 && len(secrets) > filter.Limit {
+		secrets = secrets[:filter.Limit]
+		next = encodeSecretCursor(secrets[len(secrets)-1], filter)
+	}
+
+	// Load the caller's policies once for filtering
+	evaluator, err := s.policyService.Evaluator(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Drop short words only found inside longer ones, and what the caller may not read
+	readable := secrets[:0]
+	for _, secret := range secrets {
+		if len(query.SearchWords) > 0 && !matchesSearch(secret, query.SearchWords) {
+			continue
+		}
+		if evaluator.Allows(models.ActionRead, secretAttributes(secret)) {
+			readable = append(readable, secret)
+		}
+	}
+
+	return readable, next, nil
+}
+
+// Update updates an existing secret, provided it is still at the revision the caller read
+func (s *SecretService) Update(ctx context.Context, id string, revision int, req *models.UpdateSecretRequest) (*models.SecretResponse, error) {
 	// Get encryption key from vault
 	key, err := s.vaultService.GetKey()
 	if err != nil {
 		return nil, fmt.Errorf("vault is locked: %w", err)
 	}
 
-	// Get existing secret
+	secret, evaluator, err := s.getForUpdate(ctx, id, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.update(ctx, key, secret, evaluator, req)
+}
+
+// getForUpdate gets a secret the caller may update, provided it is still at
+// the revision the caller read. The store refuses the write should it change meanwhile.
+func (s *SecretService) getForUpdate(ctx context.Context, id string, revision int) (*models.Secret, *PolicyEvaluator, error) {
 	secret, err := s.repo.Get(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get secret: %w", err)
+		return nil, nil, fmt.Errorf("failed to get secret: %w", err)
 	}
 
-	// Encrypt the new secret value
-	encryptedValue, err := utils.Encrypt([]byte(req.Value), key)
+	// Authorize first, so callers who may not update learn nothing of the revision
+	evaluator, err := s.policyService.Evaluator(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+		return nil, nil, err
 	}
+	if !evaluator.Allows(models.ActionUpdate, secretAttributes(secret)) {
+		return nil, nil, fmt.Errorf("%w: %s not permitted", ErrAccessDenied, models.ActionUpdate)
+	}
+
+	if secret.Revision != revision {
+		return nil, nil, fmt.Errorf("secret %w", ErrRevisionMismatch)
+	}
+
+	return secret, evaluator, nil
+}
+
+// update replaces the content of a secret read by getForUpdate with req and
+// saves it as a new version. The value and concealed fields are only
+// encrypted again when they change.
+func (s *SecretService) update(ctx context.Context, key []byte, secret *models.Secret, evaluator *PolicyEvaluator, req *models.UpdateSecretRequest) (*models.SecretResponse, error) {
+	var err error
+
+	// Secrets of a type outside the registry keep an opaque value while they keep that type
+	_, known := secretTypes[req.Type]
+	untyped := !known && req.Type == secret.Type
 
 	// Update secret fields
 	secret.Title = req.Title
 	secret.Type = req.Type
-	secret.EncryptedValue = encryptedValue
+	if secret.Folder, err = normalizeFolder(req.Folder); err != nil {
+		return nil, err
+	}
+	if secret.Tags, err = normalizeTags(req.Tags); err != nil {
+		return nil, err
+	}
+	if secret.RotateEvery, err = parseRotateEvery(req.RotateEvery); err != nil {
+		return nil, err
+	}
+	secret.ExpiresAt = req.ExpiresAt
 
-	// Save to database
-	if err := s.repo.Update(ctx, secret); err != nil {
+	// The caller must also be allowed to update the secret as it will be saved
+	if !evaluator.Allows(models.ActionUpdate, secretAttributes(secret)) {
+		return nil, fmt.Errorf("%w: %s not permitted", ErrAccessDenied, models.ActionUpdate)
+	}
+
+	// Publish SSH public keys as metadata, then validate the new value against the type's field set
+	reqData, fields, err := sshKeyMetadata(req.Type, req.Value, req.Data, req.Fields)
+	if err != nil {
+		return nil, err
+	}
+	build := buildPayload
+	if untyped {
+		build = buildUntypedPayload
+	}
+	payload, data, err := build(req.Type, req.Value, reqData)
+	if err != nil {
+		return nil, err
+	}
+
+	// A changed value counts as a rotation and is encrypted anew
+	previous, err := utils.Decrypt(secret.EncryptedValue, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	if !bytes.Equal(previous, payload) {
+		secret.RotatedAt = time.Now()
+
+		encryptedValue, err := utils.Encrypt(payload, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+		}
+		secret.EncryptedValue = encryptedValue
+	}
+
+	// Replace custom fields, encrypting changed concealed ones
+	concealed, err := concealedFields(secret.Fields, key)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Fields, err = encodeFields(fields, key, concealed); err != nil {
+		return nil, err
+	}
+
+	// Save to database, keeping the replaced content as a prior version
+	secret.UpdatedBy = PrincipalFromContext(ctx).String()
+	if err := s.repo.UpdateWithVersion(ctx, secret); err != nil {
 		return nil, fmt.Errorf("failed to update secret: %w", err)
 	}
+	s.pruneVersions(ctx, secret.ID)
+
+	// Callers who may update but not read the secret only get its metadata back
+	if !evaluator.Allows(models.ActionRead, secretAttributes(secret)) {
+		return metadataResponse(secret), nil
+	}
+
+	// Return response with the decrypted value
+	if err := s.auditReveal(ctx, secret); err != nil {
+		return nil, err
+	}
+	response := newSecretResponse(secret, data, fields)
+	if untyped {
+		response.Value = req.Value
+	}
+	return response, nil
+}
+
+// Delete moves a secret to the trash, provided it is still at the revision the caller read
+func (s *SecretService) Delete(ctx context.Context, id string, revision int) error {
+	// Check if vault is unlocked (we don't need the key for deletion)
+	if !s.vaultService.IsUnlocked() {
+		return fmt.Errorf("vault is locked")
+	}
+
+	// Get existing secret to check the caller may delete it
+	secret, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	if err := s.policyService.Authorize(ctx, models.ActionDelete, secretAttributes(secret)); err != nil {
+		return err
+	}
+
+	return s.repo.Delete(ctx, id, revision)
+}
+
+// reveal decrypts a secret for the caller, recording the reveal in the audit log first
+func (s *SecretService) reveal(ctx context.Context, secret *models.Secret, key []byte) (*models.SecretResponse, error) {
+	response, err := decryptSecret(secret, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditReveal(ctx, secret); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// decryptSecret decrypts a stored secret into its response form
+func decryptSecret(secret *models.Secret, key []byte) (*models.SecretResponse, error) {
+	plaintext, err := utils.Decrypt(secret.EncryptedValue, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	fields, err := decodeFields(secret.Fields, key)
+	if err != nil {
+		return nil, err
+	}
+
+	value, data := decodePayload(secret.Type, plaintext)
+	response := newSecretResponse(secret, data, fields)
+	response.Value = value
+	return response, nil
+}
 
-	// Return response
-	return &models.SecretResponse{
+// metadataResponse describes a secret in response form without its value, data
+// or custom fields, for callers who changed a secret they may not read
+func metadataResponse(secret *models.Secret) *models.SecretResponse {
+	withheld := *secret
+	withheld.Fields = nil
+	return newSecretResponse(&withheld, nil, nil)
+}
+
+// newSecretSummary describes a secret without anything that needs decrypting
+func newSecretSummary(secret *models.Secret) *models.SecretSummary {
+	summary := &models.SecretSummary{
 		ID:        secret.ID,
 		Title:     secret.Title,
 		Type:      secret.Type,
-		Value:     req.Value, // Return decrypted value
+		Folder:    secret.Folder,
+		Tags:      secret.Tags,
+		Version:   secret.Version,
+		Revision:  secret.Revision,
+		UpdatedBy: secret.UpdatedBy,
 		CreatedAt: secret.CreatedAt,
 		UpdatedAt: secret.UpdatedAt,
-	}, nil
+		ExpiresAt: secret.ExpiresAt,
+		DeletedAt: secret.DeletedAt,
+		RotatedAt: secret.RotatedAt,
+	}
+
+	if secret.RotateEvery > 0 {
+		due := secret.RotatedAt.Add(secret.RotateEvery)
+		summary.RotationDueAt = &due
+	}
+
+	if summary.Tags == nil {
+		summary.Tags = []string{}
+	}
+
+	return summary
 }
 
-// Delete removes a secret
-func (s *SecretService) Delete(ctx context.Context, id string) error {
-	// Check if vault is unlocked (we don't need the key for deletion)
-	if !s.vaultService.IsUnlocked() {
-		return fmt.Errorf("vault is locked")
+// newSecretResponse builds a response exposing the type's primary field as Value
+func newSecretResponse(secret *models.Secret, data map[string]any, fields []models.CustomField) *models.SecretResponse {
+	response := &models.SecretResponse{
+		ID:        secret.ID,
+		Title:     secret.Title,
+		Type:      secret.Type,
+		Data:      data,
+		Fields:    make([]models.CustomField, len(secret.Fields)),
+		Folder:    secret.Folder,
+		Tags:      secret.Tags,
+		Version:   secret.Version,
+		Revision:  secret.Revision,
+		UpdatedBy: secret.UpdatedBy,
+		CreatedAt: secret.CreatedAt,
+		UpdatedAt: secret.UpdatedAt,
+		DeletedAt: secret.DeletedAt,
+
+		ExpiresAt:   secret.ExpiresAt,
+		RotateEvery: formatRotateEvery(secret.RotateEvery),
+		RotatedAt:   secret.RotatedAt,
+	}
+
+	if secret.RotateEvery > 0 {
+		due := secret.RotatedAt.Add(secret.RotateEvery)
+		response.RotationDueAt = &due
 	}
 
-	return s.repo.Delete(ctx, id)
-} 
\ No newline at end of file
+	// Report field names as stored, after trimming
+	for i, field := range secret.Fields {
+		response.Fields[i] = models.CustomField{Name: field.Name, Value: fields[i].Value, Concealed: field.Concealed}
+	}
+
+	if response.Tags == nil {
+		response.Tags = []string{}
+	}
+
+	if t, ok := secretTypes[secret.Type]; ok {
+		response.Value, _ = data[t.primary].(string)
+	}
+
+	return response
+}
+
+// secretAttributes returns the attributes policy conditions are matched against
+func secretAttributes(secret *models.Secret) map[string][]string {
+	return map[string][]string{
+		"id":     {secret.ID},
+		"title":  {secret.Title},
+		"type":   {secret.Type},
+		"folder": {secret.Folder},
+		"tag":    secret.Tags,
+	}
+}
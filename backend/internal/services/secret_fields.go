@@ -0,0 +1,102 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"my-vault/internal/models"
+	"my-vault/internal/utils"
+)
+
+const (
+	// maxCustomFields caps the number of custom fields on one secret
+	maxCustomFields = 100
+
+	// maxFieldNameLength caps the length of a custom field name
+	maxFieldNameLength = 255
+)
+
+// encodeFields validates custom fields and encrypts the concealed ones, preserving order.
+// A concealed field matching one in previous by name and value keeps its ciphertext.
+func encodeFields(fields []models.CustomField, key []byte, previous map[string]concealedField) ([]models.SecretField, error) {
+	if len(fields) > maxCustomFields {
+		return nil, fmt.Errorf("%w: at most %d custom fields are allowed", ErrValidation, maxCustomFields)
+	}
+
+	seen := make(map[string]bool, len(fields))
+	stored := make([]models.SecretField, 0, len(fields))
+	for i, field := range fields {
+		name := strings.TrimSpace(field.Name)
+		if name == "" {
+			return nil, fmt.Errorf("%w: custom field %d has no name", ErrValidation, i)
+		}
+		if len(name) > maxFieldNameLength {
+			return nil, fmt.Errorf("%w: custom field name %q is too long", ErrValidation, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: duplicate custom field %q", ErrValidation, name)
+		}
+		seen[name] = true
+
+		if !field.Concealed {
+			stored = append(stored, models.SecretField{Name: name, Value: field.Value})
+			continue
+		}
+
+		if kept, ok := previous[name]; ok && kept.value == field.Value {
+			stored = append(stored, models.SecretField{Name: name, Concealed: true, EncryptedValue: kept.encryptedValue})
+			continue
+		}
+
+		encryptedValue, err := utils.Encrypt([]byte(field.Value), key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt field %q: %w", name, err)
+		}
+		stored = append(stored, models.SecretField{Name: name, Concealed: true, EncryptedValue: encryptedValue})
+	}
+
+	return stored, nil
+}
+
+// concealedField is a stored concealed field with its decrypted value
+type concealedField struct {
+	value          string
+	encryptedValue []byte
+}
+
+// concealedFields decrypts the stored concealed fields, by name
+func concealedFields(stored []models.SecretField, key []byte) (map[string]concealedField, error) {
+	concealed := make(map[string]concealedField)
+	for _, field := range stored {
+		if !field.Concealed {
+			continue
+		}
+
+		plaintext, err := utils.Decrypt(field.EncryptedValue, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt field %q: %w", field.Name, err)
+		}
+		concealed[field.Name] = concealedField{value: string(plaintext), encryptedValue: field.EncryptedValue}
+	}
+
+	return concealed, nil
+}
+
+// decodeFields decrypts stored custom fields into their response form
+func decodeFields(stored []models.SecretField, key []byte) ([]models.CustomField, error) {
+	fields := make([]models.CustomField, 0, len(stored))
+	for _, field := range stored {
+		if !field.Concealed {
+			fields = append(fields, models.CustomField{Name: field.Name, Value: field.Value})
+			continue
+		}
+
+		plaintext, err := utils.Decrypt(field.EncryptedValue, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt field %q: %w", field.Name, err)
+		}
+		fields = append(fields, models.CustomField{Name: field.Name, Value: string(plaintext), Concealed: true})
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,697 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"my-vault/internal/models"
+	"my-vault/internal/repository"
+	"my-vault/internal/utils"
+)
+
+// newTestSecretService wires a secret service to an unlocked in-memory vault
+func newTestSecretService(t *testing.T) (*SecretService, *VaultService) {
+	t.Helper()
+
+	db := repository.NewMemoryDB()
+	t.Cleanup(db.Close)
+
+	vault := NewVaultService(repository.NewMemoryVaultRepository(db))
+	if err := vault.Initialize(context.Background(), "correct horse battery staple"); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	t.Cleanup(vault.Lock)
+
+	policies := NewPolicyService(repository.NewMemoryPolicyRepository(db))
+	return NewSecretService(repository.NewMemorySecretRepository(db), vault, policies), vault
+}
+
+// ownerContext returns a context acting as the vault owner
+func ownerContext() context.Context {
+	return WithPrincipal(context.Background(), Principal{Owner: true})
+}
+
+func TestSecretLifecycle(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+
+	created, err := service.Create(ctx, &models.CreateSecretRequest{
+		Title:  "Stripe",
+		Type:   "api_token",
+		Value:  "sk_live_123",
+		Folder: "prod/payments",
+		Tags:   []string{"prod"},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got, err := service.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Value != "sk_live_123" || got.Folder != "prod/payments" || got.Version != 1 {
+		t.Errorf("Get = %+v, want the created secret", got)
+	}
+
+	updated, err := service.Update(ctx, created.ID, got.Revision, &models.UpdateSecretRequest{
+		Title:  "Stripe",
+		Type:   "api_token",
+		Value:  "sk_live_456",
+		Folder: "prod/payments",
+		Tags:   []string{"prod"},
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Value != "sk_live_456" || updated.Version != 2 {
+		t.Errorf("Update = value %q version %d, want the new value as version 2", updated.Value, updated.Version)
+	}
+
+	listed, err := service.List(ctx, models.SecretFilter{Tag: "prod"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(listed.Secrets) != 1 || listed.Secrets[0].ID != created.ID {
+		t.Errorf("List(tag=prod) = %d secrets, want the created one", len(listed.Secrets))
+	}
+
+	if err := service.Delete(ctx, created.ID, updated.Revision); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := service.Get(ctx, created.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestSecretUpdateRequiresCurrentRevision(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+
+	created, err := service.Create(ctx, &models.CreateSecretRequest{Title: "Stripe", Type: "api_token", Value: "sk_live_123"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Two editors read revision 1; the first to save wins
+	edit := func(value string) (*models.SecretResponse, error) {
+		return service.Update(ctx, created.ID, created.Revision, &models.UpdateSecretRequest{Title: "Stripe", Type: "api_token", Value: value})
+	}
+	first, err := edit("sk_live_456")
+	if err != nil {
+		t.Fatalf("first Update: %v", err)
+	}
+	if first.Revision != created.Revision+1 {
+		t.Errorf("first Update revision = %d, want %d", first.Revision, created.Revision+1)
+	}
+	if _, err := edit("sk_live_789"); !errors.Is(err, ErrRevisionMismatch) {
+		t.Errorf("second Update: got %v, want ErrRevisionMismatch", err)
+	}
+	if err := service.Delete(ctx, created.ID, created.Revision); !errors.Is(err, ErrRevisionMismatch) {
+		t.Errorf("Delete at the old revision: got %v, want ErrRevisionMismatch", err)
+	}
+
+	got, err := service.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Value != "sk_live_456" || got.Revision != first.Revision {
+		t.Errorf("Get = %q at revision %d, want the first edit at revision %d", got.Value, got.Revision, first.Revision)
+	}
+}
+
+func TestSecretPatch(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+
+	created, err := service.Create(ctx, &models.CreateSecretRequest{
+		Title: "GitHub",
+		Type:  "login",
+		Data:  map[string]any{"username": "alice", "password": "hunter2"},
+		Fields: []models.CustomField{
+			{Name: "recovery", Value: "1234-5678", Concealed: true},
+			{Name: "region", Value: "eu-west-1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	before, err := service.repo.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	revision := created.Revision
+	patch := func(body string) (*models.SecretResponse, error) {
+		t.Helper()
+		patched, err := service.Patch(ctx, created.ID, revision, []byte(body))
+		if err == nil {
+			revision = patched.Revision
+		}
+		return patched, err
+	}
+
+	// Renaming keeps the ciphertexts as they are
+	renamed, err := patch(`{"title": "GitHub (work)"}`)
+	if err != nil {
+		t.Fatalf("Patch title: %v", err)
+	}
+	after, err := service.repo.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if renamed.Title != "GitHub (work)" || renamed.Value != "hunter2" || renamed.Data["username"] != "alice" || renamed.Version != 2 {
+		t.Errorf("renamed = %q value %q data %v version %d, want only the title changed in version 2", renamed.Title, renamed.Value, renamed.Data, renamed.Version)
+	}
+	if !slices.Equal(after.EncryptedValue, before.EncryptedValue) || !slices.Equal(after.Fields[0].EncryptedValue, before.Fields[0].EncryptedValue) {
+		t.Error("renaming encrypted the value or a concealed field again")
+	}
+	if !after.RotatedAt.Equal(before.RotatedAt) {
+		t.Error("renaming counted as a rotation")
+	}
+
+	// Data is merged member by member; value and data both reach the primary field
+	changed, err := patch(`{"data": {"password": "s3cret"}}`)
+	if err != nil {
+		t.Fatalf("Patch data: %v", err)
+	}
+	if changed.Value != "s3cret" || changed.Data["username"] != "alice" {
+		t.Errorf("after data patch value %q data %v, want the new password and the old username", changed.Value, changed.Data)
+	}
+	changed, err = patch(`{"value": "0ther", "data": {"username": null}}`)
+	if err != nil {
+		t.Fatalf("Patch value: %v", err)
+	}
+	if _, ok := changed.Data["username"]; changed.Value != "0ther" || ok {
+		t.Errorf("after value patch value %q data %v, want the new password without username", changed.Value, changed.Data)
+	}
+	if len(changed.Fields) != 2 || changed.Fields[0].Value != "1234-5678" {
+		t.Errorf("fields = %+v, want them untouched", changed.Fields)
+	}
+
+	for _, body := range []string{
+		`{"title": null}`,
+		`{"value": null}`,
+		`{"data": {"pin": "1234"}}`,
+		`{"owner": "bob"}`,
+		`{"tags": "prod"}`,
+		`["title"]`,
+		`null`,
+	} {
+		if _, err := patch(body); !errors.Is(err, ErrValidation) {
+			t.Errorf("Patch(%s): got %v, want ErrValidation", body, err)
+		}
+	}
+
+	if _, err := service.Patch(ctx, created.ID, created.Revision, []byte(`{"title": "stale"}`)); !errors.Is(err, ErrRevisionMismatch) {
+		t.Errorf("Patch at the old revision: got %v, want ErrRevisionMismatch", err)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7396, appendix A
+	for _, tc := range []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	} {
+		var target, patch any
+		if err := json.Unmarshal([]byte(tc.target), &target); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(tc.patch), &patch); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := json.Marshal(mergePatch(target, patch))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("mergePatch(%s, %s) = %s, want %s", tc.target, tc.patch, got, tc.want)
+		}
+	}
+}
+
+func TestSecretListPages(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+
+	for _, title := range []string{"c", "a", "e", "b", "d"} {
+		if _, err := service.Create(ctx, &models.CreateSecretRequest{Title: title, Type: "api_token", Value: "v"}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	filter := models.SecretFilter{Sort: models.SortByTitle, Ascending: true, Limit: 2}
+	var got []string
+	for pages := 1; ; pages++ {
+		page, err := service.List(ctx, filter)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		for _, secret := range page.Secrets {
+			got = append(got, secret.Title)
+		}
+		if page.NextCursor == "" {
+			if pages != 3 {
+				t.Errorf("listed %d pages, want 3", pages)
+			}
+			break
+		}
+
+		filter.After, err = DecodeSecretCursor(page.NextCursor, filter)
+		if err != nil {
+			t.Fatalf("DecodeSecretCursor: %v", err)
+		}
+	}
+	if !slices.Equal(got, []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("paged titles = %v, want a to e", got)
+	}
+
+	// A cursor only continues the order it was issued for
+	page, err := service.List(ctx, models.SecretFilter{Limit: 1})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if _, err := DecodeSecretCursor(page.NextCursor, filter); !errors.Is(err, ErrValidation) {
+		t.Errorf("DecodeSecretCursor for another order: got %v, want ErrValidation", err)
+	}
+	if _, err := DecodeSecretCursor("not a cursor", filter); !errors.Is(err, ErrValidation) {
+		t.Errorf("DecodeSecretCursor(garbage): got %v, want ErrValidation", err)
+	}
+}
+
+func TestSecretSearch(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+
+	for _, req := range []*models.CreateSecretRequest{
+		{Title: "Stripe live key", Type: "api_token", Value: "v", Fields: []models.CustomField{{Name: "region", Value: "eu-west-1"}}},
+		{Title: "GitHub", Type: "api_token", Value: "v", Fields: []models.CustomField{{Name: "recovery", Value: "stripes", Concealed: true}}},
+		{Title: "Tripe shop", Type: "api_token", Value: "v"},
+	} {
+		if _, err := service.Create(ctx, req); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	search := func(q string) []string {
+		t.Helper()
+		listed, err := service.List(ctx, models.SecretFilter{Search: q})
+		if err != nil {
+			t.Fatalf("List(q=%q): %v", q, err)
+		}
+		var titles []string
+		for _, secret := range listed.Secrets {
+			titles = append(titles, secret.Title)
+		}
+		slices.Sort(titles)
+		return titles
+	}
+
+	for _, tc := range []struct {
+		q    string
+		want []string
+	}{
+		{"stripe", []string{"Stripe live key"}},
+		{"STRI", []string{"Stripe live key"}},
+		{"ripe", []string{"Stripe live key", "Tripe shop"}},
+		{"stripe west", []string{"Stripe live key"}},
+		{"eu", []string{"Stripe live key"}},
+		{"e", nil},
+		{"recovery", []string{"GitHub"}},
+		{"stripes", nil},
+		{"pest", nil},
+	} {
+		if got := search(tc.q); !slices.Equal(got, tc.want) {
+			t.Errorf("search %q = %v, want %v", tc.q, got, tc.want)
+		}
+	}
+
+	if _, err := service.List(ctx, models.SecretFilter{Search: "--"}); !errors.Is(err, ErrValidation) {
+		t.Errorf("List(q=--): got %v, want ErrValidation", err)
+	}
+
+}
+
+// recordingAuditor keeps audit events in memory, failing when err is set
+type recordingAuditor struct {
+	events []*models.AuditEvent
+	err    error
+}
+
+func (a *recordingAuditor) Audit(ctx context.Context, event *models.AuditEvent) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.events = append(a.events, event)
+	return nil
+}
+
+func TestSecretRevealsAreAudited(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+	auditor := &recordingAuditor{}
+	service.SetAuditor(auditor)
+
+	var ids []string
+	var revisions []int
+	for _, title := range []string{"one", "two"} {
+		created, err := service.Create(ctx, &models.CreateSecretRequest{Title: title, Type: "api_token", Value: "v"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		ids = append(ids, created.ID)
+		revisions = append(revisions, created.Revision)
+	}
+
+	// Creating returns the value, so it counts as a reveal
+	if len(auditor.events) != 2 {
+		t.Fatalf("Create audited %d events, want one per secret", len(auditor.events))
+	}
+	auditor.events = nil
+
+	// Listing metadata, moving and listing the trash reveal nothing
+	if _, err := service.List(ctx, models.SecretFilter{}); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if _, err := service.Move(ctx, ids[1], revisions[1], &models.MoveSecretRequest{Folder: "archive"}); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if _, err := service.ListTrash(ctx); err != nil {
+		t.Fatalf("ListTrash: %v", err)
+	}
+	if len(auditor.events) != 0 {
+		t.Errorf("metadata requests audited %d events, want none", len(auditor.events))
+	}
+
+	if _, err := service.Get(ctx, ids[0]); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(auditor.events) != 1 {
+		t.Fatalf("Get audited %d events, want 1", len(auditor.events))
+	}
+	if event := auditor.events[0]; event.Action != models.AuditSecretRevealed || event.SecretID != ids[0] || event.Principal != "owner" {
+		t.Errorf("Get audited %+v", event)
+	}
+
+	list, err := service.ListWithValues(ctx, models.SecretFilter{})
+	if err != nil {
+		t.Fatalf("ListWithValues: %v", err)
+	}
+	if len(list.Secrets) != 2 || list.Secrets[0].Value != "v" {
+		t.Errorf("ListWithValues = %d secrets, want both with values", len(list.Secrets))
+	}
+	if len(auditor.events) != 3 {
+		t.Errorf("ListWithValues audited %d events, want one per secret", len(auditor.events)-1)
+	}
+
+	if _, err := service.GetVersion(ctx, ids[0], 1); err != nil {
+		t.Fatalf("GetVersion: %v", err)
+	}
+	if len(auditor.events) != 4 {
+		t.Errorf("GetVersion audited %d events, want 1", len(auditor.events)-3)
+	}
+
+	// Nothing is revealed when the reveal cannot be audited
+	auditor.err = errors.New("disk full")
+	if _, err := service.Get(ctx, ids[0]); err == nil {
+		t.Error("Get succeeded although auditing failed")
+	}
+	if _, err := service.ListWithValues(ctx, models.SecretFilter{}); err == nil {
+		t.Error("ListWithValues succeeded although auditing failed")
+	}
+}
+
+func TestRenderHidesUnreadableReferences(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+
+	_, err := service.policyService.Create(ctx, &models.CreatePolicyRequest{
+		Name: "apps-readers",
+		Document: models.PolicyDocument{Statements: []models.PolicyStatement{{
+			Effect: models.EffectAllow, Actions: []string{models.ActionRead}, Resource: "secrets",
+			Conditions: map[string][]string{"folder": {"apps"}},
+		}}},
+		Subjects: []models.PolicySubject{{Type: models.SubjectUser, ID: "bob"}},
+	})
+	if err != nil {
+		t.Fatalf("Create policy: %v", err)
+	}
+
+	for _, req := range []*models.CreateSecretRequest{
+		{Title: "root", Type: "api_token", Value: "hidden", Folder: "ops"},
+		{Title: "hidden", Type: "api_token", Value: `{{ref "ops/root"}}`, Folder: "apps"},
+		{Title: "missing", Type: "api_token", Value: `{{ref "ops/nope"}}`, Folder: "apps"},
+	} {
+		if _, err := service.Create(ctx, req); err != nil {
+			t.Fatalf("Create %s: %v", req.Title, err)
+		}
+	}
+
+	list, err := service.List(ctx, models.SecretFilter{FolderPrefix: "apps"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	ids := map[string]string{}
+	for _, secret := range list.Secrets {
+		ids[secret.Title] = secret.ID
+	}
+
+	bob := WithPrincipal(context.Background(), Principal{User: "bob"})
+	_, hiddenErr := service.Render(bob, ids["hidden"])
+	_, missingErr := service.Render(bob, ids["missing"])
+	if !errors.Is(hiddenErr, ErrValidation) || !errors.Is(missingErr, ErrValidation) {
+		t.Fatalf("Render errors = %v, %v, want validation errors", hiddenErr, missingErr)
+	}
+	if hidden, missing := strings.ReplaceAll(hiddenErr.Error(), "root", "nope"), missingErr.Error(); hidden != missing {
+		t.Errorf("unreadable reference failed with %q, missing one with %q", hiddenErr, missingErr)
+	}
+
+	// The owner may read both
+	if rendered, err := service.Render(ctx, ids["hidden"]); err != nil || rendered.Value != "hidden" {
+		t.Errorf("owner Render = %+v, %v", rendered, err)
+	}
+}
+
+func TestRenderLimitsExpansion(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+
+	// Each level references the previous one twice, doubling the rendered size
+	ids := make([]string, 13)
+	value := strings.Repeat("x", 1024)
+	for i := range ids {
+		created, err := service.Create(ctx, &models.CreateSecretRequest{Title: fmt.Sprintf("level%d", i), Type: "api_token", Value: value})
+		if err != nil {
+			t.Fatalf("Create level%d: %v", i, err)
+		}
+		ids[i] = created.ID
+		value = fmt.Sprintf(`{{ref "level%d"}}{{ref "level%d"}}`, i, i)
+	}
+
+	rendered, err := service.Render(ctx, ids[8])
+	if err != nil {
+		t.Fatalf("Render of 256 KiB: %v", err)
+	}
+	if len(rendered.Value) != 256<<10 {
+		t.Errorf("Render = %d bytes, want %d", len(rendered.Value), 256<<10)
+	}
+
+	if _, err := service.Render(ctx, ids[12]); !errors.Is(err, ErrValidation) {
+		t.Errorf("Render of 4 MiB: got %v, want ErrValidation", err)
+	}
+}
+
+func TestSecretUntypedLegacyType(t *testing.T) {
+	ctx := ownerContext()
+	service, vault := newTestSecretService(t)
+
+	if _, err := service.Create(ctx, &models.CreateSecretRequest{Title: "Router", Type: "wifi", Value: "hunter2"}); !errors.Is(err, ErrValidation) {
+		t.Errorf("Create of an unknown type: got %v, want ErrValidation", err)
+	}
+
+	// A secret written before the registry, with a free-form type and an opaque value
+	key, err := vault.GetKey()
+	if err != nil {
+		t.Fatalf("GetKey: %v", err)
+	}
+	encrypted, err := utils.Encrypt([]byte("hunter2"), key)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	legacy := &models.Secret{Title: "Router", Type: "wifi", EncryptedValue: encrypted, UpdatedBy: "owner"}
+	if err := service.repo.Create(ctx, legacy); err != nil {
+		t.Fatalf("Create legacy secret: %v", err)
+	}
+
+	updated, err := service.Update(ctx, legacy.ID, legacy.Revision, &models.UpdateSecretRequest{Title: "Home router", Type: "wifi", Value: "correct horse"})
+	if err != nil {
+		t.Fatalf("Update keeping the legacy type: %v", err)
+	}
+	if updated.Value != "correct horse" || updated.Type != "wifi" || updated.Data != nil {
+		t.Errorf("Update = value %q type %q data %v, want the opaque value kept as wifi", updated.Value, updated.Type, updated.Data)
+	}
+
+	patched, err := service.Patch(ctx, legacy.ID, updated.Revision, []byte(`{"title": "Office router"}`))
+	if err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	if got, err := service.Get(ctx, legacy.ID); err != nil || got.Title != "Office router" || got.Value != "correct horse" {
+		t.Errorf("Get after Patch = %+v, %v; want the new title and the kept value", got, err)
+	}
+
+	// Typed data, or a switch to another unknown type, needs a built-in type
+	for name, req := range map[string]*models.UpdateSecretRequest{
+		"data":         {Title: "Router", Type: "wifi", Value: "v", Data: map[string]any{"ssid": "home"}},
+		"unknown type": {Title: "Router", Type: "router", Value: "v"},
+	} {
+		if _, err := service.Update(ctx, legacy.ID, patched.Revision, req); !errors.Is(err, ErrValidation) {
+			t.Errorf("Update with %s: got %v, want ErrValidation", name, err)
+		}
+	}
+
+	migrated, err := service.Update(ctx, legacy.ID, patched.Revision, &models.UpdateSecretRequest{Title: "Router", Type: "login", Value: "correct horse", Data: map[string]any{"username": "admin"}})
+	if err != nil {
+		t.Fatalf("Update to a built-in type: %v", err)
+	}
+	if migrated.Type != "login" || migrated.Data["username"] != "admin" || migrated.Value != "correct horse" {
+		t.Errorf("migrated secret = %+v, want a login", migrated)
+	}
+}
+
+func TestSecretServiceRequiresUnlockedVault(t *testing.T) {
+	ctx := ownerContext()
+	service, vault := newTestSecretService(t)
+
+	vault.Lock()
+	if _, err := service.Create(ctx, &models.CreateSecretRequest{Title: "t", Type: "api_token", Value: "v"}); err == nil {
+		t.Error("Create succeeded on a locked vault")
+	}
+
+	// The master password is fixed when the vault is initialized
+	if err := vault.Unlock(ctx, "wrong password"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("Unlock with another password: got %v, want ErrInvalidPassword", err)
+	}
+}
+
+func TestSecretUpdateWithoutReadReturnsMetadata(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+
+	_, err := service.policyService.Create(ctx, &models.CreatePolicyRequest{
+		Name: "prod-writers",
+		Document: models.PolicyDocument{Statements: []models.PolicyStatement{
+			{Effect: models.EffectAllow, Actions: []string{models.ActionUpdate}, Resource: "secrets"},
+			{Effect: models.EffectDeny, Actions: []string{models.ActionRead}, Resource: "secrets", Conditions: map[string][]string{"tag": {"prod"}}},
+		}},
+		Subjects: []models.PolicySubject{{Type: models.SubjectUser, ID: "bob"}},
+	})
+	if err != nil {
+		t.Fatalf("Create policy: %v", err)
+	}
+
+	created, err := service.Create(ctx, &models.CreateSecretRequest{
+		Title:  "Stripe",
+		Type:   "api_token",
+		Value:  "sk_live_123",
+		Tags:   []string{"prod"},
+		Fields: []models.CustomField{{Name: "region", Value: "eu-west-1"}, {Name: "recovery", Value: "1234", Concealed: true}},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	bob := WithPrincipal(context.Background(), Principal{User: "bob"})
+	withheld := func(name string, got *models.SecretResponse) {
+		t.Helper()
+		if got.Value != "" || got.Data != nil || len(got.Fields) != 0 {
+			t.Errorf("%s returned value %q, data %v and fields %v to a caller who may not read", name, got.Value, got.Data, got.Fields)
+		}
+	}
+
+	patched, err := service.Patch(bob, created.ID, created.Revision, []byte(`{"title": "Stripe live"}`))
+	if err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	withheld("Patch", patched)
+	if patched.Title != "Stripe live" || patched.Revision != created.Revision+1 {
+		t.Errorf("Patch = title %q revision %d, want the new title at the next revision", patched.Title, patched.Revision)
+	}
+
+	updated, err := service.Update(bob, created.ID, patched.Revision, &models.UpdateSecretRequest{Title: "Stripe", Type: "api_token", Value: "sk_live_456", Tags: []string{"prod"}})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	withheld("Update", updated)
+
+	// The owner still sees the values the patch carried over
+	got, err := service.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Value != "sk_live_456" || len(got.Fields) != 0 {
+		t.Errorf("Get = value %q fields %v, want the updated value", got.Value, got.Fields)
+	}
+}
+
+func TestSecretWritesAuthorizeBeforeRevisionCheck(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+
+	_, err := service.policyService.Create(ctx, &models.CreatePolicyRequest{
+		Name: "readers",
+		Document: models.PolicyDocument{Statements: []models.PolicyStatement{
+			{Effect: models.EffectAllow, Actions: []string{models.ActionRead}, Resource: "secrets"},
+		}},
+		Subjects: []models.PolicySubject{{Type: models.SubjectUser, ID: "bob"}},
+	})
+	if err != nil {
+		t.Fatalf("Create policy: %v", err)
+	}
+	bob := WithPrincipal(context.Background(), Principal{User: "bob"})
+
+	created, err := service.Create(ctx, &models.CreateSecretRequest{Title: "Stripe", Type: "api_token", Value: "sk_live_123"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// A caller who may not write cannot tell a current revision from a stale one
+	for _, revision := range []int{created.Revision, created.Revision + 1} {
+		if _, err := service.Update(bob, created.ID, revision, &models.UpdateSecretRequest{Title: "Stripe", Type: "api_token", Value: "sk_live_456"}); !errors.Is(err, ErrAccessDenied) {
+			t.Errorf("Update at revision %d: got %v, want ErrAccessDenied", revision, err)
+		}
+		if _, err := service.Move(bob, created.ID, revision, &models.MoveSecretRequest{Folder: "archive"}); !errors.Is(err, ErrAccessDenied) {
+			t.Errorf("Move at revision %d: got %v, want ErrAccessDenied", revision, err)
+		}
+	}
+
+	// Moves are refused once the secret changed since it was read
+	if _, err := service.Move(ctx, created.ID, created.Revision+1, &models.MoveSecretRequest{Folder: "archive"}); !errors.Is(err, ErrRevisionMismatch) {
+		t.Errorf("Move at a stale revision: got %v, want ErrRevisionMismatch", err)
+	}
+	moved, err := service.Move(ctx, created.ID, created.Revision, &models.MoveSecretRequest{Folder: "archive"})
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if moved.Folder != "archive" || moved.Revision != created.Revision+1 {
+		t.Errorf("Move = folder %q revision %d, want archive at the next revision", moved.Folder, moved.Revision)
+	}
+}
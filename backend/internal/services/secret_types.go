@@ -0,0 +1,362 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"my-vault/internal/models"
+	"my-vault/internal/utils"
+)
+
+// payloadVersion marks encrypted values stored as a structured JSON payload
+const payloadVersion = 1
+
+// Field kinds of structured secret types
+const (
+	FieldString     = "string"
+	FieldStringList = "string_list"
+	FieldNumber     = "number"
+)
+
+var (
+	expiryPattern = regexp.MustCompile(`^(0[1-9]|1[0-2])/([0-9]{2}|[0-9]{4})$`)
+	cvvPattern    = regexp.MustCompile(`^[0-9]{3,4}$`)
+)
+
+// fieldSpec describes one typed field of a structured secret type
+type fieldSpec struct {
+	name     string
+	kind     string
+	required bool
+	expected bool // not required, but reported by the health check when missing
+	validate func(value any) error
+}
+
+// secretType describes a built-in secret type and its field set.
+// The primary field is what SecretResponse.Value exposes.
+type secretType struct {
+	name    string
+	primary string
+	fields  []fieldSpec
+}
+
+// secretTypes is the registry of built-in secret types
+var secretTypes = map[string]*secretType{
+	"login": {
+		name:    "login",
+		primary: "password",
+		fields: []fieldSpec{
+			{name: "username", kind: FieldString, expected: true},
+			{name: "password", kind: FieldString, required: true},
+			{name: "urls", kind: FieldStringList, expected: true, validate: validateURLs},
+			{name: "totp", kind: FieldString, validate: validateTOTP},
+		},
+	},
+	"credit_card": {
+		name:    "credit_card",
+		primary: "number",
+		fields: []fieldSpec{
+			{name: "cardholder", kind: FieldString, expected: true},
+			{name: "number", kind: FieldString, required: true, validate: validateCardNumber},
+			{name: "expiry", kind: FieldString, expected: true, validate: validatePattern(expiryPattern, "MM/YY or MM/YYYY")},
+			{name: "cvv", kind: FieldString, validate: validatePattern(cvvPattern, "3 or 4 digits")},
+		},
+	},
+	"ssh_key": {
+		name:    "ssh_key",
+		primary: "private_key",
+		fields: []fieldSpec{
+			{name: "private_key", kind: FieldString, required: true, validate: validatePrivateKey},
+			{name: "public_key", kind: FieldString},
+			{name: "passphrase", kind: FieldString},
+			{name: "comment", kind: FieldString},
+		},
+	},
+	"api_token": {
+		name:    "api_token",
+		primary: "token",
+		fields: []fieldSpec{
+			{name: "token", kind: FieldString, required: true},
+			{name: "key_id", kind: FieldString},
+			{name: "url", kind: FieldString, expected: true, validate: validateURL},
+		},
+	},
+	"database_credential": {
+		name:    "database_credential",
+		primary: "password",
+		fields: []fieldSpec{
+			{name: "engine", kind: FieldString, expected: true},
+			{name: "host", kind: FieldString, expected: true},
+			{name: "port", kind: FieldNumber, validate: validatePort},
+			{name: "database", kind: FieldString, expected: true},
+			{name: "username", kind: FieldString, expected: true},
+			{name: "password", kind: FieldString, required: true},
+		},
+	},
+	"totp": {
+		name:    "totp",
+		primary: "secret",
+		fields: []fieldSpec{
+			{name: "secret", kind: FieldString, required: true, validate: validateTOTP},
+			{name: "issuer", kind: FieldString, expected: true},
+			{name: "account", kind: FieldString},
+		},
+	},
+	"secure_note": {
+		name:    "secure_note",
+		primary: "content",
+		fields: []fieldSpec{
+			{name: "content", kind: FieldString, required: true},
+		},
+	},
+}
+
+// secretPayload is the JSON document encrypted as a structured secret's value
+type secretPayload struct {
+	Version int            `json:"v"`
+	Fields  map[string]any `json:"fields"`
+}
+
+// SecretTypes returns the registry of built-in secret types, sorted by name
+func SecretTypes() []models.SecretTypeInfo {
+	infos := make([]models.SecretTypeInfo, 0, len(secretTypes))
+	for _, t := range secretTypes {
+		info := models.SecretTypeInfo{
+			Name:         t.name,
+			PrimaryField: t.primary,
+		}
+		for _, field := range t.fields {
+			info.Fields = append(info.Fields, models.SecretTypeField{
+				Name:     field.name,
+				Kind:     field.kind,
+				Required: field.required,
+				Expected: field.expected,
+			})
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// buildPayload validates value and data against the type's field set and
+// returns the JSON payload to encrypt. A non-empty value fills the primary field.
+func buildPayload(typeName, value string, data map[string]any) ([]byte, map[string]any, error) {
+	t, ok := secretTypes[typeName]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: unknown secret type %q", ErrValidation, typeName)
+	}
+
+	fields := make(map[string]any, len(data)+1)
+	for name, v := range data {
+		fields[name] = v
+	}
+
+	if value != "" {
+		if existing, ok := fields[t.primary]; ok && existing != value {
+			return nil, nil, fmt.Errorf("%w: value and data.%s disagree", ErrValidation, t.primary)
+		}
+		fields[t.primary] = value
+	}
+
+	if err := t.validate(fields); err != nil {
+		return nil, nil, err
+	}
+
+	payload, err := json.Marshal(secretPayload{Version: payloadVersion, Fields: fields})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode secret payload: %w", err)
+	}
+
+	return payload, fields, nil
+}
+
+// buildUntypedPayload keeps the value of a secret whose type predates the registry
+// as it was stored before structured types existed: one opaque string without fields
+func buildUntypedPayload(typeName, value string, data map[string]any) ([]byte, map[string]any, error) {
+	if len(data) > 0 {
+		return nil, nil, fmt.Errorf("%w: type %q has no typed fields; move the secret to a built-in type to use data", ErrValidation, typeName)
+	}
+	return []byte(value), nil, nil
+}
+
+// decodePayload splits a decrypted value into the primary value and typed fields.
+// Values written before structured types existed are returned as the primary value.
+func decodePayload(typeName string, plaintext []byte) (string, map[string]any) {
+	t, known := secretTypes[typeName]
+
+	var payload secretPayload
+	if err := json.Unmarshal(plaintext, &payload); err != nil || payload.Version != payloadVersion || payload.Fields == nil {
+		if !known {
+			return string(plaintext), nil
+		}
+		return string(plaintext), map[string]any{t.primary: string(plaintext)}
+	}
+
+	if !known {
+		return "", payload.Fields
+	}
+
+	value, _ := payload.Fields[t.primary].(string)
+	return value, payload.Fields
+}
+
+// validate checks fields against the type definition, normalizing list values in place
+func (t *secretType) validate(fields map[string]any) error {
+	specs := make(map[string]fieldSpec, len(t.fields))
+	for _, spec := range t.fields {
+		specs[spec.name] = spec
+	}
+
+	for name := range fields {
+		if _, ok := specs[name]; !ok {
+			return fmt.Errorf("%w: unknown field %q for type %s", ErrValidation, name, t.name)
+		}
+	}
+
+	for _, spec := range t.fields {
+		value, present := fields[spec.name]
+		if !present || value == nil || value == "" {
+			if spec.required {
+				return fmt.Errorf("%w: field %q is required for type %s", ErrValidation, spec.name, t.name)
+			}
+			delete(fields, spec.name)
+			continue
+		}
+
+		normalized, err := checkKind(spec, value)
+		if err != nil {
+			return err
+		}
+		fields[spec.name] = normalized
+
+		if spec.validate != nil {
+			if err := spec.validate(normalized); err != nil {
+				return fmt.Errorf("%w: field %q: %v", ErrValidation, spec.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkKind verifies a decoded JSON value has the field's kind
+func checkKind(spec fieldSpec, value any) (any, error) {
+	switch spec.kind {
+	case FieldString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case FieldNumber:
+		if n, ok := value.(float64); ok {
+			return n, nil
+		}
+	case FieldStringList:
+		switch list := value.(type) {
+		case []string:
+			return list, nil
+		case []any:
+			values := make([]string, 0, len(list))
+			for _, item := range list {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("%w: field %q must be a list of strings", ErrValidation, spec.name)
+				}
+				values = append(values, s)
+			}
+			return values, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: field %q must be a %s", ErrValidation, spec.name, strings.ReplaceAll(spec.kind, "_", " "))
+}
+
+// validateURL requires an absolute URL
+func validateURL(value any) error {
+	u, err := url.Parse(value.(string))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("must be an absolute URL")
+	}
+	return nil
+}
+
+// validateURLs requires every entry to be an absolute URL
+func validateURLs(value any) error {
+	for _, u := range value.([]string) {
+		if err := validateURL(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateCardNumber checks length and the Luhn checksum
+func validateCardNumber(value any) error {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(value.(string))
+	if len(digits) < 12 || len(digits) > 19 {
+		return fmt.Errorf("must have 12 to 19 digits")
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if d < 0 || d > 9 {
+			return fmt.Errorf("must contain only digits")
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+
+	if sum%10 != 0 {
+		return fmt.Errorf("failed checksum")
+	}
+	return nil
+}
+
+// validatePrivateKey requires a PEM or OpenSSH private key block
+func validatePrivateKey(value any) error {
+	if !strings.Contains(value.(string), "PRIVATE KEY-----") {
+		return fmt.Errorf("must be a PEM or OpenSSH private key")
+	}
+	return nil
+}
+
+// validateTOTP requires an otpauth://totp/ URI or base32 seed
+func validateTOTP(value any) error {
+	if _, err := utils.ParseTOTP(value.(string)); err != nil {
+		return fmt.Errorf("must be an otpauth URI or base32 seed: %v", err)
+	}
+	return nil
+}
+
+// validatePort requires an integer TCP port
+func validatePort(value any) error {
+	n := value.(float64)
+	if n != math.Trunc(n) || n < 1 || n > 65535 {
+		return fmt.Errorf("must be an integer between 1 and 65535")
+	}
+	return nil
+}
+
+// validatePattern returns a validator matching a regular expression
+func validatePattern(pattern *regexp.Regexp, description string) func(any) error {
+	return func(value any) error {
+		if !pattern.MatchString(value.(string)) {
+			return fmt.Errorf("must be %s", description)
+		}
+		return nil
+	}
+}
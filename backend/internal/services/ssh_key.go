@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"strings"
+
+	"my-vault/internal/models"
+	"my-vault/internal/utils"
+)
+
+// Metadata fields published for ssh_key secrets
+const (
+	sshPublicKeyField   = "public_key"
+	sshFingerprintField = "fingerprint"
+)
+
+// defaultRSAKeyBits is the RSA modulus size used when none is requested
+const defaultRSAKeyBits = 3072
+
+// rsaKeygenSlots bounds how many RSA keys are generated at once. Each one can
+// take a CPU core for seconds, so requests beyond the limit are turned away.
+var rsaKeygenSlots = make(chan struct{}, 2)
+
+// rsaKeyStrength maps the accepted RSA modulus sizes to their estimated security strength in bits
+var rsaKeyStrength = map[int]float64{2048: 112, 3072: 128, 4096: 140}
+
+// generateSSHKey generates an SSH private key in the OpenSSH format
+func generateSSHKey(req *models.GenerateRequest) (*models.GenerateResponse, error) {
+	algorithm := req.KeyAlgorithm
+	if algorithm == "" {
+		algorithm = utils.SSHKeyEd25519
+	}
+
+	var strength float64
+	bits := 0
+	switch algorithm {
+	case utils.SSHKeyEd25519:
+		strength = 128
+	case utils.SSHKeyRSA:
+		bits = req.KeyBits
+		if bits == 0 {
+			bits = defaultRSAKeyBits
+		}
+		var ok bool
+		if strength, ok = rsaKeyStrength[bits]; !ok {
+			return nil, fmt.Errorf("%w: RSA keys must have 2048, 3072 or 4096 bits", ErrValidation)
+		}
+
+		select {
+		case rsaKeygenSlots <- struct{}{}:
+			defer func() { <-rsaKeygenSlots }()
+		default:
+			return nil, fmt.Errorf("%w: too many RSA keys are being generated, try again shortly", ErrUnavailable)
+		}
+	default:
+		return nil, fmt.Errorf("%w: key algorithm must be %s or %s", ErrValidation, utils.SSHKeyEd25519, utils.SSHKeyRSA)
+	}
+
+	key, err := utils.GenerateSSHKey(algorithm, bits)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := utils.MarshalSSHPrivateKey(key, utils.SSHFormatOpenSSH, req.Comment, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, fingerprint, err := utils.SSHPublicKey(key, req.Comment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GenerateResponse{
+		Mode:        models.GenerateModeSSHKey,
+		Value:       privateKey,
+		Entropy:     strength,
+		PublicKey:   publicKey,
+		Fingerprint: fingerprint,
+	}, nil
+}
+
+// ExportSSHKey re-encodes the private key of an ssh_key secret in the requested
+// format, optionally encrypted with a new passphrase
+func (s *SecretService) ExportSSHKey(ctx context.Context, id string, req *models.SSHKeyExportRequest) (*models.SSHKeyExportResponse, error) {
+	key, err := s.vaultService.GetKey()
+	if err != nil {
+		return nil, fmt.Errorf("vault is locked: %w", err)
+	}
+
+	format := req.Format
+	if format == "" {
+		format = utils.SSHFormatOpenSSH
+	}
+	if format != utils.SSHFormatOpenSSH && format != utils.SSHFormatPEM {
+		return nil, fmt.Errorf("%w: format must be %s or %s", ErrValidation, utils.SSHFormatOpenSSH, utils.SSHFormatPEM)
+	}
+
+	secret, err := s.authorizedSecret(ctx, models.ActionRead, id)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Type != "ssh_key" {
+		return nil, fmt.Errorf("%w: secret is not an ssh_key", ErrValidation)
+	}
+
+	plaintext, err := utils.Decrypt(secret.EncryptedValue, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	_, data := decodePayload(secret.Type, plaintext)
+	private, comment, err := parseSSHKeyData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	exported, err := utils.MarshalSSHPrivateKey(private, format, comment, []byte(req.Passphrase))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auditReveal(ctx, secret); err != nil {
+		return nil, err
+	}
+
+	publicKey, fingerprint, err := utils.SSHPublicKey(private, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SSHKeyExportResponse{
+		Format:      format,
+		PrivateKey:  exported,
+		PublicKey:   publicKey,
+		Fingerprint: fingerprint,
+	}, nil
+}
+
+// generateValue produces the primary value requested by a create request's
+// generate options. SSH keys may only be generated for ssh_key secrets, and
+// their comment is kept in the returned copy of data.
+func generateValue(typeName string, req *models.GenerateRequest, data map[string]any) (string, map[string]any, error) {
+	if req.Mode == models.GenerateModeSSHKey && typeName != "ssh_key" {
+		return "", nil, fmt.Errorf("%w: SSH keys can only be generated for ssh_key secrets", ErrValidation)
+	}
+	if typeName == "ssh_key" && req.Mode != models.GenerateModeSSHKey {
+		return "", nil, fmt.Errorf("%w: ssh_key secrets must be generated in %s mode", ErrValidation, models.GenerateModeSSHKey)
+	}
+
+	generated, err := Generate(req)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if req.Mode == models.GenerateModeSSHKey && req.Comment != "" {
+		withComment := make(map[string]any, len(data)+1)
+		for name, v := range data {
+			withComment[name] = v
+		}
+		withComment["comment"] = req.Comment
+		data = withComment
+	}
+
+	return generated.Value, data, nil
+}
+
+// sshKeyMetadata publishes the public key and fingerprint of an ssh_key secret as
+// plain custom fields, replacing any fields of the same name. The custom field is
+// the only copy of the public key: a public_key passed in data is moved there,
+// and must match the private key when the key can be read. Keys encrypted with a
+// passphrase that is not stored only publish a public key passed in data.
+func sshKeyMetadata(typeName, value string, data map[string]any, fields []models.CustomField) (map[string]any, []models.CustomField, error) {
+	if typeName != "ssh_key" {
+		return data, fields, nil
+	}
+
+	result := make([]models.CustomField, 0, len(fields)+2)
+	for _, field := range fields {
+		if field.Name != sshPublicKeyField && field.Name != sshFingerprintField {
+			result = append(result, field)
+		}
+	}
+
+	rest := make(map[string]any, len(data))
+	for name, v := range data {
+		if name != sshPublicKeyField {
+			rest[name] = v
+		}
+	}
+	given, _ := data[sshPublicKeyField].(string)
+	given = strings.TrimSpace(given)
+
+	// The private key may be passed as the value; missing or malformed keys are
+	// left to the type's validation to report
+	privateKey, _ := data["private_key"].(string)
+	if value != "" {
+		privateKey = value
+	}
+
+	var publicKey string
+	if privateKey != "" {
+		private, comment, err := parseSSHKeyData(map[string]any{"private_key": privateKey, "passphrase": data["passphrase"], "comment": data["comment"]})
+		switch {
+		case errors.Is(err, utils.ErrSSHPassphraseMissing):
+		case err != nil:
+			return nil, nil, err
+		default:
+			if publicKey, _, err = utils.SSHPublicKey(private, comment); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if given != "" {
+		givenFingerprint, err := utils.SSHFingerprint(given)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: field %q: %v", ErrValidation, sshPublicKeyField, err)
+		}
+		if publicKey == "" {
+			publicKey = given
+		} else if derived, _ := utils.SSHFingerprint(publicKey); derived != givenFingerprint {
+			return nil, nil, fmt.Errorf("%w: field %q does not match the private key", ErrValidation, sshPublicKeyField)
+		}
+	}
+	if publicKey == "" {
+		return rest, result, nil
+	}
+
+	fingerprint, err := utils.SSHFingerprint(publicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rest, append(result,
+		models.CustomField{Name: sshPublicKeyField, Value: publicKey},
+		models.CustomField{Name: sshFingerprintField, Value: fingerprint},
+	), nil
+}
+
+// parseSSHKeyData parses the private key of ssh_key fields using the stored passphrase
+func parseSSHKeyData(data map[string]any) (crypto.Signer, string, error) {
+	privateKey, _ := data["private_key"].(string)
+	passphrase, _ := data["passphrase"].(string)
+	comment, _ := data["comment"].(string)
+
+	private, err := utils.ParseSSHPrivateKey(privateKey, []byte(passphrase))
+	if errors.Is(err, utils.ErrSSHPassphraseMissing) {
+		return nil, "", err
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: field \"private_key\": %v", ErrValidation, err)
+	}
+
+	return private, comment, nil
+}
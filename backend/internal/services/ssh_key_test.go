@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"my-vault/internal/models"
+	"my-vault/internal/utils"
+)
+
+// customField returns the value of a custom field by name
+func customField(fields []models.CustomField, name string) (string, bool) {
+	for _, field := range fields {
+		if field.Name == name {
+			return field.Value, true
+		}
+	}
+	return "", false
+}
+
+func TestSSHKeyMetadata(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+
+	created, err := service.Create(ctx, &models.CreateSecretRequest{
+		Title:    "CI deploy key",
+		Type:     "ssh_key",
+		Generate: &models.GenerateRequest{Mode: models.GenerateModeSSHKey, Comment: "deploy@ci"},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// The public key is only kept as a plain custom field
+	publicKey, ok := customField(created.Fields, sshPublicKeyField)
+	if !ok {
+		t.Fatalf("fields = %+v, want a public_key field", created.Fields)
+	}
+	fingerprint, _ := customField(created.Fields, sshFingerprintField)
+	if want, err := utils.SSHFingerprint(publicKey); err != nil || fingerprint != want {
+		t.Errorf("fingerprint = %q, want %q (%v)", fingerprint, want, err)
+	}
+	if _, ok := created.Data[sshPublicKeyField]; ok {
+		t.Errorf("data = %+v, want no public_key copy", created.Data)
+	}
+
+	// A public key passed in data is moved to the custom field when it matches
+	data := map[string]any{"private_key": created.Value, "comment": "deploy@ci", sshPublicKeyField: publicKey}
+	updated, err := service.Update(ctx, created.ID, created.Revision, &models.UpdateSecretRequest{
+		Title: created.Title,
+		Type:  "ssh_key",
+		Data:  data,
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, _ := customField(updated.Fields, sshPublicKeyField); got != publicKey {
+		t.Errorf("public_key field = %q, want %q", got, publicKey)
+	}
+	if _, ok := updated.Data[sshPublicKeyField]; ok {
+		t.Errorf("data = %+v, want no public_key copy", updated.Data)
+	}
+
+	// A public key that belongs to another private key is refused
+	other, err := Generate(&models.GenerateRequest{Mode: models.GenerateModeSSHKey})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	data[sshPublicKeyField] = other.PublicKey
+	if _, err := service.Update(ctx, created.ID, updated.Revision, &models.UpdateSecretRequest{Title: created.Title, Type: "ssh_key", Data: data}); !errors.Is(err, ErrValidation) {
+		t.Errorf("Update with a mismatched public key: got %v, want ErrValidation", err)
+	}
+
+	// Without the passphrase only the given public key can be published
+	key, err := utils.GenerateSSHKey(utils.SSHKeyEd25519, 0)
+	if err != nil {
+		t.Fatalf("GenerateSSHKey: %v", err)
+	}
+	encrypted, err := utils.MarshalSSHPrivateKey(key, utils.SSHFormatOpenSSH, "", []byte("secret"))
+	if err != nil {
+		t.Fatalf("MarshalSSHPrivateKey: %v", err)
+	}
+	givenKey, givenFingerprint, err := utils.SSHPublicKey(key, "")
+	if err != nil {
+		t.Fatalf("SSHPublicKey: %v", err)
+	}
+	locked, err := service.Create(ctx, &models.CreateSecretRequest{
+		Title: "Encrypted key",
+		Type:  "ssh_key",
+		Data:  map[string]any{"private_key": encrypted, sshPublicKeyField: givenKey},
+	})
+	if err != nil {
+		t.Fatalf("Create with an encrypted key: %v", err)
+	}
+	if got, _ := customField(locked.Fields, sshFingerprintField); got != givenFingerprint {
+		t.Errorf("fingerprint = %q, want %q", got, givenFingerprint)
+	}
+}
+
+func TestGenerateRSAKeyLimit(t *testing.T) {
+	req := &models.GenerateRequest{Mode: models.GenerateModeSSHKey, KeyAlgorithm: utils.SSHKeyRSA, KeyBits: 2048}
+
+	// With every slot taken, RSA requests are turned away rather than queued
+	for range cap(rsaKeygenSlots) {
+		rsaKeygenSlots <- struct{}{}
+	}
+	_, err := Generate(req)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Generate with all slots taken: got %v, want ErrUnavailable", err)
+	}
+	if _, err := Generate(&models.GenerateRequest{Mode: models.GenerateModeSSHKey}); err != nil {
+		t.Errorf("Ed25519 Generate with all RSA slots taken: %v", err)
+	}
+	for range cap(rsaKeygenSlots) {
+		<-rsaKeygenSlots
+	}
+
+	resp, err := Generate(req)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if resp.Entropy != 112 || len(rsaKeygenSlots) != 0 {
+		t.Errorf("Generate = %v bits of strength with %d slots held, want 112 and none", resp.Entropy, len(rsaKeygenSlots))
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"my-vault/internal/models"
+	"my-vault/internal/utils"
+)
+
+// TOTP returns the current one-time password of a secret holding a TOTP seed,
+// taken from a login's totp field or the secret's primary value
+func (s *SecretService) TOTP(ctx context.Context, id string) (*models.TOTPResponse, error) {
+	key, err := s.vaultService.GetKey()
+	if err != nil {
+		return nil, fmt.Errorf("vault is locked: %w", err)
+	}
+
+	secret, err := s.authorizedSecret(ctx, models.ActionRead, id)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := utils.Decrypt(secret.EncryptedValue, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	value, data := decodePayload(secret.Type, plaintext)
+	seed, _ := data["totp"].(string)
+	if seed == "" {
+		seed = value
+	}
+
+	totp, err := utils.ParseTOTP(seed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: secret does not hold a TOTP seed: %v", ErrValidation, err)
+	}
+
+	if err := s.auditReveal(ctx, secret); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &models.TOTPResponse{
+		Code:             totp.Code(now),
+		SecondsRemaining: totp.Remaining(now),
+		Period:           totp.Period,
+		Digits:           totp.Digits,
+		Algorithm:        totp.Algorithm,
+	}, nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+// trashPurgeInterval is how often the background job looks for expired trash
+const trashPurgeInterval = time.Hour
+
+// ListTrash retrieves the metadata of the trashed secrets the caller may read
+func (s *SecretService) ListTrash(ctx context.Context) ([]*models.SecretSummary, error) {
+	if !s.vaultService.IsUnlocked() {
+		return nil, fmt.Errorf("vault is locked: %w", ErrLocked)
+	}
+
+	secrets, err := s.repo.ListTrash(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluator, err := s.policyService.Evaluator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []*models.SecretSummary{}
+	for _, secret := range secrets {
+		if evaluator.Allows(models.ActionRead, secretAttributes(secret)) {
+			summaries = append(summaries, newSecretSummary(secret))
+		}
+	}
+
+	return summaries, nil
+}
+
+// RestoreFromTrash moves a trashed secret back into the vault
+func (s *SecretService) RestoreFromTrash(ctx context.Context, id string) (*models.SecretResponse, error) {
+	key, err := s.vaultService.GetKey()
+	if err != nil {
+		return nil, fmt.Errorf("vault is locked: %w", err)
+	}
+
+	secret, err := s.repo.GetTrashed(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluator, err := s.policyService.Evaluator(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restoring undoes a delete and writes the secret again, so it takes both
+	attrs := secretAttributes(secret)
+	for _, action := range []string{models.ActionDelete, models.ActionUpdate} {
+		if !evaluator.Allows(action, attrs) {
+			return nil, fmt.Errorf("%w: %s not permitted", ErrAccessDenied, action)
+		}
+	}
+
+	if err := s.repo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+
+	// Restore counts as a write, so the response carries the new revision
+	secret.DeletedAt = nil
+	secret.Revision++
+
+	if !evaluator.Allows(models.ActionRead, attrs) {
+		return metadataResponse(secret), nil
+	}
+	return s.reveal(ctx, secret, key)
+}
+
+// Purge permanently removes a trashed secret
+func (s *SecretService) Purge(ctx context.Context, id string) error {
+	if !s.vaultService.IsUnlocked() {
+		return fmt.Errorf("vault is locked: %w", ErrLocked)
+	}
+
+	secret, err := s.repo.GetTrashed(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.policyService.Authorize(ctx, models.ActionDelete, secretAttributes(secret)); err != nil {
+		return err
+	}
+
+	if err := s.repo.Purge(ctx, id); err != nil {
+		return err
+	}
+	s.deleteBlobs(id)
+
+	return nil
+}
+
+// StartTrashPurge starts a background job that permanently removes secrets
+// trashed longer than retention ago. It runs until the returned function is called.
+func (s *SecretService) StartTrashPurge(retention time.Duration) (stop func()) {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(trashPurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			s.purgeExpiredTrash(retention)
+
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// purgeExpiredTrash runs one pass of the trash purge job
+func (s *SecretService) purgeExpiredTrash(retention time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	purged, err := s.repo.PurgeDeletedBefore(ctx, time.Now().Add(-retention))
+	if errors.Is(err, ErrLocked) {
+		// Retried on the next pass once the vault is unlocked
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to purge trash: %v", err)
+		return
+	}
+
+	for _, id := range purged {
+		s.deleteBlobs(id)
+	}
+
+	if len(purged) > 0 {
+		log.Printf("Purged %d secrets from the trash", len(purged))
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"my-vault/internal/models"
+	"my-vault/internal/repository"
+)
+
+// withBlobs gives service a blob store in a temporary directory and returns it
+func withBlobs(t *testing.T, service *SecretService) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	blobs, err := repository.NewBlobStore(dir)
+	if err != nil {
+		t.Fatalf("NewBlobStore: %v", err)
+	}
+	service.SetAttachments(blobs, AttachmentLimits{MaxFileSize: 1 << 20, MaxTotalSize: 1 << 20})
+	return dir
+}
+
+// trashedSecret creates a secret with an attachment and moves it to the trash
+func trashedSecret(t *testing.T, service *SecretService, req *models.CreateSecretRequest) *models.SecretResponse {
+	t.Helper()
+
+	ctx := ownerContext()
+	created, err := service.Create(ctx, req)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := service.AddAttachment(ctx, created.ID, "recovery.txt", strings.NewReader("recovery codes")); err != nil {
+		t.Fatalf("AddAttachment: %v", err)
+	}
+	if err := service.Delete(ctx, created.ID, created.Revision); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	return created
+}
+
+func TestRestoreFromTrash(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+	withBlobs(t, service)
+
+	_, err := service.policyService.Create(ctx, &models.CreatePolicyRequest{
+		Name: "cleaners",
+		Document: models.PolicyDocument{Statements: []models.PolicyStatement{
+			{Effect: models.EffectAllow, Actions: []string{models.ActionDelete}, Resource: "secrets"},
+			{Effect: models.EffectAllow, Actions: []string{models.ActionUpdate}, Resource: "secrets", Conditions: map[string][]string{"tag": {"ops"}}},
+			{Effect: models.EffectAllow, Actions: []string{models.ActionRead}, Resource: "secrets", Conditions: map[string][]string{"folder": {"shared"}}},
+		}},
+		Subjects: []models.PolicySubject{{Type: models.SubjectUser, ID: "bob"}},
+	})
+	if err != nil {
+		t.Fatalf("Create policy: %v", err)
+	}
+	bob := WithPrincipal(context.Background(), Principal{User: "bob"})
+
+	// Delete alone is not enough to bring a secret back
+	readOnly := trashedSecret(t, service, &models.CreateSecretRequest{Title: "Stripe", Type: "api_token", Value: "sk_live_123", Tags: []string{"prod"}})
+	if _, err := service.RestoreFromTrash(bob, readOnly.ID); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("RestoreFromTrash without update: got %v, want ErrAccessDenied", err)
+	}
+
+	// A caller who may restore but not read gets the metadata only
+	hidden := trashedSecret(t, service, &models.CreateSecretRequest{Title: "Pager", Type: "api_token", Value: "pd_123", Tags: []string{"ops"}})
+	restored, err := service.RestoreFromTrash(bob, hidden.ID)
+	if err != nil {
+		t.Fatalf("RestoreFromTrash: %v", err)
+	}
+	if restored.Value != "" || restored.Data != nil || len(restored.Fields) != 0 {
+		t.Errorf("RestoreFromTrash revealed value %q to a caller who may not read", restored.Value)
+	}
+	if restored.Title != "Pager" || restored.Revision != hidden.Revision+2 {
+		t.Errorf("RestoreFromTrash = %q at revision %d, want Pager at revision %d", restored.Title, restored.Revision, hidden.Revision+2)
+	}
+
+	// Readers get the value back, and the secret is live again
+	shared := trashedSecret(t, service, &models.CreateSecretRequest{Title: "Wifi", Type: "api_token", Value: "hunter2", Folder: "shared", Tags: []string{"ops"}})
+	restored, err = service.RestoreFromTrash(bob, shared.ID)
+	if err != nil {
+		t.Fatalf("RestoreFromTrash: %v", err)
+	}
+	if restored.Value != "hunter2" {
+		t.Errorf("RestoreFromTrash value = %q, want hunter2", restored.Value)
+	}
+	if _, err := service.Get(ctx, shared.ID); err != nil {
+		t.Errorf("Get after restore: %v", err)
+	}
+	if attachments, err := service.ListAttachments(ctx, shared.ID); err != nil || len(attachments) != 1 {
+		t.Errorf("ListAttachments after restore = %d, %v; want the attachment back", len(attachments), err)
+	}
+	if _, err := service.RestoreFromTrash(ctx, shared.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second RestoreFromTrash: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+	dir := withBlobs(t, service)
+
+	_, err := service.policyService.Create(ctx, &models.CreatePolicyRequest{
+		Name: "prod-cleaners",
+		Document: models.PolicyDocument{Statements: []models.PolicyStatement{
+			{Effect: models.EffectAllow, Actions: []string{models.ActionDelete}, Resource: "secrets", Conditions: map[string][]string{"tag": {"prod"}}},
+		}},
+		Subjects: []models.PolicySubject{{Type: models.SubjectUser, ID: "bob"}},
+	})
+	if err != nil {
+		t.Fatalf("Create policy: %v", err)
+	}
+	bob := WithPrincipal(context.Background(), Principal{User: "bob"})
+
+	staging := trashedSecret(t, service, &models.CreateSecretRequest{Title: "Staging", Type: "api_token", Value: "s3cret", Tags: []string{"staging"}})
+	if err := service.Purge(bob, staging.ID); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Purge outside the policy: got %v, want ErrAccessDenied", err)
+	}
+
+	prod := trashedSecret(t, service, &models.CreateSecretRequest{Title: "Prod", Type: "api_token", Value: "s3cret", Tags: []string{"prod"}})
+	if err := service.Purge(bob, prod.ID); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if _, err := service.RestoreFromTrash(ctx, prod.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RestoreFromTrash after purge: got %v, want ErrNotFound", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, prod.ID)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("attachment blobs after purge: got %v, want them removed", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, staging.ID)); err != nil {
+		t.Errorf("attachment blobs of a secret still in the trash: %v", err)
+	}
+
+	// Purging only reaches the trash
+	live, err := service.Create(ctx, &models.CreateSecretRequest{Title: "Live", Type: "api_token", Value: "s3cret"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := service.Purge(ctx, live.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Purge of a live secret: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	service, _ := newTestSecretService(t)
+	dir := withBlobs(t, service)
+
+	trashed := trashedSecret(t, service, &models.CreateSecretRequest{Title: "Old", Type: "api_token", Value: "s3cret"})
+
+	// Nothing has been in the trash for an hour yet
+	service.purgeExpiredTrash(time.Hour)
+	if _, err := service.repo.GetTrashed(context.Background(), trashed.ID); err != nil {
+		t.Fatalf("GetTrashed before expiry: %v", err)
+	}
+
+	service.purgeExpiredTrash(0)
+	if _, err := service.repo.GetTrashed(context.Background(), trashed.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTrashed after expiry: got %v, want ErrNotFound", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, trashed.ID)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("attachment blobs after expiry: got %v, want them removed", err)
+	}
+}
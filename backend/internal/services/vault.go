@@ -1,15 +1,24 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"my-vault/internal/models"
+	"my-vault/internal/repository"
 	"my-vault/internal/utils"
 )
 
+// vaultVerifier is the known value encrypted into the vault header to check
+// the master password on unlock
+const vaultVerifier = "vaultbox-key-check"
+
 // VaultService manages the vault state and encryption key
 type VaultService struct {
+	store        repository.VaultStore
 	mu           sync.RWMutex
 	key          []byte
 	salt         []byte
@@ -20,29 +29,83 @@ type VaultService struct {
 }
 
 // NewVaultService creates a new vault service instance
-func NewVaultService() *VaultService {
+func NewVaultService(store repository.VaultStore) *VaultService {
 	return &VaultService{
+		store:        store,
 		autoLockTime: 15 * time.Minute,
 		stopAutoLock: make(chan struct{}),
 	}
 }
 
-// Unlock unlocks the vault with the provided master password
-func (v *VaultService) Unlock(masterPassword string) error {
+// Initialize sets the master password of a new vault by creating its header,
+// then unlocks it. It fails with ErrConflict once the vault is initialized.
+func (v *VaultService) Initialize(ctx context.Context, masterPassword string) error {
 	v.mu.Lock()
 	defer v.mu.Unlock()
 
-	// Generate salt if not exists (first time unlock)
-	if v.salt == nil {
-		salt, err := utils.GenerateSalt()
-		if err != nil {
-			return fmt.Errorf("failed to generate salt: %w", err)
+	_, err := v.store.GetHeader(ctx)
+	if err == nil {
+		return fmt.Errorf("vault is already initialized: %w", ErrConflict)
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	salt, err := utils.GenerateSalt()
+	if err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	verifier, err := utils.Encrypt([]byte(vaultVerifier), utils.DeriveKey(masterPassword, salt))
+	if err != nil {
+		return fmt.Errorf("failed to create key check: %w", err)
+	}
+
+	// Another instance may have initialized the vault since the check above
+	header := &models.VaultHeader{Salt: salt, Verifier: verifier, CreatedAt: time.Now()}
+	if err := v.store.CreateHeader(ctx, header); err != nil {
+		if errors.Is(err, ErrConflict) {
+			return fmt.Errorf("vault is already initialized: %w", err)
 		}
-		v.salt = salt
+		return err
 	}
 
-	// Derive key from master password
-	key := utils.DeriveKey(masterPassword, v.salt)
+	return v.unlock(ctx, masterPassword, header)
+}
+
+// Unlock unlocks the vault with the provided master password.
+// It fails with ErrNotInitialized until the vault has been initialized.
+func (v *VaultService) Unlock(ctx context.Context, masterPassword string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	header, err := v.store.GetHeader(ctx)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrNotInitialized
+	}
+	if err != nil {
+		return err
+	}
+
+	return v.unlock(ctx, masterPassword, header)
+}
+
+// unlock checks the master password against header and keeps the derived key.
+// The caller must hold v.mu.
+func (v *VaultService) unlock(ctx context.Context, masterPassword string, header *models.VaultHeader) error {
+	// Derive key from master password and check it against the header
+	key := utils.DeriveKey(masterPassword, header.Salt)
+	if check, err := utils.Decrypt(header.Verifier, key); err != nil || string(check) != vaultVerifier {
+		return ErrInvalidPassword
+	}
+
+	// Backends encrypted with the vault key are readable from here on
+	if keyed, ok := v.store.(repository.KeyedStore); ok {
+		if err := keyed.Unlock(ctx, key); err != nil {
+			return fmt.Errorf("failed to open vault storage: %w", err)
+		}
+	}
+	v.salt = header.Salt
 	
 	// Store the key in memory
 	v.key = key
@@ -63,6 +126,9 @@ func (v *VaultService) Lock() {
 	// Clear the key from memory
 	v.key = nil
 	v.isUnlocked = false
+	if keyed, ok := v.store.(repository.KeyedStore); ok {
+		keyed.Lock()
+	}
 
 	// Stop auto-lock timer
 	select {
@@ -129,12 +195,14 @@ func (v *VaultService) startAutoLockTimer() {
 }
 
 // GetStatus returns the current vault status
-func (v *VaultService) GetStatus() map[string]interface{} {
+func (v *VaultService) GetStatus(ctx context.Context) map[string]interface{} {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
 
+	_, err := v.store.GetHeader(ctx)
 	status := map[string]interface{}{
-		"unlocked": v.isUnlocked,
+		"initialized": v.isUnlocked || err == nil,
+		"unlocked":    v.isUnlocked,
 	}
 
 	if v.isUnlocked {
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"my-vault/internal/repository"
+)
+
+func TestVaultInitialize(t *testing.T) {
+	ctx := context.Background()
+
+	db := repository.NewMemoryDB()
+	t.Cleanup(db.Close)
+	vault := NewVaultService(repository.NewMemoryVaultRepository(db))
+	t.Cleanup(vault.Lock)
+
+	// Unlocking never sets the master password
+	if err := vault.Unlock(ctx, "correct horse battery staple"); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("Unlock before Initialize: got %v, want ErrNotInitialized", err)
+	}
+	if status := vault.GetStatus(ctx); status["initialized"] != false || status["unlocked"] != false {
+		t.Errorf("status before Initialize = %v, want neither initialized nor unlocked", status)
+	}
+
+	if err := vault.Initialize(ctx, "correct horse battery staple"); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	if !vault.IsUnlocked() {
+		t.Error("vault is locked after Initialize")
+	}
+
+	// The master password is set once
+	if err := vault.Initialize(ctx, "another password"); !errors.Is(err, ErrConflict) {
+		t.Errorf("second Initialize: got %v, want ErrConflict", err)
+	}
+
+	vault.Lock()
+	if status := vault.GetStatus(ctx); status["initialized"] != true || status["unlocked"] != false {
+		t.Errorf("status after Lock = %v, want initialized and locked", status)
+	}
+	if err := vault.Unlock(ctx, "another password"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("Unlock with another password: got %v, want ErrInvalidPassword", err)
+	}
+	if err := vault.Unlock(ctx, "correct horse battery staple"); err != nil {
+		t.Errorf("Unlock: %v", err)
+	}
+}
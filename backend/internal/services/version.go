@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+// versionPruneInterval is how often the background job looks for expired versions
+const versionPruneInterval = time.Hour
+
+// VersionRetention limits how much secret history is kept.
+// Zero values disable the respective limit.
+type VersionRetention struct {
+	MaxVersions int
+	MaxAge      time.Duration
+}
+
+// SetVersionRetention sets the retention applied after each versioned update.
+// The age limit is also enforced on all secrets by StartVersionPrune.
+func (s *SecretService) SetVersionRetention(retention VersionRetention) {
+	s.retention = retention
+}
+
+// ListVersions returns the version history of a secret, current version first
+func (s *SecretService) ListVersions(ctx context.Context, id string) ([]*models.SecretVersionInfo, error) {
+	if !s.vaultService.IsUnlocked() {
+		return nil, fmt.Errorf("vault is locked")
+	}
+
+	secret, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	if err := s.policyService.Authorize(ctx, models.ActionRead, secretAttributes(secret)); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.repo.ListVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := []*models.SecretVersionInfo{{
+		Version:   secret.Version,
+		Title:     secret.Title,
+		Type:      secret.Type,
+		Author:    secret.UpdatedBy,
+		Current:   true,
+		CreatedAt: secret.UpdatedAt,
+	}}
+	for _, v := range versions {
+		infos = append(infos, &models.SecretVersionInfo{
+			Version:   v.Version,
+			Title:     v.Title,
+			Type:      v.Type,
+			Author:    v.Author,
+			CreatedAt: v.CreatedAt,
+		})
+	}
+
+	return infos, nil
+}
+
+// GetVersion decrypts one version of a secret
+func (s *SecretService) GetVersion(ctx context.Context, id string, version int) (*models.SecretResponse, error) {
+	key, err := s.vaultService.GetKey()
+	if err != nil {
+		return nil, fmt.Errorf("vault is locked: %w", err)
+	}
+
+	secret, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	// Authorize before looking up the version, so callers cannot probe which versions exist
+	evaluator, err := s.policyService.Evaluator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !evaluator.Allows(models.ActionRead, secretAttributes(secret)) {
+		return nil, fmt.Errorf("%w: %s not permitted", ErrAccessDenied, models.ActionRead)
+	}
+
+	if version == secret.Version {
+		return s.reveal(ctx, secret, key)
+	}
+
+	v, err := s.repo.GetVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// The caller must be allowed to read the secret both as it is now and as it was
+	archived := secretAtVersion(secret, v)
+	if !evaluator.Allows(models.ActionRead, secretAttributes(archived)) {
+		return nil, fmt.Errorf("%w: %s not permitted", ErrAccessDenied, models.ActionRead)
+	}
+
+	return s.reveal(ctx, archived, key)
+}
+
+// RestoreVersion makes a prior version current again, archiving the current
+// content, provided the secret is still at the revision the caller read
+func (s *SecretService) RestoreVersion(ctx context.Context, id string, revision, version int) (*models.SecretResponse, error) {
+	key, err := s.vaultService.GetKey()
+	if err != nil {
+		return nil, fmt.Errorf("vault is locked: %w", err)
+	}
+
+	secret, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	// Authorize before looking up the version, so callers cannot probe which versions exist
+	evaluator, err := s.policyService.Evaluator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !evaluator.Allows(models.ActionUpdate, secretAttributes(secret)) {
+		return nil, fmt.Errorf("%w: %s not permitted", ErrAccessDenied, models.ActionUpdate)
+	}
+	if secret.Revision != revision {
+		return nil, fmt.Errorf("secret %w", ErrRevisionMismatch)
+	}
+
+	if version == secret.Version {
+		return nil, fmt.Errorf("%w: version %d is already current", ErrValidation, version)
+	}
+
+	v, err := s.repo.GetVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// The caller must be allowed to update the secret both as it is now and as restored
+	restored := secretAtVersion(secret, v)
+	if !evaluator.Allows(models.ActionUpdate, secretAttributes(restored)) {
+		return nil, fmt.Errorf("%w: %s not permitted", ErrAccessDenied, models.ActionUpdate)
+	}
+
+	restored.UpdatedBy = PrincipalFromContext(ctx).String()
+	restored.RotatedAt = time.Now()
+	if err := s.repo.UpdateWithVersion(ctx, restored); err != nil {
+		return nil, fmt.Errorf("failed to restore version: %w", err)
+	}
+	s.pruneVersions(ctx, id)
+
+	// Callers who may restore but not read the secret only get its metadata back
+	if !evaluator.Allows(models.ActionRead, secretAttributes(restored)) {
+		return metadataResponse(restored), nil
+	}
+	return s.reveal(ctx, restored, key)
+}
+
+// pruneVersions applies the retention limits to a secret's history.
+// Failures are logged rather than returned since the update itself succeeded.
+func (s *SecretService) pruneVersions(ctx context.Context, id string) {
+	var olderThan time.Time
+	if s.retention.MaxAge > 0 {
+		olderThan = time.Now().Add(-s.retention.MaxAge)
+	}
+
+	if s.retention.MaxVersions <= 0 && olderThan.IsZero() {
+		return
+	}
+
+	if err := s.repo.PruneVersions(ctx, id, s.retention.MaxVersions, olderThan); err != nil {
+		log.Printf("Failed to prune versions of secret %s: %v", id, err)
+	}
+}
+
+// StartVersionPrune starts a background job that deletes prior versions older
+// than maxAge from every secret, so history expires even on secrets that are no
+// longer updated. It runs until the returned function is called.
+func (s *SecretService) StartVersionPrune(maxAge time.Duration) (stop func()) {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(versionPruneInterval)
+		defer ticker.Stop()
+
+		for {
+			s.pruneExpiredVersions(maxAge)
+
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// pruneExpiredVersions runs one pass of the version prune job
+func (s *SecretService) pruneExpiredVersions(maxAge time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	pruned, err := s.repo.PruneVersionsBefore(ctx, time.Now().Add(-maxAge))
+	if errors.Is(err, ErrLocked) {
+		// Retried on the next pass once the vault is unlocked
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to prune versions: %v", err)
+		return
+	}
+
+	if pruned > 0 {
+		log.Printf("Pruned %d expired secret versions", pruned)
+	}
+}
+
+// secretAtVersion returns a copy of secret carrying the content of an archived version
+func secretAtVersion(secret *models.Secret, v *models.SecretVersion) *models.Secret {
+	archived := *secret
+	archived.Title = v.Title
+	archived.Type = v.Type
+	archived.EncryptedValue = v.EncryptedValue
+	archived.Fields = v.Fields
+	archived.Version = v.Version
+	archived.UpdatedBy = v.Author
+	archived.UpdatedAt = v.CreatedAt
+	return &archived
+}
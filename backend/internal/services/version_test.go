@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"my-vault/internal/models"
+)
+
+func TestVersionAccess(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+
+	_, err := service.policyService.Create(ctx, &models.CreatePolicyRequest{
+		Name: "ops-writers",
+		Document: models.PolicyDocument{Statements: []models.PolicyStatement{
+			{Effect: models.EffectAllow, Actions: []string{models.ActionUpdate}, Resource: "secrets", Conditions: map[string][]string{"tag": {"ops"}}},
+		}},
+		Subjects: []models.PolicySubject{{Type: models.SubjectUser, ID: "bob"}},
+	})
+	if err != nil {
+		t.Fatalf("Create policy: %v", err)
+	}
+	bob := WithPrincipal(context.Background(), Principal{User: "bob"})
+
+	created, err := service.Create(ctx, &models.CreateSecretRequest{Title: "Pager", Type: "api_token", Value: "pd_123", Tags: []string{"ops"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	updated, err := service.Update(ctx, created.ID, created.Revision, &models.UpdateSecretRequest{Title: "Pager", Type: "api_token", Value: "pd_456", Tags: []string{"ops"}})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	// Existing and missing versions look the same to a caller who may not read
+	for _, version := range []int{1, 2, 99} {
+		if _, err := service.GetVersion(bob, created.ID, version); !errors.Is(err, ErrAccessDenied) {
+			t.Errorf("GetVersion(%d) without read: got %v, want ErrAccessDenied", version, err)
+		}
+	}
+
+	// Restoring is allowed, but only the metadata comes back
+	restored, err := service.RestoreVersion(bob, created.ID, updated.Revision, 1)
+	if err != nil {
+		t.Fatalf("RestoreVersion: %v", err)
+	}
+	if restored.Value != "" || restored.Data != nil || len(restored.Fields) != 0 {
+		t.Errorf("RestoreVersion revealed value %q to a caller who may not read", restored.Value)
+	}
+	if restored.Version != updated.Version+1 {
+		t.Errorf("RestoreVersion = version %d, want %d", restored.Version, updated.Version+1)
+	}
+
+	got, err := service.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Value != "pd_123" {
+		t.Errorf("value after restore = %q, want pd_123", got.Value)
+	}
+
+	// Restores are refused once the secret changed since it was read
+	if _, err := service.RestoreVersion(ctx, created.ID, updated.Revision, 2); !errors.Is(err, ErrRevisionMismatch) {
+		t.Errorf("RestoreVersion at a stale revision: got %v, want ErrRevisionMismatch", err)
+	}
+
+	// The owner restoring gets the value
+	restored, err = service.RestoreVersion(ctx, created.ID, restored.Revision, 2)
+	if err != nil {
+		t.Fatalf("RestoreVersion: %v", err)
+	}
+	if restored.Value != "pd_456" {
+		t.Errorf("RestoreVersion value = %q, want pd_456", restored.Value)
+	}
+	if _, err := service.RestoreVersion(ctx, created.ID, restored.Revision, 99); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RestoreVersion of a missing version: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestPruneExpiredVersions(t *testing.T) {
+	ctx := ownerContext()
+	service, _ := newTestSecretService(t)
+
+	created, err := service.Create(ctx, &models.CreateSecretRequest{Title: "Pager", Type: "api_token", Value: "pd_123"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := service.Update(ctx, created.ID, created.Revision, &models.UpdateSecretRequest{Title: "Pager", Type: "api_token", Value: "pd_456"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	// Nothing in the history is an hour old yet
+	service.pruneExpiredVersions(time.Hour)
+	versions, err := service.ListVersions(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("ListVersions: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("ListVersions = %d versions, want the current and one prior", len(versions))
+	}
+
+	// Expired history goes without the secret being written again
+	service.pruneExpiredVersions(0)
+	versions, err = service.ListVersions(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("ListVersions: %v", err)
+	}
+	if len(versions) != 1 || !versions[0].Current {
+		t.Errorf("ListVersions after expiry = %d versions, want the current one only", len(versions))
+	}
+	if _, err := service.GetVersion(ctx, created.ID, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetVersion of an expired version: got %v, want ErrNotFound", err)
+	}
+}
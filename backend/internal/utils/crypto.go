@@ -3,24 +3,29 @@ package utils
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"io"
 
 	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
 )
 
 const (
 	// Argon2id parameters
-	time    = 1
-	memory  = 64 * 1024 // 64MB
-	threads = 4
-	keyLen  = 32 // 256 bits for AES-256
+	argonTime = 1
+	memory    = 64 * 1024 // 64MB
+	threads   = 4
+	keyLen    = 32 // 256 bits for AES-256
 )
 
 // DeriveKey derives a key from a password using Argon2id
 func DeriveKey(password string, salt []byte) []byte {
-	return argon2.IDKey([]byte(password), salt, time, memory, threads, keyLen)
+	return argon2.IDKey([]byte(password), salt, argonTime, memory, threads, keyLen)
 }
 
 // GenerateSalt generates a random salt for key derivation
@@ -79,6 +84,23 @@ func Decrypt(ciphertext []byte, key []byte) ([]byte, error) {
 	return plaintext, nil
 }
 
+// DeriveSubkey derives a purpose-specific key from the vault key using HKDF-SHA256,
+// so keyed hashes never reuse the encryption key itself
+func DeriveSubkey(key []byte, purpose string) ([]byte, error) {
+	subkey := make([]byte, keyLen)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(purpose)), subkey); err != nil {
+		return nil, fmt.Errorf("failed to derive subkey: %w", err)
+	}
+	return subkey, nil
+}
+
+// KeyedHash returns the hex-encoded HMAC-SHA256 of value under key
+func KeyedHash(key []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 // EncodeToBase64 encodes bytes to base64 string
 func EncodeToBase64(data []byte) string {
 	return base64.StdEncoding.EncodeToString(data)
@@ -87,4 +109,4 @@ func EncodeToBase64(data []byte) string {
 // DecodeFromBase64 decodes base64 string to bytes
 func DecodeFromBase64(s string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(s)
-} 
\ No newline at end of file
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	_ "embed"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// effLargeWordlist is the EFF large diceware wordlist (7776 words), published by
+// the Electronic Frontier Foundation under CC BY 3.0:
+// https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+//
+//go:embed wordlist/eff_large_wordlist.txt
+var effLargeWordlist []byte
+
+// Wordlist returns the bundled diceware wordlist
+var Wordlist = sync.OnceValue(func() []string {
+	words := make([]string, 0, 7776)
+	scanner := bufio.NewScanner(bytes.NewReader(effLargeWordlist))
+	for scanner.Scan() {
+		// Lines are "<dice rolls>\t<word>"
+		if _, word, ok := strings.Cut(scanner.Text(), "\t"); ok {
+			words = append(words, word)
+		}
+	}
+	return words
+})
+
+// RandomIndex returns a uniformly random integer in [0, n) from a secure source
+func RandomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number: %w", err)
+	}
+	return int(i.Int64()), nil
+}
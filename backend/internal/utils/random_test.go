@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWordlist(t *testing.T) {
+	words := Wordlist()
+	if len(words) != 7776 {
+		t.Fatalf("Wordlist has %d words, want 7776", len(words))
+	}
+
+	seen := make(map[string]bool, len(words))
+	for _, word := range words {
+		if word == "" || strings.TrimSpace(word) != word {
+			t.Errorf("malformed word %q", word)
+		}
+		if seen[word] {
+			t.Errorf("duplicate word %q", word)
+		}
+		seen[word] = true
+	}
+}
+
+func TestRandomIndex(t *testing.T) {
+	if i, err := RandomIndex(1); err != nil || i != 0 {
+		t.Errorf("RandomIndex(1) = %d, %v; want 0", i, err)
+	}
+
+	// Every value in range shows up and none outside it
+	const n = 10
+	counts := make([]int, n)
+	for range 10000 {
+		i, err := RandomIndex(n)
+		if err != nil {
+			t.Fatalf("RandomIndex: %v", err)
+		}
+		if i < 0 || i >= n {
+			t.Fatalf("RandomIndex(%d) = %d, out of range", n, i)
+		}
+		counts[i]++
+	}
+	for i, count := range counts {
+		// Expect 1000 each; this bound fails by chance far less than once in a billion runs
+		if count < 800 || count > 1200 {
+			t.Errorf("value %d drawn %d times out of 10000, want about 1000", i, count)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/youmark/pkcs8"
+	"golang.org/x/crypto/ssh"
+)
+
+// SSH key algorithms
+const (
+	SSHKeyEd25519 = "ed25519"
+	SSHKeyRSA     = "rsa"
+)
+
+// SSH private key export formats
+const (
+	SSHFormatOpenSSH = "openssh"
+	SSHFormatPEM     = "pem"
+)
+
+// ErrSSHPassphraseMissing is returned when parsing an encrypted key without its passphrase
+var ErrSSHPassphraseMissing = errors.New("private key is encrypted")
+
+// pemKeyOpts encrypts exported PEM keys as PKCS#8 with PBES2, PBKDF2 and AES-256-CBC
+var pemKeyOpts = &pkcs8.Opts{
+	Cipher: pkcs8.AES256CBC,
+	KDFOpts: pkcs8.PBKDF2Opts{
+		SaltSize:       16,
+		IterationCount: 600000,
+		HMACHash:       crypto.SHA256,
+	},
+}
+
+// GenerateSSHKey generates an Ed25519 or RSA private key; bits applies to RSA only
+func GenerateSSHKey(algorithm string, bits int) (crypto.Signer, error) {
+	switch algorithm {
+	case SSHKeyEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+		return key, nil
+	case SSHKeyRSA:
+		key, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm %q", algorithm)
+	}
+}
+
+// ParseSSHPrivateKey parses a PEM, PKCS#8 or OpenSSH private key, decrypting it
+// with passphrase when it is encrypted
+func ParseSSHPrivateKey(data string, passphrase []byte) (crypto.Signer, error) {
+	// PKCS#8 encrypted keys are not understood by the ssh package
+	if block, _ := pem.Decode([]byte(data)); block != nil && block.Type == "ENCRYPTED PRIVATE KEY" {
+		if len(passphrase) == 0 {
+			return nil, ErrSSHPassphraseMissing
+		}
+		raw, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %w", err)
+		}
+		return asSigner(raw)
+	}
+
+	// The passphrase is only used when the key turns out to be encrypted
+	raw, err := ssh.ParseRawPrivateKey([]byte(data))
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		if len(passphrase) == 0 {
+			return nil, ErrSSHPassphraseMissing
+		}
+		raw, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(data), passphrase)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+
+	return asSigner(raw)
+}
+
+// asSigner normalizes a parsed private key to a crypto.Signer
+func asSigner(raw any) (crypto.Signer, error) {
+	switch key := raw.(type) {
+	case *ed25519.PrivateKey:
+		return *key, nil
+	case crypto.Signer:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", raw)
+	}
+}
+
+// MarshalSSHPrivateKey encodes a private key in the OpenSSH or PEM (PKCS#8) format,
+// encrypted with passphrase unless it is empty
+func MarshalSSHPrivateKey(key crypto.Signer, format, comment string, passphrase []byte) (string, error) {
+	var block *pem.Block
+
+	switch format {
+	case SSHFormatOpenSSH:
+		var err error
+		if len(passphrase) > 0 {
+			block, err = ssh.MarshalPrivateKeyWithPassphrase(key, comment, passphrase)
+		} else {
+			block, err = ssh.MarshalPrivateKey(key, comment)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to encode private key: %w", err)
+		}
+	case SSHFormatPEM:
+		der, err := pkcs8.MarshalPrivateKey(key, passphrase, pemKeyOpts)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode private key: %w", err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+		if len(passphrase) > 0 {
+			block.Type = "ENCRYPTED PRIVATE KEY"
+		}
+	default:
+		return "", fmt.Errorf("unsupported key format %q", format)
+	}
+
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+// SSHPublicKey returns the authorized_keys line and SHA256 fingerprint of a private key
+func SSHPublicKey(key crypto.Signer, comment string) (string, string, error) {
+	public, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to derive public key: %w", err)
+	}
+
+	authorized := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(public)))
+	if comment != "" {
+		authorized += " " + comment
+	}
+
+	return authorized, ssh.FingerprintSHA256(public), nil
+}
+
+// SSHFingerprint returns the SHA256 fingerprint of an authorized_keys line
+func SSHFingerprint(authorizedKey string) (string, error) {
+	public, _, _, _, err := ssh.ParseAuthorizedKey([]byte(authorizedKey))
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+	return ssh.FingerprintSHA256(public), nil
+}
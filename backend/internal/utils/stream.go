@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Chunked AEAD stream format:
+//
+//	version (1 byte) | salt (32 bytes) | chunk | chunk | ...
+//
+// Each stream encrypts with its own AES-256-GCM key derived from the vault key
+// and the random salt. Every chunk holds up to streamChunkSize bytes of
+// plaintext sealed with a nonce made of a chunk counter and a flag marking the
+// final chunk, so reordered, dropped or truncated chunks fail to decrypt. The
+// caller's additional data, such as the ID of what the stream stores, is
+// authenticated with every chunk, so a stream moved elsewhere fails as well.
+const (
+	streamVersion   = 1
+	streamSaltSize  = 32
+	streamChunkSize = 64 * 1024
+	streamTagSize   = 16
+)
+
+// streamInfo separates stream keys from any other key derived from the vault key
+var streamInfo = []byte("my-vault stream v1")
+
+// NewEncryptWriter returns a writer encrypting everything written to it into w
+// as a chunked AEAD stream bound to additionalData. Close must be called to
+// seal the final chunk.
+func NewEncryptWriter(w io.Writer, key, additionalData []byte) (io.WriteCloser, error) {
+	salt := make([]byte, streamSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	aead, err := streamAEAD(key, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(append([]byte{streamVersion}, salt...)); err != nil {
+		return nil, fmt.Errorf("failed to write stream header: %w", err)
+	}
+
+	return &encryptWriter{
+		w:    w,
+		aead: aead,
+		ad:   additionalData,
+		buf:  make([]byte, 0, streamChunkSize+streamTagSize),
+	}, nil
+}
+
+// NewDecryptReader returns a reader decrypting a chunked AEAD stream from r.
+// Reads fail if the stream was tampered with or truncated, or was encrypted
+// with other additional data.
+func NewDecryptReader(r io.Reader, key, additionalData []byte) (io.Reader, error) {
+	header := make([]byte, 1+streamSaltSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read stream header: %w", err)
+	}
+	if header[0] != streamVersion {
+		return nil, fmt.Errorf("unsupported stream version %d", header[0])
+	}
+
+	aead, err := streamAEAD(key, header[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{
+		r:    bufio.NewReader(r),
+		aead: aead,
+		ad:   additionalData,
+		buf:  make([]byte, streamChunkSize+streamTagSize),
+	}, nil
+}
+
+// encryptWriter buffers plaintext and seals it chunk by chunk
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	ad      []byte
+	buf     []byte
+	counter uint32
+	closed  bool
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("write to closed stream")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// A full chunk is only sealed once more data follows so the last one can be flagged
+		if len(e.buf) == streamChunkSize {
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(e.buf[len(e.buf):streamChunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+
+	return written, nil
+}
+
+// Close seals the final chunk; it does not close the underlying writer
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	return e.seal(true)
+}
+
+func (e *encryptWriter) seal(last bool) error {
+	if e.counter == math.MaxUint32 {
+		return errors.New("stream too large")
+	}
+
+	ciphertext := e.aead.Seal(e.buf[:0], streamNonce(e.counter, last), e.buf, e.ad)
+	if _, err := e.w.Write(ciphertext); err != nil {
+		return fmt.Errorf("failed to write chunk: %w", err)
+	}
+
+	e.buf = e.buf[:0]
+	e.counter++
+	return nil
+}
+
+// decryptReader opens one chunk at a time and hands out its plaintext
+type decryptReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	ad      []byte
+	buf     []byte
+	plain   []byte
+	counter uint32
+	done    bool
+	err     error
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.open()
+	}
+
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *decryptReader) open() error {
+	n, err := io.ReadFull(d.r, d.buf)
+
+	var last bool
+	switch {
+	case errors.Is(err, io.EOF):
+		return errors.New("stream truncated")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		// Only the final chunk may be shorter than a full one
+		last = true
+	case err != nil:
+		return fmt.Errorf("failed to read chunk: %w", err)
+	default:
+		if _, err := d.r.Peek(1); errors.Is(err, io.EOF) {
+			last = true
+		} else if err != nil {
+			return fmt.Errorf("failed to read chunk: %w", err)
+		}
+	}
+
+	plain, err := d.aead.Open(d.buf[:0], streamNonce(d.counter, last), d.buf[:n], d.ad)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt chunk %d: %w", d.counter, err)
+	}
+
+	d.plain = plain
+	d.counter++
+	d.done = last
+	return nil
+}
+
+// streamAEAD derives the per-stream key from the vault key and salt
+func streamAEAD(key, salt []byte) (cipher.AEAD, error) {
+	streamKey := make([]byte, keyLen)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, salt, streamInfo), streamKey); err != nil {
+		return nil, fmt.Errorf("failed to derive stream key: %w", err)
+	}
+
+	block, err := aes.NewCipher(streamKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return gcm, nil
+}
+
+// streamNonce builds the nonce of a chunk: zero padding, big-endian counter, last-chunk flag
+func streamNonce(counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint32(nonce[7:11], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
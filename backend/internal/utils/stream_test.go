@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+)
+
+const (
+	streamHeaderSize  = 1 + streamSaltSize
+	streamSealedChunk = streamChunkSize + streamTagSize
+)
+
+// testStreamID is the additional data the test streams are bound to
+var testStreamID = []byte("attachment-1")
+
+func encryptStream(t *testing.T, key, additionalData, plaintext []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := NewEncryptWriter(&buf, key, additionalData)
+	if err != nil {
+		t.Fatalf("NewEncryptWriter: %v", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func decryptStream(key, additionalData, ciphertext []byte) ([]byte, error) {
+	r, err := NewDecryptReader(bytes.NewReader(ciphertext), key, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("rand.Read: %v", err)
+	}
+	return b
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	key := randomBytes(t, keyLen)
+
+	for _, size := range []int{0, 1, streamChunkSize - 1, streamChunkSize, streamChunkSize + 1, 3*streamChunkSize + 5} {
+		plaintext := randomBytes(t, size)
+		ciphertext := encryptStream(t, key, testStreamID, plaintext)
+
+		chunks := size/streamChunkSize + 1
+		if size > 0 && size%streamChunkSize == 0 {
+			chunks--
+		}
+		if want := streamHeaderSize + size + chunks*streamTagSize; len(ciphertext) != want {
+			t.Errorf("size %d: ciphertext is %d bytes, want %d", size, len(ciphertext), want)
+		}
+
+		got, err := decryptStream(key, testStreamID, ciphertext)
+		if err != nil {
+			t.Errorf("size %d: decrypt: %v", size, err)
+			continue
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("size %d: decrypted content differs", size)
+		}
+	}
+}
+
+func TestStreamSmallWrites(t *testing.T) {
+	key := randomBytes(t, keyLen)
+	plaintext := randomBytes(t, 2*streamChunkSize+100)
+
+	var buf bytes.Buffer
+	w, err := NewEncryptWriter(&buf, key, testStreamID)
+	if err != nil {
+		t.Fatalf("NewEncryptWriter: %v", err)
+	}
+	for rest := plaintext; len(rest) > 0; {
+		n := min(len(rest), 1000)
+		if _, err := w.Write(rest[:n]); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		rest = rest[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := w.Write([]byte("late")); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+
+	got, err := decryptStream(key, testStreamID, buf.Bytes())
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Error("decrypted content differs")
+	}
+}
+
+func TestStreamRejectsDamage(t *testing.T) {
+	key := randomBytes(t, keyLen)
+	ciphertext := encryptStream(t, key, testStreamID, randomBytes(t, 2*streamChunkSize+100))
+	firstChunk := streamHeaderSize
+	secondChunk := firstChunk + streamSealedChunk
+	thirdChunk := secondChunk + streamSealedChunk
+
+	flip := func(offset int) []byte {
+		damaged := bytes.Clone(ciphertext)
+		damaged[offset] ^= 0x01
+		return damaged
+	}
+
+	tests := []struct {
+		name       string
+		key        []byte
+		ciphertext []byte
+	}{
+		{name: "header only", key: key, ciphertext: ciphertext[:streamHeaderSize]},
+		{name: "final chunk dropped", key: key, ciphertext: ciphertext[:thirdChunk]},
+		{name: "final chunk cut short", key: key, ciphertext: ciphertext[:len(ciphertext)-1]},
+		{name: "middle chunk cut short", key: key, ciphertext: ciphertext[:secondChunk+100]},
+		{
+			name: "chunks reordered",
+			key:  key,
+			ciphertext: bytes.Join([][]byte{
+				ciphertext[:firstChunk],
+				ciphertext[secondChunk:thirdChunk],
+				ciphertext[firstChunk:secondChunk],
+				ciphertext[thirdChunk:],
+			}, nil),
+		},
+		{
+			name:       "chunk duplicated",
+			key:        key,
+			ciphertext: bytes.Join([][]byte{ciphertext[:secondChunk], ciphertext[firstChunk:]}, nil),
+		},
+		{name: "ciphertext tampered", key: key, ciphertext: flip(secondChunk + 10)},
+		{name: "tag tampered", key: key, ciphertext: flip(len(ciphertext) - 1)},
+		{name: "salt tampered", key: key, ciphertext: flip(1)},
+		{name: "unknown version", key: key, ciphertext: flip(0)},
+		{name: "wrong key", key: randomBytes(t, keyLen), ciphertext: ciphertext},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decryptStream(tt.key, testStreamID, tt.ciphertext); err == nil {
+				t.Error("damaged stream decrypted without error")
+			}
+		})
+	}
+}
+
+func TestStreamRejectsSwappedBlobs(t *testing.T) {
+	key := randomBytes(t, keyLen)
+	first := encryptStream(t, key, []byte("attachment-1"), []byte("recovery codes"))
+	second := encryptStream(t, key, []byte("attachment-2"), []byte("backup key"))
+
+	if got, err := decryptStream(key, []byte("attachment-2"), second); err != nil || string(got) != "backup key" {
+		t.Fatalf("decrypt = %q, %v; want the content back", got, err)
+	}
+
+	// A blob copied over another attachment's fails to decrypt
+	if _, err := decryptStream(key, []byte("attachment-2"), first); err == nil {
+		t.Error("stream decrypted under another attachment's ID")
+	}
+	if _, err := decryptStream(key, nil, first); err == nil {
+		t.Error("stream decrypted without its additional data")
+	}
+}
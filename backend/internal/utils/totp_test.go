@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"encoding/base32"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// RFC 6238 Appendix B seeds, one per algorithm
+var rfc6238Seeds = map[string]string{
+	TOTPSHA1:   "12345678901234567890",
+	TOTPSHA256: "12345678901234567890123456789012",
+	TOTPSHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix      int64
+		algorithm string
+		want      string
+	}{
+		{59, TOTPSHA1, "94287082"},
+		{59, TOTPSHA256, "46119246"},
+		{59, TOTPSHA512, "90693936"},
+		{1111111109, TOTPSHA1, "07081804"},
+		{1111111109, TOTPSHA256, "68084774"},
+		{1111111109, TOTPSHA512, "25091201"},
+		{1111111111, TOTPSHA1, "14050471"},
+		{1111111111, TOTPSHA256, "67062674"},
+		{1111111111, TOTPSHA512, "99943326"},
+		{1234567890, TOTPSHA1, "89005924"},
+		{1234567890, TOTPSHA256, "91819424"},
+		{1234567890, TOTPSHA512, "93441116"},
+		{2000000000, TOTPSHA1, "69279037"},
+		{2000000000, TOTPSHA256, "90698825"},
+		{2000000000, TOTPSHA512, "38618901"},
+		{20000000000, TOTPSHA1, "65353130"},
+		{20000000000, TOTPSHA256, "77737706"},
+		{20000000000, TOTPSHA512, "47863826"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.algorithm, tt.unix), func(t *testing.T) {
+			// Go through the otpauth URI so parsing is covered too
+			seed := base32.StdEncoding.EncodeToString([]byte(rfc6238Seeds[tt.algorithm]))
+			totp, err := ParseTOTP(fmt.Sprintf("otpauth://totp/RFC:6238?secret=%s&algorithm=%s&digits=8&period=30", seed, tt.algorithm))
+			if err != nil {
+				t.Fatalf("ParseTOTP: %v", err)
+			}
+
+			at := time.Unix(tt.unix, 0)
+			if got := totp.Code(at); got != tt.want {
+				t.Errorf("Code = %s, want %s", got, tt.want)
+			}
+
+			// Six digit codes are the low digits of the same value
+			totp.Digits = 6
+			if got := totp.Code(at); got != tt.want[2:] {
+				t.Errorf("6 digit Code = %s, want %s", got, tt.want[2:])
+			}
+		})
+	}
+}
+
+func TestParseTOTP(t *testing.T) {
+	seed := base32.StdEncoding.EncodeToString([]byte(rfc6238Seeds[TOTPSHA1]))
+
+	totp, err := ParseTOTP("  gezd gnbv gy3t qojq gezd gnbv gy3t qojq  ")
+	if err != nil {
+		t.Fatalf("ParseTOTP of a bare seed: %v", err)
+	}
+	if string(totp.Secret) != rfc6238Seeds[TOTPSHA1] || totp.Algorithm != TOTPSHA1 || totp.Digits != 6 || totp.Period != 30 {
+		t.Errorf("bare seed parsed as %+v, want the RFC seed with SHA1, 6 digits, 30s", totp)
+	}
+	if got := totp.Remaining(time.Unix(59, 0)); got != 1 {
+		t.Errorf("Remaining at 59s = %d, want 1", got)
+	}
+
+	for _, uri := range []string{
+		"",
+		"not base32!",
+		"otpauth://hotp/Example?secret=" + seed,
+		"otpauth://totp/Example?secret=" + seed + "&algorithm=MD5",
+		"otpauth://totp/Example?secret=" + seed + "&digits=7",
+		"otpauth://totp/Example?secret=" + seed + "&period=0",
+		"otpauth://totp/Example",
+	} {
+		if _, err := ParseTOTP(uri); err == nil {
+			t.Errorf("ParseTOTP(%q) succeeded", uri)
+		}
+	}
+}